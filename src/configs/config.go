@@ -12,9 +12,17 @@ import (
 )
 
 type Config struct {
-	ServerPort string             `mapstructure:"server_port"`
-	LogConfig  *loggers.LogConfig `mapstructure:"log_config"`
-	DBConfig   *db.DBConfig       `mapstructure:"db_config"`
+	ServerPort  string             `mapstructure:"server_port"`
+	LogConfig   *loggers.LogConfig `mapstructure:"log_config"`
+	DBConfig    *db.DBConfig       `mapstructure:"db_config"`
+	PwdConfig   *PasswordConfig    `mapstructure:"password_config"`
+	TokenConfig *TokenConfig       `mapstructure:"token_config"`
+	LoginConfig *LoginLimitConfig  `mapstructure:"login_limit_config"`
+	UserConfig  *UserConfig        `mapstructure:"user_config"`
+	MailConfig  *MailConfig        `mapstructure:"mail_config"`
+	TotpConfig  *TotpConfig        `mapstructure:"totp_config"`
+	ExecConfig  *ExecutorConfig    `mapstructure:"executor_config"`
+	ScreenConf  *ScreeningConfig   `mapstructure:"screening_config"`
 }
 
 const DEFAULT_SERVER_PORT = "8096"
@@ -72,6 +80,83 @@ func InitConfig(configPath string) (*Config, error) {
 		return nil, errors.New("not found the db config")
 	}
 
+	err = checkDBConfig(conf.DBConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.PwdConfig == nil {
+		conf.PwdConfig = new(PasswordConfig)
+	}
+
+	err = checkPasswordConfig(conf.PwdConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.TokenConfig == nil {
+		conf.TokenConfig = new(TokenConfig)
+	}
+
+	err = checkTokenConfig(conf.TokenConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.LoginConfig == nil {
+		conf.LoginConfig = new(LoginLimitConfig)
+	}
+
+	err = checkLoginLimitConfig(conf.LoginConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.UserConfig == nil {
+		conf.UserConfig = new(UserConfig)
+	}
+
+	err = checkUserConfig(conf.UserConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.MailConfig == nil {
+		conf.MailConfig = new(MailConfig)
+	}
+
+	err = checkMailConfig(conf.MailConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.TotpConfig == nil {
+		conf.TotpConfig = &TotpConfig{Skew: DEFAULT_TOTP_SKEW}
+	}
+
+	err = checkTotpConfig(conf.TotpConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.ExecConfig == nil {
+		conf.ExecConfig = &ExecutorConfig{SimulateDelay: DEFAULT_EXECUTOR_SIMULATE_DELAY}
+	}
+
+	err = checkExecutorConfig(conf.ExecConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.ScreenConf == nil {
+		conf.ScreenConf = new(ScreeningConfig)
+	}
+
+	err = checkScreeningConfig(conf.ScreenConf)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(conf.ServerPort) == 0 {
 		conf.ServerPort = DEFAULT_SERVER_PORT
 	}
@@ -0,0 +1,28 @@
+package configs
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+)
+
+const DEFAULT_POSTGRES_SSL_MODE = "disable"
+
+func checkDBConfig(dbConf *db.DBConfig) error {
+	switch dbConf.Driver {
+	case "":
+		dbConf.Driver = db.DB_DRIVER_MYSQL
+	case db.DB_DRIVER_MYSQL, db.DB_DRIVER_SQLITE:
+	case db.DB_DRIVER_POSTGRES:
+		if len(dbConf.SslMode) == 0 {
+			dbConf.SslMode = DEFAULT_POSTGRES_SSL_MODE
+		}
+	default:
+		return errors.New("the db driver must be mysql, sqlite or postgres")
+	}
+
+	if len(dbConf.DbName) == 0 {
+		return errors.New("the db name is empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package configs
+
+import (
+	"errors"
+	"time"
+)
+
+// 指令的执行方式，配置文件定义的常量
+const (
+	// EXECUTOR_MODE_LOCAL 由服务内的执行器执行
+	EXECUTOR_MODE_LOCAL = "local"
+
+	// EXECUTOR_MODE_REMOTE 由执行系统领取指令并上报结果
+	EXECUTOR_MODE_REMOTE = "remote"
+)
+
+const (
+	DEFAULT_EXECUTOR_MODE = EXECUTOR_MODE_LOCAL
+
+	DEFAULT_EXECUTOR_WORKERS = 4
+
+	DEFAULT_EXECUTOR_POLL_INTERVAL = time.Second
+
+	DEFAULT_EXECUTOR_TIMEOUT = 30 * time.Second
+
+	DEFAULT_EXECUTOR_MAX_ATTEMPTS = 3
+
+	DEFAULT_EXECUTOR_RETRY_BACKOFF = 5 * time.Second
+
+	DEFAULT_EXECUTOR_MAX_BACKOFF = 5 * time.Minute
+
+	DEFAULT_EXECUTOR_SIMULATE_DELAY = 500 * time.Millisecond
+)
+
+// ExecutorConfig 指令执行器的配置，待执行的指令保存在数据库中，重启后继续执行
+type ExecutorConfig struct {
+	// Mode: local or remote. In the remote mode Workers and SimulateDelay are not used
+	Mode string `mapstructure:"mode"`
+
+	// Workers is the number of instructions executed at the same time
+	Workers int `mapstructure:"workers"`
+
+	// PollInterval is the interval to check the queue when it is idle
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+
+	// Timeout is the default time limit of one attempt, the instruction can set its own.
+	// In the remote mode it is the deadline of the result after the instruction is claimed
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// MaxAttempts includes the first attempt
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// RetryBackoff doubles after every failed attempt until MaxBackoff
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+
+	// SimulateDelay 接入真实的执行系统前模拟的执行耗时
+	SimulateDelay time.Duration `mapstructure:"simulate_delay"`
+
+	// RequireApproval 操作规避指令需要提交人以外的用户审批后才进入执行队列
+	RequireApproval bool `mapstructure:"require_approval"`
+}
+
+func checkExecutorConfig(execConf *ExecutorConfig) error {
+	if len(execConf.Mode) == 0 {
+		execConf.Mode = DEFAULT_EXECUTOR_MODE
+	}
+	if execConf.Mode != EXECUTOR_MODE_LOCAL && execConf.Mode != EXECUTOR_MODE_REMOTE {
+		return errors.New("invalid executor mode")
+	}
+
+	if execConf.Workers <= 0 {
+		execConf.Workers = DEFAULT_EXECUTOR_WORKERS
+	}
+
+	if execConf.PollInterval <= 0 {
+		execConf.PollInterval = DEFAULT_EXECUTOR_POLL_INTERVAL
+	}
+
+	if execConf.Timeout <= 0 {
+		execConf.Timeout = DEFAULT_EXECUTOR_TIMEOUT
+	}
+
+	if execConf.MaxAttempts <= 0 {
+		execConf.MaxAttempts = DEFAULT_EXECUTOR_MAX_ATTEMPTS
+	}
+
+	if execConf.RetryBackoff <= 0 {
+		execConf.RetryBackoff = DEFAULT_EXECUTOR_RETRY_BACKOFF
+	}
+
+	if execConf.MaxBackoff < execConf.RetryBackoff {
+		execConf.MaxBackoff = DEFAULT_EXECUTOR_MAX_BACKOFF
+	}
+
+	if execConf.SimulateDelay < 0 {
+		execConf.SimulateDelay = DEFAULT_EXECUTOR_SIMULATE_DELAY
+	}
+
+	return nil
+}
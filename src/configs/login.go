@@ -0,0 +1,62 @@
+package configs
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// 登录失败锁定的对象，配置文件定义的常量
+const (
+	LOCK_MODE_USER = "user"
+
+	LOCK_MODE_IP = "ip"
+
+	LOCK_MODE_BOTH = "both"
+)
+
+const (
+	DEFAULT_LOGIN_MAX_FAILURES = 5
+
+	DEFAULT_LOGIN_FAIL_WINDOW = 15 * time.Minute
+
+	DEFAULT_LOGIN_LOCK_DURATION = 30 * time.Minute
+
+	DEFAULT_LOGIN_LOCK_MODE = LOCK_MODE_BOTH
+)
+
+// LoginLimitConfig 登录失败次数限制的配置
+type LoginLimitConfig struct {
+	// MaxFailures is the number of failures within the window that triggers the lockout
+	MaxFailures int32 `mapstructure:"max_failures"`
+
+	// FailWindow such as 15m
+	FailWindow time.Duration `mapstructure:"fail_window"`
+
+	// LockDuration such as 30m
+	LockDuration time.Duration `mapstructure:"lock_duration"`
+
+	// LockMode: user, ip or both
+	LockMode string `mapstructure:"lock_mode"`
+}
+
+func checkLoginLimitConfig(limitConf *LoginLimitConfig) error {
+	if limitConf.MaxFailures <= 0 {
+		limitConf.MaxFailures = DEFAULT_LOGIN_MAX_FAILURES
+	}
+	if limitConf.FailWindow <= 0 {
+		limitConf.FailWindow = DEFAULT_LOGIN_FAIL_WINDOW
+	}
+	if limitConf.LockDuration <= 0 {
+		limitConf.LockDuration = DEFAULT_LOGIN_LOCK_DURATION
+	}
+	if len(limitConf.LockMode) == 0 {
+		limitConf.LockMode = DEFAULT_LOGIN_LOCK_MODE
+	}
+	limitConf.LockMode = strings.ToLower(limitConf.LockMode)
+	if limitConf.LockMode != LOCK_MODE_USER && limitConf.LockMode != LOCK_MODE_IP &&
+		limitConf.LockMode != LOCK_MODE_BOTH {
+		return errors.New("invalid login lock mode")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package configs
+
+import "errors"
+
+const DEFAULT_SMTP_PORT = 25
+
+// MailConfig 发送邮件的 SMTP 配置，Host 为空时不发送邮件
+type MailConfig struct {
+	Host string `mapstructure:"host"`
+
+	Port int `mapstructure:"port"`
+
+	// UserName and Password are used for PLAIN auth, empty means no auth
+	UserName string `mapstructure:"user_name"`
+
+	Password string `mapstructure:"password"`
+
+	// From is the sender address
+	From string `mapstructure:"from"`
+
+	// UseTLS connect with implicit TLS, such as port 465.
+	// Otherwise STARTTLS is used when the server supports it
+	UseTLS bool `mapstructure:"use_tls"`
+}
+
+func checkMailConfig(mailConf *MailConfig) error {
+	if len(mailConf.Host) == 0 {
+		return nil
+	}
+
+	if mailConf.Port <= 0 {
+		mailConf.Port = DEFAULT_SMTP_PORT
+	}
+
+	if len(mailConf.From) == 0 {
+		return errors.New("the mail sender address is empty")
+	}
+
+	return nil
+}
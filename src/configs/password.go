@@ -0,0 +1,80 @@
+package configs
+
+import (
+	"errors"
+	"strings"
+)
+
+// 密码哈希算法，配置文件定义的常量
+const (
+	PWD_ALGORITHM_BCRYPT = "bcrypt"
+
+	PWD_ALGORITHM_ARGON2ID = "argon2id"
+)
+
+const (
+	DEFAULT_PWD_ALGORITHM = PWD_ALGORITHM_BCRYPT
+
+	DEFAULT_BCRYPT_COST = 12
+
+	// argon2id 推荐参数：3 次迭代，64MB 内存，2 个线程
+	DEFAULT_ARGON2_TIME = 3
+
+	DEFAULT_ARGON2_MEMORY = 64 * 1024
+
+	DEFAULT_ARGON2_THREADS = 2
+
+	DEFAULT_ARGON2_KEY_LEN = 32
+
+	DEFAULT_ARGON2_SALT_LEN = 16
+)
+
+// PasswordConfig 用户密码哈希的配置
+type PasswordConfig struct {
+	// Algorithm: bcrypt or argon2id
+	Algorithm string `mapstructure:"algorithm"`
+
+	BcryptCost int `mapstructure:"bcrypt_cost"`
+
+	// Argon2Time is the number of passes over the memory
+	Argon2Time uint32 `mapstructure:"argon2_time"`
+
+	// Argon2Memory is the size of the memory in KiB
+	Argon2Memory uint32 `mapstructure:"argon2_memory"`
+
+	Argon2Threads uint8 `mapstructure:"argon2_threads"`
+
+	Argon2KeyLen uint32 `mapstructure:"argon2_key_len"`
+
+	Argon2SaltLen uint32 `mapstructure:"argon2_salt_len"`
+}
+
+func checkPasswordConfig(pwdConf *PasswordConfig) error {
+	if len(pwdConf.Algorithm) == 0 {
+		pwdConf.Algorithm = DEFAULT_PWD_ALGORITHM
+	}
+	pwdConf.Algorithm = strings.ToLower(pwdConf.Algorithm)
+	if pwdConf.Algorithm != PWD_ALGORITHM_BCRYPT &&
+		pwdConf.Algorithm != PWD_ALGORITHM_ARGON2ID {
+		return errors.New("invalid password algorithm")
+	}
+	if pwdConf.BcryptCost == 0 {
+		pwdConf.BcryptCost = DEFAULT_BCRYPT_COST
+	}
+	if pwdConf.Argon2Time == 0 {
+		pwdConf.Argon2Time = DEFAULT_ARGON2_TIME
+	}
+	if pwdConf.Argon2Memory == 0 {
+		pwdConf.Argon2Memory = DEFAULT_ARGON2_MEMORY
+	}
+	if pwdConf.Argon2Threads == 0 {
+		pwdConf.Argon2Threads = DEFAULT_ARGON2_THREADS
+	}
+	if pwdConf.Argon2KeyLen == 0 {
+		pwdConf.Argon2KeyLen = DEFAULT_ARGON2_KEY_LEN
+	}
+	if pwdConf.Argon2SaltLen == 0 {
+		pwdConf.Argon2SaltLen = DEFAULT_ARGON2_SALT_LEN
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package configs
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DEFAULT_SCREENING_INTERVAL = 10 * time.Minute
+
+	DEFAULT_SCREENING_LOOK_AHEAD = 24 * time.Hour
+
+	DEFAULT_SCREENING_STEP = 30 * time.Second
+
+	DEFAULT_SCREENING_HIGH_THRESHOLD_KM = 1.0
+
+	DEFAULT_SCREENING_LOW_THRESHOLD_KM = 5.0
+
+	DEFAULT_SCREENING_EVENT_TOLERANCE = 10 * time.Minute
+)
+
+// ScreeningConfig 碰撞筛查的配置，碎片或轨道变化时以及每隔 Interval 筛查一次
+type ScreeningConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+
+	// LookAhead is the window propagated from now
+	LookAhead time.Duration `mapstructure:"look_ahead"`
+
+	// Step is the sampling interval, the closest approach is refined between the samples
+	Step time.Duration `mapstructure:"step"`
+
+	// 最近距离不超过 HighThresholdKm 为高威胁，不超过 LowThresholdKm 为低威胁
+	HighThresholdKm float64 `mapstructure:"high_threshold_km"`
+
+	LowThresholdKm float64 `mapstructure:"low_threshold_km"`
+
+	// 同一卫星和碎片的最近接近时刻相差不超过 EventTolerance 时视为同一接近事件，
+	// 自主规避指令只生成一次
+	EventTolerance time.Duration `mapstructure:"event_tolerance"`
+}
+
+func checkScreeningConfig(screenConf *ScreeningConfig) error {
+	if screenConf.Interval <= 0 {
+		screenConf.Interval = DEFAULT_SCREENING_INTERVAL
+	}
+
+	if screenConf.LookAhead <= 0 {
+		screenConf.LookAhead = DEFAULT_SCREENING_LOOK_AHEAD
+	}
+
+	if screenConf.Step <= 0 {
+		screenConf.Step = DEFAULT_SCREENING_STEP
+	}
+
+	if screenConf.HighThresholdKm <= 0 {
+		screenConf.HighThresholdKm = DEFAULT_SCREENING_HIGH_THRESHOLD_KM
+	}
+
+	if screenConf.LowThresholdKm <= 0 {
+		screenConf.LowThresholdKm = DEFAULT_SCREENING_LOW_THRESHOLD_KM
+	}
+
+	if screenConf.EventTolerance <= 0 {
+		screenConf.EventTolerance = DEFAULT_SCREENING_EVENT_TOLERANCE
+	}
+
+	if screenConf.LowThresholdKm < screenConf.HighThresholdKm {
+		return errors.New("the low threat threshold can not be less than the high one")
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package configs
+
+import (
+	"errors"
+	"time"
+)
+
+// token 签名算法，配置文件定义的常量
+const (
+	TOKEN_ALG_HS512 = "HS512"
+
+	TOKEN_ALG_RS256 = "RS256"
+
+	TOKEN_ALG_EDDSA = "EdDSA"
+)
+
+const (
+	DEFAULT_TOKEN_ISSUER = "satellitebc"
+
+	DEFAULT_ACCESS_TOKEN_EXPIRE = 15 * time.Minute
+
+	DEFAULT_REFRESH_TOKEN_EXPIRE = 7 * 24 * time.Hour
+)
+
+// SigningKeyConfig 单个 token 签名密钥的配置
+type SigningKeyConfig struct {
+	// Kid is written to the token header, so the verifier can find the key
+	Kid string `mapstructure:"kid"`
+
+	// Algorithm: HS512, RS256 or EdDSA
+	Algorithm string `mapstructure:"algorithm"`
+
+	// PrivateKeyFile is a PEM encoded private key, only the active key needs it
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+
+	// PublicKeyFile is a PEM encoded public key. A key with only the public key
+	// can still verify the tokens it signed before, which is used during rotation
+	PublicKeyFile string `mapstructure:"public_key_file"`
+
+	// Secret is only used by HS512, at least 32 bytes
+	Secret string `mapstructure:"secret"`
+}
+
+// TokenConfig token 签发与校验的配置
+type TokenConfig struct {
+	Issuer string `mapstructure:"issuer"`
+
+	// AccessTokenExpire is the lifetime of the access token, such as 15m
+	AccessTokenExpire time.Duration `mapstructure:"access_token_expire"`
+
+	// RefreshTokenExpire is the lifetime of the refresh token, such as 168h
+	RefreshTokenExpire time.Duration `mapstructure:"refresh_token_expire"`
+
+	// ActiveKid is the kid of the key used to sign new tokens
+	ActiveKid string `mapstructure:"active_kid"`
+
+	// SigningKeys are all the keys accepted when verifying tokens.
+	// If empty, an ephemeral key is generated at startup
+	SigningKeys []*SigningKeyConfig `mapstructure:"signing_keys"`
+}
+
+func checkTokenConfig(tokenConf *TokenConfig) error {
+	if len(tokenConf.Issuer) == 0 {
+		tokenConf.Issuer = DEFAULT_TOKEN_ISSUER
+	}
+
+	if tokenConf.AccessTokenExpire <= 0 {
+		tokenConf.AccessTokenExpire = DEFAULT_ACCESS_TOKEN_EXPIRE
+	}
+
+	if tokenConf.RefreshTokenExpire <= 0 {
+		tokenConf.RefreshTokenExpire = DEFAULT_REFRESH_TOKEN_EXPIRE
+	}
+
+	if len(tokenConf.SigningKeys) == 0 {
+		return nil
+	}
+
+	kids := make(map[string]bool)
+	for _, k := range tokenConf.SigningKeys {
+		if len(k.Kid) == 0 {
+			return errors.New("the kid of signing key is empty")
+		}
+		if kids[k.Kid] {
+			return errors.New("duplicate signing key kid: " + k.Kid)
+		}
+		kids[k.Kid] = true
+
+		switch k.Algorithm {
+		case TOKEN_ALG_HS512, TOKEN_ALG_RS256, TOKEN_ALG_EDDSA:
+		default:
+			return errors.New("invalid signing key algorithm: " + k.Algorithm)
+		}
+	}
+
+	if len(tokenConf.ActiveKid) == 0 {
+		tokenConf.ActiveKid = tokenConf.SigningKeys[0].Kid
+	}
+
+	if !kids[tokenConf.ActiveKid] {
+		return errors.New("the active kid not found in signing keys")
+	}
+
+	return nil
+}
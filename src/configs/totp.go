@@ -0,0 +1,43 @@
+package configs
+
+import "time"
+
+const (
+	DEFAULT_TOTP_ISSUER = "satellitebc"
+
+	DEFAULT_TOTP_SKEW = 1
+
+	DEFAULT_TOTP_CHALLENGE_EXPIRE = 5 * time.Minute
+)
+
+// TotpConfig 两步验证的配置
+type TotpConfig struct {
+	// Issuer is shown in the authenticator app
+	Issuer string `mapstructure:"issuer"`
+
+	// RequiredRoles are the role names that must enable 2FA, such as 控制系统.
+	// A user holding any of them can only enroll 2FA after login until it is enabled
+	RequiredRoles []string `mapstructure:"required_roles"`
+
+	// Skew is the number of 30s steps accepted before and after the current one
+	Skew int `mapstructure:"skew"`
+
+	// ChallengeExpire is the lifetime of the token between the password and the code
+	ChallengeExpire time.Duration `mapstructure:"challenge_expire"`
+}
+
+func checkTotpConfig(totpConf *TotpConfig) error {
+	if len(totpConf.Issuer) == 0 {
+		totpConf.Issuer = DEFAULT_TOTP_ISSUER
+	}
+
+	if totpConf.Skew < 0 {
+		totpConf.Skew = DEFAULT_TOTP_SKEW
+	}
+
+	if totpConf.ChallengeExpire <= 0 {
+		totpConf.ChallengeExpire = DEFAULT_TOTP_CHALLENGE_EXPIRE
+	}
+
+	return nil
+}
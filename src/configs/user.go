@@ -0,0 +1,70 @@
+package configs
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// 用户自助注册方式，配置文件定义的常量
+const (
+	// REGISTER_MODE_OFF 关闭自助注册，只能由管理员创建
+	REGISTER_MODE_OFF = "off"
+
+	// REGISTER_MODE_INVITE 凭管理员生成的邀请码注册
+	REGISTER_MODE_INVITE = "invite"
+
+	// REGISTER_MODE_APPROVAL 注册后需管理员审批才能登录
+	REGISTER_MODE_APPROVAL = "approval"
+
+	// REGISTER_MODE_OPEN 任何人都可以注册
+	REGISTER_MODE_OPEN = "open"
+)
+
+const (
+	DEFAULT_REGISTER_MODE = REGISTER_MODE_APPROVAL
+
+	DEFAULT_INVITE_CODE_EXPIRE = 72 * time.Hour
+
+	DEFAULT_PWD_RESET_EXPIRE = 30 * time.Minute
+)
+
+// UserConfig 用户注册与初始管理员的配置
+type UserConfig struct {
+	// RegisterMode: off, invite, approval or open
+	RegisterMode string `mapstructure:"register_mode"`
+
+	InviteCodeExpire time.Duration `mapstructure:"invite_code_expire"`
+
+	// PwdResetExpire is the lifetime of the password reset token, such as 30m
+	PwdResetExpire time.Duration `mapstructure:"pwd_reset_expire"`
+
+	// PwdResetUrl is the page to reset the password, the token is appended
+	// as the query parameter "token". If empty, only the token is sent
+	PwdResetUrl string `mapstructure:"pwd_reset_url"`
+
+	// InitAdminName and InitAdminPwd create the first administrator
+	// at startup when there is no administrator
+	InitAdminName string `mapstructure:"init_admin_name"`
+
+	InitAdminPwd string `mapstructure:"init_admin_pwd"`
+}
+
+func checkUserConfig(userConf *UserConfig) error {
+	if len(userConf.RegisterMode) == 0 {
+		userConf.RegisterMode = DEFAULT_REGISTER_MODE
+	}
+	userConf.RegisterMode = strings.ToLower(userConf.RegisterMode)
+	switch userConf.RegisterMode {
+	case REGISTER_MODE_OFF, REGISTER_MODE_INVITE, REGISTER_MODE_APPROVAL, REGISTER_MODE_OPEN:
+	default:
+		return errors.New("invalid register mode")
+	}
+	if userConf.InviteCodeExpire <= 0 {
+		userConf.InviteCodeExpire = DEFAULT_INVITE_CODE_EXPIRE
+	}
+	if userConf.PwdResetExpire <= 0 {
+		userConf.PwdResetExpire = DEFAULT_PWD_RESET_EXPIRE
+	}
+	return nil
+}
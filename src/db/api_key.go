@@ -0,0 +1,28 @@
+package db
+
+const APIKEY_TABLE_NAME = "api_key"
+
+// ApiKey 服务账号的 API Key，只保存哈希值，Scopes 为逗号分隔的权限
+type ApiKey struct {
+	GeneralField
+	UserId     int32  `gorm:"index"`
+	UserName   string `gorm:"index;size:191"`
+	KeyName    string
+	KeyPrefix  string `gorm:"uniqueIndex;size:32"`
+	KeyHash    string `gorm:"uniqueIndex;size:64"`
+	Scopes     string `gorm:"type:text"`
+	CreatedBy  string
+	ExpiresAt  int64
+	LastUsedAt int64
+	LastUsedIp string
+	RevokedAt  int64
+}
+
+func (a *ApiKey) TableName() string {
+	return APIKEY_TABLE_NAME
+}
+
+func init() {
+	apiKey := new(ApiKey)
+	TableSlice = append(TableSlice, &apiKey)
+}
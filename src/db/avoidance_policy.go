@@ -0,0 +1,49 @@
+package db
+
+const AVOIDANCE_POLICY_TABLE_NAME = "avoidance_policy"
+
+type AvoidanceMode int32
+
+const (
+	AVOIDANCE_AUTO AvoidanceMode = iota + 1
+	AVOIDANCE_RECOMMEND
+)
+
+const (
+	// AVOIDANCE_AUTO_STR 生成的指令直接进入执行队列
+	AVOIDANCE_AUTO_STR = "自动执行"
+	// AVOIDANCE_RECOMMEND_STR 生成的指令为待审批，由操作员确认后执行
+	AVOIDANCE_RECOMMEND_STR = "仅建议"
+)
+
+var AvoidanceModeName = map[AvoidanceMode]string{
+	AVOIDANCE_AUTO:      AVOIDANCE_AUTO_STR,
+	AVOIDANCE_RECOMMEND: AVOIDANCE_RECOMMEND_STR,
+}
+
+var AvoidanceModeValue = map[string]AvoidanceMode{
+	AVOIDANCE_AUTO_STR:      AVOIDANCE_AUTO,
+	AVOIDANCE_RECOMMEND_STR: AVOIDANCE_RECOMMEND,
+}
+
+// AvoidancePolicy 高威胁接近时生成自主规避指令的策略，作用于一颗卫星或一个星座，
+// 卫星的策略优先于所在星座的策略。Source 为生成指令的来源，ContentTemplate 为指令内容模板
+type AvoidancePolicy struct {
+	GeneralField
+	PolicyName      string `gorm:"index"`
+	SatelliteId     string `gorm:"index"`
+	ConstellationId string `gorm:"index"`
+	Mode            AvoidanceMode
+	Source          string
+	ContentTemplate string `gorm:"type:text"`
+	Enabled         bool
+}
+
+func (a *AvoidancePolicy) TableName() string {
+	return AVOIDANCE_POLICY_TABLE_NAME
+}
+
+func init() {
+	avoidancePolicy := new(AvoidancePolicy)
+	TableSlice = append(TableSlice, &avoidancePolicy)
+}
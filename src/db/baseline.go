@@ -0,0 +1,308 @@
+package db
+
+// 基线版本（迁移版本 1）发布时的表结构快照。模型后续的变化由之后的迁移完成，
+// 这里的结构体和表名不能再修改，枚举类型统一写为 int32
+type baselineApiKey struct {
+	Id         int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime   int64  `gorm:"autoCreateTime:milli;index"`
+	UserId     int32  `gorm:"index"`
+	UserName   string `gorm:"index;size:191"`
+	KeyName    string
+	KeyPrefix  string `gorm:"uniqueIndex;size:32"`
+	KeyHash    string `gorm:"uniqueIndex;size:64"`
+	Scopes     string `gorm:"type:text"`
+	CreatedBy  string
+	ExpiresAt  int64
+	LastUsedAt int64
+	LastUsedIp string
+	RevokedAt  int64
+}
+
+func (b *baselineApiKey) TableName() string { return "api_key" }
+
+type baselineCommState struct {
+	Id            int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime      int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId   string `gorm:"index"`
+	SatelliteName string `gorm:"index"`
+	OrbitId       string
+	CommState     int32
+	CommBandwidth string
+	CommDelay     string
+	CommPort      string
+	LinkLoad      string
+}
+
+func (b *baselineCommState) TableName() string { return "comm_state" }
+
+type baselineConstellation struct {
+	Id                 int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime           int64  `gorm:"autoCreateTime:milli;index"`
+	ConstellationId    string `gorm:"index"`
+	ConstellationName  string `gorm:"index"`
+	SatelliteTotalNum  int32
+	SatelliteUpNum     int32
+	SatelliteDownNum   int32
+	SatelliteLinkState int32
+}
+
+func (b *baselineConstellation) TableName() string { return "constellation" }
+
+type baselineControl struct {
+	Id                   int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime             int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId          string `gorm:"index"`
+	SatelliteName        string `gorm:"index"`
+	SatelliteAttitude    string
+	SatelliteTemperature float64
+	SatellitePower       string
+}
+
+func (b *baselineControl) TableName() string { return "control" }
+
+type baselineDebris struct {
+	Id           int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime     int64  `gorm:"autoCreateTime:milli;index"`
+	DebrisId     string `gorm:"index"`
+	DebrisName   string `gorm:"index"`
+	DebrisSource string
+	Angle        float64
+	Speed        float64
+	Height       float64
+	Volume       float64
+	Type         int32
+}
+
+func (b *baselineDebris) TableName() string { return "debris" }
+
+type baselineFault struct {
+	Id               int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime         int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId      string `gorm:"index"`
+	SatelliteName    string `gorm:"index"`
+	OrbitId          string
+	FaultType        int32
+	FaultTime        int64
+	FaultDescription LongText
+	RepairState      int32
+}
+
+func (b *baselineFault) TableName() string { return "fault" }
+
+type baselineInstruction struct {
+	Id                  int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime            int64  `gorm:"autoCreateTime:milli;index"`
+	InstructionId       string `gorm:"index"`
+	Type                int32
+	InstructionContent  string
+	InstructionSource   string
+	ExecInstructionTime int64
+	GenInstructionTime  int64
+	DebrisId            string
+	DebrisName          string
+	Treaten             int32
+	SatelliteId         string `gorm:"index"`
+	SatelliteName       string `gorm:"index"`
+	ExecState           int32
+}
+
+func (b *baselineInstruction) TableName() string { return "instruction" }
+
+type baselineInviteCode struct {
+	Id         int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime   int64  `gorm:"autoCreateTime:milli;index"`
+	CodeHash   string `gorm:"uniqueIndex;size:64"`
+	CodePrefix string
+	UserRole   int32
+	CreatedBy  string
+	ExpiresAt  int64
+	UsedBy     string
+	UsedTime   int64
+}
+
+func (b *baselineInviteCode) TableName() string { return "invite_code" }
+
+type baselineLoginLock struct {
+	Id            int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime      int64  `gorm:"autoCreateTime:milli;index"`
+	LockKey       string `gorm:"uniqueIndex;size:191"`
+	LockType      int32
+	LockTarget    string `gorm:"index"`
+	FailCount     int32
+	FirstFailTime int64
+	LockedUntil   int64
+}
+
+func (b *baselineLoginLock) TableName() string { return "login_lock" }
+
+type baselineLoginLog struct {
+	Id          int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime    int64  `gorm:"autoCreateTime:milli;index"`
+	UserName    string `gorm:"index"`
+	LoginTime   int64
+	LoginIp     string
+	UserAgent   string
+	LoginResult int32 `gorm:"default:1"`
+	FailReason  string
+}
+
+func (b *baselineLoginLog) TableName() string { return "login_log" }
+
+type baselineNetState struct {
+	Id               int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime         int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId      string `gorm:"index"`
+	SatelliteName    string `gorm:"index"`
+	OrbitId          string
+	NetworkSegment   string
+	NetworkState     int32
+	NetworkBandwidth string
+}
+
+func (b *baselineNetState) TableName() string { return "net_state" }
+
+type baselineOperation struct {
+	Id              int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime        int64  `gorm:"autoCreateTime:milli;index"`
+	Operator        string `gorm:"index"`
+	OperatorIp      string
+	OperationTime   int64
+	OperationRecord LongText
+	SatelliteId     string `gorm:"index"`
+	SatelliteName   string
+}
+
+func (b *baselineOperation) TableName() string { return "operation" }
+
+type baselineOrbit struct {
+	Id                     int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime               int64  `gorm:"autoCreateTime:milli;index"`
+	OrbitId                string `gorm:"index"`
+	OrbitType              string
+	OrbitSemiMajorAxis     float64
+	OrbitEccentricity      float64
+	OrbitAngle             float64
+	AscendingNodeLongitude float64
+	Perigee                float64
+}
+
+func (b *baselineOrbit) TableName() string { return "orbit" }
+
+type baselinePwdResetToken struct {
+	Id        int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime  int64  `gorm:"autoCreateTime:milli;index"`
+	UserId    int32  `gorm:"index"`
+	TokenHash string `gorm:"uniqueIndex;size:64"`
+	ExpiresAt int64
+	UsedAt    int64
+}
+
+func (b *baselinePwdResetToken) TableName() string { return "pwd_reset_token" }
+
+type baselineRecoveryCode struct {
+	Id       int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime int64  `gorm:"autoCreateTime:milli;index"`
+	UserId   int32  `gorm:"index"`
+	CodeHash string `gorm:"size:64"`
+	UsedAt   int64
+}
+
+func (b *baselineRecoveryCode) TableName() string { return "recovery_code" }
+
+type baselineRefreshToken struct {
+	Id        int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime  int64  `gorm:"autoCreateTime:milli;index"`
+	UserId    int32  `gorm:"index"`
+	SessionId string `gorm:"index;size:64"`
+	TokenHash string `gorm:"uniqueIndex;size:64"`
+	ExpiresAt int64
+	RevokedAt int64
+}
+
+func (b *baselineRefreshToken) TableName() string { return "refresh_token" }
+
+type baselineRevokedToken struct {
+	Id        int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime  int64  `gorm:"autoCreateTime:milli;index"`
+	Jti       string `gorm:"uniqueIndex;size:64"`
+	UserId    int32  `gorm:"index"`
+	ExpiresAt int64  `gorm:"index"`
+}
+
+func (b *baselineRevokedToken) TableName() string { return "revoked_token" }
+
+type baselineRole struct {
+	Id          int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime    int64  `gorm:"autoCreateTime:milli;index"`
+	RoleName    string `gorm:"uniqueIndex;size:64"`
+	Description string
+	Permissions string `gorm:"type:text"`
+	BuiltIn     bool
+}
+
+func (b *baselineRole) TableName() string { return "role" }
+
+type baselineUserRoleBinding struct {
+	Id       int32 `gorm:"primaryKey;autoIncrement"`
+	LastTime int64 `gorm:"autoCreateTime:milli;index"`
+	UserId   int32 `gorm:"uniqueIndex:idx_user_role_binding"`
+	RoleId   int32 `gorm:"uniqueIndex:idx_user_role_binding;index"`
+}
+
+func (b *baselineUserRoleBinding) TableName() string { return "user_role_binding" }
+
+type baselineSatellite struct {
+	Id            int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime      int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId   string `gorm:"index"`
+	SatelliteName string `gorm:"index"`
+	OrbitId       string
+	MeanAnomaly   float64
+	Speed         float64
+	RunState      int32
+}
+
+func (b *baselineSatellite) TableName() string { return "satellite" }
+
+type baselineUser struct {
+	Id              int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime        int64  `gorm:"autoCreateTime:milli;index"`
+	UserName        string `gorm:"uniqueIndex"`
+	UserRole        int32
+	UserPwd         string
+	UserNickName    string
+	UserPhoneNum    string
+	UserEmail       string
+	UserState       int32 `gorm:"default:1"`
+	ServiceAccount  bool
+	TokenVersion    int32
+	TotpSecret      string
+	TotpEnabled     bool
+	TotpLastCounter int64
+}
+
+func (b *baselineUser) TableName() string { return "user" }
+
+type baselineUserSession struct {
+	Id           int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime     int64  `gorm:"autoCreateTime:milli;index"`
+	UserId       int32  `gorm:"index"`
+	SessionId    string `gorm:"uniqueIndex;size:64"`
+	LoginIp      string
+	UserAgent    string
+	LoginTime    int64
+	LastActiveAt int64
+	ExpiresAt    int64 `gorm:"index"`
+	RevokedAt    int64
+}
+
+func (b *baselineUserSession) TableName() string { return "user_session" }
+
+var baselineTables = []interface{}{
+	&baselineApiKey{}, &baselineCommState{}, &baselineConstellation{}, &baselineControl{},
+	&baselineDebris{}, &baselineFault{}, &baselineInstruction{}, &baselineInviteCode{},
+	&baselineLoginLock{}, &baselineLoginLog{}, &baselineNetState{}, &baselineOperation{},
+	&baselineOrbit{}, &baselinePwdResetToken{}, &baselineRecoveryCode{}, &baselineRefreshToken{},
+	&baselineRevokedToken{}, &baselineRole{}, &baselineUserRoleBinding{}, &baselineSatellite{},
+	&baselineUser{}, &baselineUserSession{},
+}
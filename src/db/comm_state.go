@@ -8,10 +8,11 @@ type CommState struct {
 	SatelliteName string `gorm:"index"`
 	OrbitId       string
 	CommState     State
-	CommBandwidth string
-	CommDelay     string
 	CommPort      string
-	LinkLoad      string
+	// 单位见 Unit，入库前已换算
+	CommBandwidthMbps float64
+	CommDelayMs       float64
+	LinkLoadPercent   float64
 }
 
 func (c *CommState) TableName() string {
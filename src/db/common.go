@@ -1,5 +1,10 @@
 package db
 
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
 var TableSlice = make([]interface{}, 0)
 
 type GeneralField struct {
@@ -7,6 +12,16 @@ type GeneralField struct {
 	LastTime int64 `gorm:"autoCreateTime:milli;index"`
 }
 
+// LongText 长文本，MySQL 为 longtext，PostgreSQL 没有 longtext，使用 text
+type LongText string
+
+func (LongText) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == DB_DRIVER_POSTGRES {
+		return "text"
+	}
+	return "longtext"
+}
+
 type ModelStruct interface {
 	TableName() string
 }
@@ -0,0 +1,26 @@
+package db
+
+const CONJUNCTION_TABLE_NAME = "conjunction"
+
+// Conjunction 卫星与碎片在筛查窗口内的最近接近，每对卫星和碎片一条，每次筛查时更新。
+// Tca 为最近接近时刻（秒），MissDistanceKm 为最近距离（千米），ScreenedAt 为筛查时间（毫秒）
+type Conjunction struct {
+	GeneralField
+	SatelliteId    string `gorm:"index"`
+	SatelliteName  string
+	DebrisId       string `gorm:"index"`
+	DebrisName     string
+	Tca            int64
+	MissDistanceKm float64
+	Treaten        ThreatDegree `gorm:"index"`
+	ScreenedAt     int64
+}
+
+func (c *Conjunction) TableName() string {
+	return CONJUNCTION_TABLE_NAME
+}
+
+func init() {
+	conjunction := new(Conjunction)
+	TableSlice = append(TableSlice, &conjunction)
+}
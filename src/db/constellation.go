@@ -1,15 +1,14 @@
 package db
 
+import "gorm.io/plugin/soft_delete"
+
 const CONSTELLATION_TABLE_NAME = "constellation"
 
 type Constellation struct {
 	GeneralField
-	ConstellationId    string `gorm:"index"`
-	ConstellationName  string `gorm:"index"`
-	SatelliteTotalNum  int32
-	SatelliteUpNum     int32
-	SatelliteDownNum   int32
-	SatelliteLinkState State
+	ConstellationId   string                `gorm:"index"`
+	ConstellationName string                `gorm:"index"`
+	DeletedAt         soft_delete.DeletedAt `gorm:"softDelete:milli;default:0"`
 }
 
 func (c *Constellation) TableName() string {
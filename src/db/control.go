@@ -8,7 +8,7 @@ type Control struct {
 	SatelliteName        string `gorm:"index"`
 	SatelliteAttitude    string
 	SatelliteTemperature float64
-	SatellitePower       string
+	SatellitePowerW      float64
 }
 
 func (c *Control) TableName() string {
@@ -7,17 +7,36 @@ import (
 
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
 )
 
+// 支持的数据库驱动
+const (
+	DB_DRIVER_MYSQL = "mysql"
+
+	// DB_DRIVER_SQLITE dbname 为数据库文件路径，:memory: 表示内存数据库
+	DB_DRIVER_SQLITE = "sqlite"
+
+	DB_DRIVER_POSTGRES = "postgres"
+)
+
+const SQLITE_MEMORY = ":memory:"
+
 // DBConfig
 type DBConfig struct {
+	Driver   string `mapstructure:"driver"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	IP       string `mapstructure:"ip"`
 	Port     string `mapstructure:"port"`
 	DbName   string `mapstructure:"dbname"`
+	// SslMode only for postgres
+	SslMode string `mapstructure:"ssl_mode"`
+	// AutoMigrate apply the pending migrations on startup, otherwise run the migrate command
+	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 // GetDBConfig --Get DB config from config file.
@@ -27,18 +46,39 @@ func getDBConfig(dbConf *DBConfig) string {
 	return mysqlURL
 }
 
-// DbInit db init
-func GormInit(dbConf *DBConfig, tableSlice []interface{},
-	zaplogger *zap.SugaredLogger) (*gorm.DB, error) {
-	var err error
-	gormDb, err := gorm.Open(mysql.New(mysql.Config{
-		DSN:                       getDBConfig(dbConf),
-		DefaultStringSize:         256,   // string 类型字段的默认长度
-		DisableDatetimePrecision:  true,  // 禁用 datetime 精度，MySQL 5.6 之前的数据库不支持
-		DontSupportRenameIndex:    true,  // 重命名索引时采用删除并新建的方式，MySQL 5.7 之前的数据库和 MariaDB 不支持重命名索引
-		DontSupportRenameColumn:   true,  // 用 `change` 重命名列，MySQL 8 之前的数据库和 MariaDB 不支持重命名列
-		SkipInitializeWithVersion: false, // 根据当前 MySQL 版本自动配置
-	}), &gorm.Config{
+func getPostgresDSN(dbConf *DBConfig) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Local",
+		dbConf.IP, dbConf.Port, dbConf.User, dbConf.Password, dbConf.DbName, dbConf.SslMode)
+}
+
+func getDialector(dbConf *DBConfig) (gorm.Dialector, error) {
+	switch dbConf.Driver {
+	case DB_DRIVER_MYSQL, "":
+		return mysql.New(mysql.Config{
+			DSN:                       getDBConfig(dbConf),
+			DefaultStringSize:         256,   // string 类型字段的默认长度
+			DisableDatetimePrecision:  true,  // 禁用 datetime 精度，MySQL 5.6 之前的数据库不支持
+			DontSupportRenameIndex:    true,  // 重命名索引时采用删除并新建的方式，MySQL 5.7 之前的数据库和 MariaDB 不支持重命名索引
+			DontSupportRenameColumn:   true,  // 用 `change` 重命名列，MySQL 8 之前的数据库和 MariaDB 不支持重命名列
+			SkipInitializeWithVersion: false, // 根据当前 MySQL 版本自动配置
+		}), nil
+	case DB_DRIVER_SQLITE:
+		return sqlite.Open(dbConf.DbName), nil
+	case DB_DRIVER_POSTGRES:
+		return postgres.Open(getPostgresDSN(dbConf)), nil
+	default:
+		return nil, fmt.Errorf("the db driver [%s] is not supported", dbConf.Driver)
+	}
+}
+
+// GormInit open the db, the tables are created by the migrations in Migrations
+func GormInit(dbConf *DBConfig, zaplogger *zap.SugaredLogger) (*gorm.DB, error) {
+	dialector, err := getDialector(dbConf)
+	if err != nil {
+		return nil, err
+	}
+
+	gormDb, err := gorm.Open(dialector, &gorm.Config{
 		Logger: loggers.NewGormLogger(
 			zaplogger,
 			200*time.Millisecond,
@@ -58,14 +98,10 @@ func GormInit(dbConf *DBConfig, tableSlice []interface{},
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
-	// Set table options
-	gormDb.Set("gorm:association_autoupdate", false).
-		Set("gorm:association_autocreate", false).
-		Set("gorm:table_options", "ENGINE=InnoDB")
-	err = gormDb.Set("gorm:table_options", "CHARSET=utf8").
-		Set("gorm:table_options", "COLLATE=utf8_general_ci").AutoMigrate(tableSlice...)
-	if err != nil {
-		return nil, err
+	if dbConf.Driver == DB_DRIVER_SQLITE {
+		// SQLite 同一时间只允许一个写入，内存数据库的每个连接都是独立的库
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
 	}
 
 	return gormDb, nil
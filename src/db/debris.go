@@ -1,5 +1,7 @@
 package db
 
+import "gorm.io/plugin/soft_delete"
+
 type DebrisType int32
 
 const (
@@ -28,8 +30,9 @@ type Debris struct {
 	Angle        float64
 	Speed        float64
 	Height       float64
-	Volunme      float64
+	Volume       float64
 	Type         DebrisType
+	DeletedAt    soft_delete.DeletedAt `gorm:"softDelete:milli;default:0"`
 }
 
 var DebrisTypeName = map[DebrisType]string{
@@ -32,6 +32,50 @@ var FaultTypeValue = map[string]FaultType{
 	DType_STR: DType,
 }
 
+// FaultState 故障处理流程的状态
+type FaultState int32
+
+const (
+	FAULT_OPEN FaultState = iota + 1
+	FAULT_ACKNOWLEDGED
+	FAULT_IN_REPAIR
+	FAULT_RESOLVED
+	FAULT_CLOSED
+)
+
+const (
+	FAULT_OPEN_STR = "待确认"
+
+	FAULT_ACKNOWLEDGED_STR = "已确认"
+
+	FAULT_IN_REPAIR_STR = "维修中"
+
+	FAULT_RESOLVED_STR = "已修复"
+
+	FAULT_CLOSED_STR = "已关闭"
+)
+
+var FaultStateName = map[FaultState]string{
+	FAULT_OPEN:         FAULT_OPEN_STR,
+	FAULT_ACKNOWLEDGED: FAULT_ACKNOWLEDGED_STR,
+	FAULT_IN_REPAIR:    FAULT_IN_REPAIR_STR,
+	FAULT_RESOLVED:     FAULT_RESOLVED_STR,
+	FAULT_CLOSED:       FAULT_CLOSED_STR,
+}
+
+var FaultStateValue = map[string]FaultState{
+	FAULT_OPEN_STR:         FAULT_OPEN,
+	FAULT_ACKNOWLEDGED_STR: FAULT_ACKNOWLEDGED,
+	FAULT_IN_REPAIR_STR:    FAULT_IN_REPAIR,
+	FAULT_RESOLVED_STR:     FAULT_RESOLVED,
+	FAULT_CLOSED_STR:       FAULT_CLOSED,
+}
+
+// UnresolvedFaultStates 尚未修复的故障状态
+var UnresolvedFaultStates = []FaultState{FAULT_OPEN, FAULT_ACKNOWLEDGED, FAULT_IN_REPAIR}
+
+// Fault RepairState 由 FaultState 决定，修复或关闭前为异常；
+// 各时间为首次进入对应状态的时间（秒），未进入时为 0
 type Fault struct {
 	GeneralField
 	SatelliteId      string `gorm:"index"`
@@ -39,8 +83,13 @@ type Fault struct {
 	OrbitId          string
 	FaultType        FaultType
 	FaultTime        int64
-	FaultDescription string `gorm:"type:longtext"`
+	FaultDescription LongText
 	RepairState      State
+	FaultState       FaultState `gorm:"index"`
+	Assignee         string
+	AcknowledgedAt   int64
+	ResolvedAt       int64
+	ClosedAt         int64
 }
 
 func (f *Fault) TableName() string {
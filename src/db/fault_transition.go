@@ -0,0 +1,24 @@
+package db
+
+const FAULT_TRANSITION_TABLE_NAME = "fault_transition"
+
+// FaultTransition 故障的每一次状态变化，记录操作人、指派人和备注
+type FaultTransition struct {
+	GeneralField
+	FaultId        int32 `gorm:"index"`
+	FromState      FaultState
+	ToState        FaultState
+	Operator       string
+	Assignee       string
+	Note           string `gorm:"type:text"`
+	TransitionTime int64
+}
+
+func (f *FaultTransition) TableName() string {
+	return FAULT_TRANSITION_TABLE_NAME
+}
+
+func init() {
+	faultTransition := new(FaultTransition)
+	TableSlice = append(TableSlice, &faultTransition)
+}
@@ -33,6 +33,9 @@ const (
 	INEXEC
 	EXECSUCCESS
 	EXECFAIL
+	WAITAPPROVAL
+	REJECTED
+	CANCELLED
 )
 
 const (
@@ -43,20 +46,32 @@ const (
 	EXECSUCCESS_STR = "执行成功"
 
 	EXECFAIL_STR = "执行失败"
+
+	WAITAPPROVAL_STR = "待审批"
+
+	REJECTED_STR = "已驳回"
+
+	CANCELLED_STR = "已取消"
 )
 
 var ExecStateName = map[InstructionExecState]string{
-	NOTEXEC:     NOTEXEC_STR,
-	INEXEC:      INEXEC_STR,
-	EXECSUCCESS: EXECSUCCESS_STR,
-	EXECFAIL:    EXECFAIL_STR,
+	NOTEXEC:      NOTEXEC_STR,
+	INEXEC:       INEXEC_STR,
+	EXECSUCCESS:  EXECSUCCESS_STR,
+	EXECFAIL:     EXECFAIL_STR,
+	WAITAPPROVAL: WAITAPPROVAL_STR,
+	REJECTED:     REJECTED_STR,
+	CANCELLED:    CANCELLED_STR,
 }
 
 var ExecStateValue = map[string]InstructionExecState{
-	NOTEXEC_STR:     NOTEXEC,
-	INEXEC_STR:      INEXEC,
-	EXECSUCCESS_STR: EXECSUCCESS,
-	EXECFAIL_STR:    EXECFAIL,
+	NOTEXEC_STR:      NOTEXEC,
+	INEXEC_STR:       INEXEC,
+	EXECSUCCESS_STR:  EXECSUCCESS,
+	EXECFAIL_STR:     EXECFAIL,
+	WAITAPPROVAL_STR: WAITAPPROVAL,
+	REJECTED_STR:     REJECTED,
+	CANCELLED_STR:    CANCELLED,
 }
 
 type ThreatDegree int32
@@ -99,6 +114,14 @@ type Instruction struct {
 	SatelliteId         string `gorm:"index"`
 	SatelliteName       string `gorm:"index"`
 	ExecState           InstructionExecState
+	// 要求的执行时间和执行窗口的结束时间（秒），0 表示立即执行、不限结束时间
+	ScheduledTime     int64
+	ScheduleWindowEnd int64
+	// 审批或驳回的用户和时间（秒）
+	Reviewer   string
+	ReviewTime int64
+	// 自主规避指令对应的最近接近时刻（秒）
+	ConjunctionTca int64
 }
 
 func (i *Instruction) TableName() string {
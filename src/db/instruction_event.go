@@ -0,0 +1,23 @@
+package db
+
+const INSTRUCTION_EVENT_TABLE_NAME = "instruction_event"
+
+// InstructionEvent 指令执行状态的每一次变化，创建指令时 FromState 为 0
+type InstructionEvent struct {
+	GeneralField
+	InstructionId string `gorm:"index"`
+	FromState     InstructionExecState
+	ToState       InstructionExecState
+	Operator      string
+	Note          string `gorm:"type:text"`
+	EventTime     int64
+}
+
+func (i *InstructionEvent) TableName() string {
+	return INSTRUCTION_EVENT_TABLE_NAME
+}
+
+func init() {
+	instructionEvent := new(InstructionEvent)
+	TableSlice = append(TableSlice, &instructionEvent)
+}
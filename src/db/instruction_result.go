@@ -0,0 +1,28 @@
+package db
+
+const INSTRUCTION_RESULT_TABLE_NAME = "instruction_result"
+
+// InstructionResult 指令每一次执行的结果，时间单位为秒。
+// 执行系统上报的结果包含错误码和遥测快照，超时未上报的由服务记为超时
+type InstructionResult struct {
+	GeneralField
+	InstructionId string `gorm:"index"`
+	Attempt       int32
+	ExecState     InstructionExecState
+	ErrorCode     string
+	ErrorMessage  string `gorm:"type:text"`
+	// Telemetry 执行结束时的遥测快照，JSON 对象
+	Telemetry  string `gorm:"type:text"`
+	StartedAt  int64
+	FinishedAt int64
+	ReportedBy string
+}
+
+func (i *InstructionResult) TableName() string {
+	return INSTRUCTION_RESULT_TABLE_NAME
+}
+
+func init() {
+	instructionResult := new(InstructionResult)
+	TableSlice = append(TableSlice, &instructionResult)
+}
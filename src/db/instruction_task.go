@@ -0,0 +1,76 @@
+package db
+
+const INSTRUCTION_TASK_TABLE_NAME = "instruction_task"
+
+type InstructionTaskState int32
+
+const (
+	TASK_QUEUED InstructionTaskState = iota + 1
+	TASK_RUNNING
+	TASK_DONE
+	TASK_GAVE_UP
+	TASK_HELD
+	TASK_CANCELLED
+)
+
+const (
+	TASK_QUEUED_STR = "排队中"
+
+	TASK_RUNNING_STR = "执行中"
+
+	TASK_DONE_STR = "已完成"
+
+	TASK_GAVE_UP_STR = "已放弃"
+
+	TASK_HELD_STR = "待审批"
+
+	TASK_CANCELLED_STR = "已取消"
+)
+
+var TaskStateName = map[InstructionTaskState]string{
+	TASK_QUEUED:    TASK_QUEUED_STR,
+	TASK_RUNNING:   TASK_RUNNING_STR,
+	TASK_DONE:      TASK_DONE_STR,
+	TASK_GAVE_UP:   TASK_GAVE_UP_STR,
+	TASK_HELD:      TASK_HELD_STR,
+	TASK_CANCELLED: TASK_CANCELLED_STR,
+}
+
+var TaskStateValue = map[string]InstructionTaskState{
+	TASK_QUEUED_STR:    TASK_QUEUED,
+	TASK_RUNNING_STR:   TASK_RUNNING,
+	TASK_DONE_STR:      TASK_DONE,
+	TASK_GAVE_UP_STR:   TASK_GAVE_UP,
+	TASK_HELD_STR:      TASK_HELD,
+	TASK_CANCELLED_STR: TASK_CANCELLED,
+}
+
+// InstructionTask 指令的执行队列，时间单位为毫秒，待审批的任务在审批通过后才进入排队。
+// 执行中的任务在 LeaseExpiresAt 之前归 Worker 所有，过期后视为本次执行超时
+type InstructionTask struct {
+	GeneralField
+	InstructionId string               `gorm:"uniqueIndex"`
+	TaskState     InstructionTaskState `gorm:"index"`
+	Attempts      int32
+	MaxAttempts   int32
+	TimeoutMs     int64
+	NextRunAt     int64 `gorm:"index"`
+	// WindowEnd 之后不再开始执行，0 表示不限
+	WindowEnd      int64
+	Worker         string
+	LeaseExpiresAt int64
+	LastError      string `gorm:"type:text"`
+	// 执行系统上报的进度（0-100），上报时间单位为秒
+	Progress        int32
+	ProgressMessage string
+	ProgressAt      int64
+}
+
+func (i *InstructionTask) TableName() string {
+	return INSTRUCTION_TASK_TABLE_NAME
+}
+
+func init() {
+	instructionTask := new(InstructionTask)
+	TableSlice = append(TableSlice, &instructionTask)
+}
@@ -0,0 +1,24 @@
+package db
+
+const INVITECODE_TABLE_NAME = "invite_code"
+
+// InviteCode 注册邀请码，只保存哈希值，CodePrefix 用于展示
+type InviteCode struct {
+	GeneralField
+	CodeHash   string `gorm:"uniqueIndex;size:64"`
+	CodePrefix string
+	UserRole   UserRoleType
+	CreatedBy  string
+	ExpiresAt  int64
+	UsedBy     string
+	UsedTime   int64
+}
+
+func (i *InviteCode) TableName() string {
+	return INVITECODE_TABLE_NAME
+}
+
+func init() {
+	inviteCode := new(InviteCode)
+	TableSlice = append(TableSlice, &inviteCode)
+}
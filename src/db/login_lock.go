@@ -0,0 +1,45 @@
+package db
+
+const LOGINLOCK_TABLE_NAME = "login_lock"
+
+type LockType int32
+
+const (
+	LOCK_USER LockType = iota + 1
+	LOCK_IP
+)
+
+const (
+	LOCK_USER_STR = "用户"
+	LOCK_IP_STR   = "IP"
+)
+
+var LockTypeName = map[LockType]string{
+	LOCK_USER: LOCK_USER_STR,
+	LOCK_IP:   LOCK_IP_STR,
+}
+
+var LockTypeValue = map[string]LockType{
+	LOCK_USER_STR: LOCK_USER,
+	LOCK_IP_STR:   LOCK_IP,
+}
+
+// LoginLock 登录失败计数与锁定状态，LockTarget 为用户名或 IP
+type LoginLock struct {
+	GeneralField
+	LockKey       string `gorm:"uniqueIndex;size:191"`
+	LockType      LockType
+	LockTarget    string `gorm:"index"`
+	FailCount     int32
+	FirstFailTime int64
+	LockedUntil   int64
+}
+
+func (l *LoginLock) TableName() string {
+	return LOGINLOCK_TABLE_NAME
+}
+
+func init() {
+	loginLock := new(LoginLock)
+	TableSlice = append(TableSlice, &loginLock)
+}
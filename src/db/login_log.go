@@ -2,11 +2,36 @@ package db
 
 const LOGINLOG_TABLE_NAME = "login_log"
 
+type LoginResultType int32
+
+const (
+	LOGIN_SUCCESS LoginResultType = iota + 1
+	LOGIN_FAIL
+)
+
+const (
+	LOGIN_SUCCESS_STR = "成功"
+	LOGIN_FAIL_STR    = "失败"
+)
+
+var LoginResultTypeName = map[LoginResultType]string{
+	LOGIN_SUCCESS: LOGIN_SUCCESS_STR,
+	LOGIN_FAIL:    LOGIN_FAIL_STR,
+}
+
+var LoginResultTypeValue = map[string]LoginResultType{
+	LOGIN_SUCCESS_STR: LOGIN_SUCCESS,
+	LOGIN_FAIL_STR:    LOGIN_FAIL,
+}
+
 type LoginLog struct {
 	GeneralField
-	UserName  string `gorm:"index"`
-	LoginTime int64
-	LoginIp   string
+	UserName    string `gorm:"index"`
+	LoginTime   int64
+	LoginIp     string
+	UserAgent   string
+	LoginResult LoginResultType `gorm:"default:1"`
+	FailReason  string
 }
 
 func (l *LoginLog) TableName() string {
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const SCHEMAVERSION_TABLE_NAME = "schema_version"
+
+// MySQL 建表选项，必须一次设置，多次 Set 会互相覆盖
+const MYSQL_TABLE_OPTIONS = "ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_general_ci"
+
+// SchemaVersion 已执行的迁移，每个版本一条记录
+type SchemaVersion struct {
+	GeneralField
+	Version   int32 `gorm:"uniqueIndex"`
+	Name      string
+	AppliedAt int64
+}
+
+func (s *SchemaVersion) TableName() string {
+	return SCHEMAVERSION_TABLE_NAME
+}
+
+// Migration 版本号从 1 开始连续递增，Down 撤销 Up 的修改
+type Migration struct {
+	Version int32
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// LatestVersion the schema version the binary expects
+func LatestVersion() int32 {
+	if len(Migrations) == 0 {
+		return 0
+	}
+	return Migrations[len(Migrations)-1].Version
+}
+
+// CurrentVersion the latest applied version, 0 when no migration is applied
+func CurrentVersion(gormDb *gorm.DB) (int32, error) {
+	err := gormDb.AutoMigrate(&SchemaVersion{})
+	if err != nil {
+		return 0, err
+	}
+
+	var version int32
+	err = gormDb.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// MigrateUp apply the migrations up to the target version, 0 means the latest
+func MigrateUp(gormDb *gorm.DB, target int32) error {
+	if target == 0 {
+		target = LatestVersion()
+	}
+
+	current, err := CurrentVersion(gormDb)
+	if err != nil {
+		return err
+	}
+
+	if current > LatestVersion() {
+		return fmt.Errorf("the schema version %d is ahead of the binary %d", current, LatestVersion())
+	}
+
+	for _, m := range Migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+
+		err = gormDb.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaVersion{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now().Unix(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migrate up to %d %s failed: %s", m.Version, m.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// MigrateDown revert the migrations until the schema is at the target version
+func MigrateDown(gormDb *gorm.DB, target int32) error {
+	if target < 0 {
+		return errors.New("the target version can not be negative")
+	}
+
+	current, err := CurrentVersion(gormDb)
+	if err != nil {
+		return err
+	}
+
+	if current > LatestVersion() {
+		return fmt.Errorf("the schema version %d is ahead of the binary %d", current, LatestVersion())
+	}
+
+	for i := len(Migrations) - 1; i >= 0; i-- {
+		m := Migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+
+		err = gormDb.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Where("version = ?", m.Version).Delete(&SchemaVersion{}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migrate down from %d %s failed: %s", m.Version, m.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// CheckSchemaVersion the schema must be exactly the version of the binary
+func CheckSchemaVersion(gormDb *gorm.DB) error {
+	current, err := CurrentVersion(gormDb)
+	if err != nil {
+		return err
+	}
+
+	latest := LatestVersion()
+	if current > latest {
+		return fmt.Errorf("the schema version %d is ahead of the binary %d, upgrade the binary", current, latest)
+	}
+	if current < latest {
+		return fmt.Errorf("the schema version %d is behind the binary %d, run the migrate command", current, latest)
+	}
+	return nil
+}
+
+func checkMigrations(migrations []*Migration) error {
+	sorted := sort.SliceIsSorted(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	if !sorted {
+		return errors.New("the migrations are not sorted by version")
+	}
+
+	for i, m := range migrations {
+		if m.Version != int32(i+1) {
+			return fmt.Errorf("the migration version %d is not continuous", m.Version)
+		}
+		if m.Up == nil || m.Down == nil {
+			return fmt.Errorf("the migration %d must have both up and down", m.Version)
+		}
+	}
+	return nil
+}
+
+func init() {
+	if err := checkMigrations(Migrations); err != nil {
+		panic(err)
+	}
+}
+
+// tableOptions the mysql tables are created with the engine and charset
+func tableOptions(tx *gorm.DB) *gorm.DB {
+	if tx.Dialector.Name() == DB_DRIVER_MYSQL {
+		return tx.Set("gorm:table_options", MYSQL_TABLE_OPTIONS)
+	}
+	return tx
+}
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMigrateSqlite(t *testing.T) {
+	gormDb, err := GormInit(&DBConfig{
+		Driver: DB_DRIVER_SQLITE,
+		DbName: SQLITE_MEMORY,
+	}, zap.NewNop().Sugar())
+	require.Nil(t, err)
+
+	// 升级前由 AutoMigrate 创建的表
+	type legacyDebris struct {
+		GeneralField
+		DebrisName string
+		Volunme    float64
+	}
+	err = gormDb.Table(DEBRIS_TABLE_NAME).AutoMigrate(&legacyDebris{})
+	require.Nil(t, err)
+	type legacyOrbit struct {
+		GeneralField
+		OrbitId string `gorm:"index"`
+	}
+	err = gormDb.Table(DEBRIS_TABLE_NAME).Create(&legacyDebris{DebrisName: "d1", Volunme: 1.5}).Error
+	require.Nil(t, err)
+	err = gormDb.Table(ORBIT_TABLE_NAME).AutoMigrate(&legacyOrbit{})
+	require.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		err = gormDb.Table(ORBIT_TABLE_NAME).Create(&legacyOrbit{OrbitId: "o1"}).Error
+		require.Nil(t, err)
+	}
+
+	type legacyConstellation struct {
+		GeneralField
+		ConstellationId    string `gorm:"index"`
+		SatelliteLinkState State
+	}
+	err = gormDb.Table(CONSTELLATION_TABLE_NAME).AutoMigrate(&legacyConstellation{})
+	require.Nil(t, err)
+
+	type legacyCommState struct {
+		GeneralField
+		CommBandwidth string
+		CommDelay     string
+		LinkLoad      string
+	}
+	err = gormDb.Table(COMMSTATE_TABLE_NAME).AutoMigrate(&legacyCommState{})
+	require.Nil(t, err)
+	err = gormDb.Table(COMMSTATE_TABLE_NAME).Create(&legacyCommState{
+		CommBandwidth: "1.5Gbps", CommDelay: "35 ms", LinkLoad: "60%"}).Error
+	require.Nil(t, err)
+
+	require.Nil(t, gormDb.AutoMigrate(&Instruction{}))
+	for _, state := range []InstructionExecState{NOTEXEC, INEXEC, EXECSUCCESS} {
+		require.Nil(t, gormDb.Create(&Instruction{InstructionId: "i1", ExecState: state,
+			ExecInstructionTime: int64(state)}).Error)
+	}
+	require.Nil(t, gormDb.Create(&Instruction{InstructionId: "i2", ExecState: NOTEXEC}).Error)
+
+	require.NotNil(t, CheckSchemaVersion(gormDb))
+
+	require.Nil(t, MigrateUp(gormDb, 0))
+	require.Nil(t, CheckSchemaVersion(gormDb))
+	require.False(t, gormDb.Migrator().HasColumn(&Debris{}, "volunme"))
+
+	debris := new(Debris)
+	require.Nil(t, gormDb.Where("debris_name = ?", "d1").First(debris).Error)
+	require.Equal(t, 1.5, debris.Volume)
+
+	// 重复的业务 id 只保留最新的一条
+	orbits := make([]*Orbit, 0)
+	require.Nil(t, gormDb.Where("orbit_id = ?", "o1").Find(&orbits).Error)
+	require.Len(t, orbits, 1)
+	require.Equal(t, int32(3), orbits[0].Id)
+	require.NotNil(t, gormDb.Create(&Orbit{OrbitId: "o1"}).Error)
+
+	// 星座的链路状态改为实时计算
+	require.False(t, gormDb.Migrator().HasColumn(&Constellation{}, "satellite_link_state"))
+	require.Nil(t, gormDb.Create(&Constellation{ConstellationId: "c1"}).Error)
+
+	// 遥测字符串换算为数值
+	commState := new(CommState)
+	require.Nil(t, gormDb.First(commState).Error)
+	require.Equal(t, 1500.0, commState.CommBandwidthMbps)
+	require.Equal(t, 35.0, commState.CommDelayMs)
+	require.Equal(t, 60.0, commState.LinkLoadPercent)
+	require.False(t, gormDb.Migrator().HasColumn(&CommState{}, "comm_bandwidth"))
+
+	// 指令的多行状态合并为一行，状态变化写入事件
+	instructions := make([]*Instruction, 0)
+	require.Nil(t, gormDb.Order("id").Find(&instructions).Error)
+	require.Len(t, instructions, 2)
+	require.Equal(t, int32(1), instructions[0].Id)
+	require.Equal(t, EXECSUCCESS, instructions[0].ExecState)
+	require.Equal(t, int64(EXECSUCCESS), instructions[0].ExecInstructionTime)
+	require.NotNil(t, gormDb.Create(&Instruction{InstructionId: "i1"}).Error)
+
+	events := make([]*InstructionEvent, 0)
+	require.Nil(t, gormDb.Where("instruction_id = ?", "i1").Order("id").Find(&events).Error)
+	require.Len(t, events, 3)
+	require.Equal(t, InstructionExecState(0), events[0].FromState)
+	require.Equal(t, INEXEC, events[2].FromState)
+
+	require.Nil(t, MigrateDown(gormDb, 5))
+	var commBandwidth string
+	require.Nil(t, gormDb.Table(COMMSTATE_TABLE_NAME).Select("comm_bandwidth").
+		Row().Scan(&commBandwidth))
+	require.Equal(t, "1500Mbps", commBandwidth)
+	require.Nil(t, MigrateUp(gormDb, 0))
+
+	require.Nil(t, MigrateDown(gormDb, 2))
+	require.True(t, gormDb.Migrator().HasColumn(&Constellation{}, "satellite_link_state"))
+	require.False(t, gormDb.Migrator().HasColumn(&Orbit{}, "deleted_at"))
+
+	require.Nil(t, MigrateDown(gormDb, 1))
+	require.True(t, gormDb.Migrator().HasColumn(&Debris{}, "volunme"))
+
+	require.Nil(t, MigrateDown(gormDb, 0))
+	require.False(t, gormDb.Migrator().HasTable(&Debris{}))
+
+	version, err := CurrentVersion(gormDb)
+	require.Nil(t, err)
+	require.Equal(t, int32(0), version)
+
+	// 库中的版本高于程序时拒绝启动
+	require.Nil(t, gormDb.Create(&SchemaVersion{Version: LatestVersion() + 1}).Error)
+	require.NotNil(t, CheckSchemaVersion(gormDb))
+	require.NotNil(t, MigrateUp(gormDb, 0))
+}
+
+// 无法解析的遥测值不能被丢弃，迁移失败并列出记录，修正后可以重新迁移
+func TestMigrateUnparseableTelemetrySqlite(t *testing.T) {
+	gormDb, err := GormInit(&DBConfig{
+		Driver: DB_DRIVER_SQLITE,
+		DbName: SQLITE_MEMORY,
+	}, zap.NewNop().Sugar())
+	require.Nil(t, err)
+
+	type legacyCommState struct {
+		GeneralField
+		LinkLoad string
+	}
+	err = gormDb.Table(COMMSTATE_TABLE_NAME).AutoMigrate(&legacyCommState{})
+	require.Nil(t, err)
+	for _, linkLoad := range []string{"60%", "unknown", "", "high"} {
+		err = gormDb.Table(COMMSTATE_TABLE_NAME).Create(&legacyCommState{LinkLoad: linkLoad}).Error
+		require.Nil(t, err)
+	}
+
+	err = MigrateUp(gormDb, 0)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "comm_state.link_load")
+	require.Contains(t, err.Error(), "[2,4]")
+
+	version, err := CurrentVersion(gormDb)
+	require.Nil(t, err)
+	require.Equal(t, int32(5), version)
+	require.True(t, gormDb.Migrator().HasColumn(&CommState{}, "link_load"))
+
+	var linkLoad string
+	require.Nil(t, gormDb.Table(COMMSTATE_TABLE_NAME).Select("link_load").Where("id = ?", 2).
+		Row().Scan(&linkLoad))
+	require.Equal(t, "unknown", linkLoad)
+
+	err = gormDb.Table(COMMSTATE_TABLE_NAME).Where("id IN ?", []int32{2, 4}).
+		Update("link_load", "").Error
+	require.Nil(t, err)
+	require.Nil(t, MigrateUp(gormDb, 0))
+
+	commStates := make([]*CommState, 0)
+	require.Nil(t, gormDb.Order("id").Find(&commStates).Error)
+	require.Len(t, commStates, 4)
+	require.Equal(t, 60.0, commStates[0].LinkLoadPercent)
+	require.False(t, gormDb.Migrator().HasColumn(&CommState{}, "link_load"))
+}
+
+// 基线之后的表和列都由之后的迁移添加，新库迁移到最新版本后与模型一致
+func TestMigrateFreshSqlite(t *testing.T) {
+	gormDb, err := GormInit(&DBConfig{
+		Driver: DB_DRIVER_SQLITE,
+		DbName: SQLITE_MEMORY,
+	}, zap.NewNop().Sugar())
+	require.Nil(t, err)
+
+	require.Nil(t, MigrateUp(gormDb, 1))
+	require.False(t, gormDb.Migrator().HasColumn(&Debris{}, "deleted_at"))
+	require.False(t, gormDb.Migrator().HasTable(&SatelliteCatalog{}))
+
+	// 每个版本只添加当时的表和列，回滚时全部删除
+	m := gormDb.Migrator()
+	require.Nil(t, MigrateUp(gormDb, 11))
+	require.True(t, m.HasColumn(&Instruction{}, "scheduled_time"))
+	require.False(t, m.HasColumn(&Instruction{}, "reviewer"))
+	require.False(t, m.HasColumn(&Instruction{}, "conjunction_tca"))
+	require.False(t, m.HasTable(&AvoidancePolicy{}))
+	require.Nil(t, MigrateDown(gormDb, 10))
+	require.False(t, m.HasColumn(&Instruction{}, "scheduled_time"))
+	require.False(t, m.HasColumn(&InstructionTask{}, "window_end"))
+
+	require.Nil(t, MigrateUp(gormDb, 0))
+	for _, model := range TableSlice {
+		stmt := &gorm.Statement{DB: gormDb}
+		require.Nil(t, stmt.Parse(model))
+		for _, field := range stmt.Schema.Fields {
+			if len(field.DBName) != 0 {
+				require.True(t, m.HasColumn(model, field.DBName),
+					stmt.Schema.Table+"."+field.DBName)
+			}
+		}
+	}
+
+	require.Nil(t, MigrateDown(gormDb, 0))
+	for _, model := range TableSlice {
+		require.False(t, m.HasTable(model))
+	}
+}
+
+func TestGrantBuiltInPermissionsSqlite(t *testing.T) {
+	gormDb, err := GormInit(&DBConfig{
+		Driver: DB_DRIVER_SQLITE,
+		DbName: SQLITE_MEMORY,
+	}, zap.NewNop().Sugar())
+	require.Nil(t, err)
+
+	require.Nil(t, MigrateUp(gormDb, 14))
+	require.Nil(t, gormDb.Create(&Role{RoleName: CONTROL_STR, BuiltIn: true,
+		Permissions: "orbit:write,debris:read"}).Error)
+	require.Nil(t, gormDb.Create(&Role{RoleName: EXEC_STR, BuiltIn: true,
+		Permissions: "fault:read,satellite:read"}).Error)
+
+	permissionsOf := func(roleName string) string {
+		role := new(Role)
+		require.Nil(t, gormDb.Where("role_name = ?", roleName).First(role).Error)
+		return role.Permissions
+	}
+
+	require.Nil(t, MigrateUp(gormDb, 0))
+	require.Equal(t, "debris:read,orbit:write,satellite:read,satellite:write", permissionsOf(CONTROL_STR))
+	require.Equal(t, "fault:read,satellite:read", permissionsOf(EXEC_STR))
+
+	require.Nil(t, MigrateDown(gormDb, 14))
+	require.Equal(t, "debris:read,orbit:write", permissionsOf(CONTROL_STR))
+	require.Equal(t, "fault:read", permissionsOf(EXEC_STR))
+}
+
+// sqlRecorder collect the sql printed by the gorm logger
+type sqlRecorder struct {
+	sqls []string
+}
+
+func (r *sqlRecorder) Printf(format string, args ...interface{}) {
+	r.sqls = append(r.sqls, fmt.Sprintf(format, args...))
+}
+
+func TestCreateTablesPostgresDryRun(t *testing.T) {
+	recorder := new(sqlRecorder)
+	gormDb, err := gorm.Open(postgres.New(postgres.Config{
+		DSN: "host=localhost user=postgres dbname=postgres sslmode=disable",
+	}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.New(recorder, logger.Config{LogLevel: logger.Info}),
+	})
+	require.Nil(t, err)
+
+	tables := append(append([]interface{}{}, baselineTables...), TableSlice...)
+	for _, table := range tables {
+		require.Nil(t, gormDb.Migrator().CreateTable(table))
+	}
+
+	createSqls := make([]string, 0)
+	for _, sql := range recorder.sqls {
+		if strings.Contains(sql, "CREATE TABLE") {
+			createSqls = append(createSqls, sql)
+		}
+	}
+	require.Len(t, createSqls, len(tables))
+
+	all := strings.Join(createSqls, "\n")
+	require.NotContains(t, all, "longtext")
+	require.Contains(t, all, `"fault_description" text`)
+	require.Contains(t, all, `"operation_record" text`)
+}
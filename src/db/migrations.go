@@ -0,0 +1,585 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Migrations 按版本顺序排列，已发布的迁移不能修改，表结构变化时在末尾追加新的迁移
+var Migrations = []*Migration{
+	{
+		// 基线版本，按发布时的表结构快照建表，已有的库执行时只会补齐缺少的表和列
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(baselineTables...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(baselineTables...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "rename debris volunme to volume",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&Debris{}, "volunme") {
+				return nil
+			}
+
+			// 基线版本在已有的库上已经补齐了 volume 列
+			if !m.HasColumn(&Debris{}, "volume") {
+				return m.RenameColumn(&Debris{}, "volunme", "volume")
+			}
+
+			// deleted_at 列在版本 3 才添加，不能经过模型的软删除条件
+			err := tx.Table(DEBRIS_TABLE_NAME).Where("1 = 1").
+				Update("volume", gorm.Expr("volunme")).Error
+			if err != nil {
+				return err
+			}
+			return m.DropColumn(&Debris{}, "volunme")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().RenameColumn(&Debris{}, "volume", "volunme")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "soft delete and unique business id of the reference data",
+		Up: func(tx *gorm.DB) error {
+			for _, t := range referenceTables {
+				if err := uniqueBusinessId(tx, t.model, t.column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, t := range referenceTables {
+				m := tx.Migrator()
+				name := businessIdIndexName(t.model, t.column)
+				if m.HasIndex(t.model, name) {
+					if err := m.DropIndex(t.model, name); err != nil {
+						return err
+					}
+				}
+
+				if err := m.DropColumn(t.model, "deleted_at"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 4,
+		Name:    "satellite catalog",
+		Up: func(tx *gorm.DB) error {
+			err := tableOptions(tx).AutoMigrate(&v4SatelliteCatalog{})
+			if err != nil {
+				return err
+			}
+			return uniqueBusinessId(tx, &v4SatelliteCatalog{}, "satellite_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v4SatelliteCatalog{})
+		},
+	},
+	{
+		// 星座的卫星数量和链路状态改为由卫星目录中的成员实时计算
+		Version: 5,
+		Name:    "derive constellation health from members",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, column := range constellationHealthColumns {
+				if !m.HasColumn(&Constellation{}, column) {
+					continue
+				}
+				if err := m.DropColumn(&Constellation{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range constellationHealthColumns {
+				err := tx.Exec("ALTER TABLE " + CONSTELLATION_TABLE_NAME + " ADD COLUMN " +
+					column + " integer DEFAULT 0").Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 6,
+		Name:    "numeric telemetry with units",
+		Up: func(tx *gorm.DB) error {
+			err := tableOptions(tx).AutoMigrate(&v6CommState{}, &v6NetState{}, &v6Control{})
+			if err != nil {
+				return err
+			}
+
+			for _, t := range telemetryColumns {
+				if err := numericTelemetry(tx, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, t := range telemetryColumns {
+				table := t.model.TableName()
+				err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + t.legacy + " text").Error
+				if err != nil {
+					return err
+				}
+
+				if err := stringTelemetry(tx, t); err != nil {
+					return err
+				}
+
+				err = tx.Exec("ALTER TABLE " + table + " DROP COLUMN " + t.column).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 7,
+		Name:    "fault lifecycle",
+		Up: func(tx *gorm.DB) error {
+			err := tableOptions(tx).AutoMigrate(&v7Fault{}, &v7FaultTransition{})
+			if err != nil {
+				return err
+			}
+
+			// 已有的故障按修复状态归入待确认或已修复，修复时间未知
+			err = tx.Model(&v7Fault{}).
+				Where("(fault_state IS NULL OR fault_state = 0) AND repair_state = ?", WRONG).
+				Update("fault_state", FAULT_OPEN).Error
+			if err != nil {
+				return err
+			}
+			return tx.Model(&v7Fault{}).Where("fault_state IS NULL OR fault_state = 0").
+				Update("fault_state", FAULT_RESOLVED).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&v7FaultTransition{}); err != nil {
+				return err
+			}
+
+			if m.HasIndex(&v7Fault{}, "FaultState") {
+				if err := m.DropIndex(&v7Fault{}, "FaultState"); err != nil {
+					return err
+				}
+			}
+
+			for _, column := range []string{"FaultState", "Assignee", "AcknowledgedAt",
+				"ResolvedAt", "ClosedAt"} {
+				if err := m.DropColumn(&v7Fault{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// 同一条指令的多行状态记录合并为一行，状态变化写入 instruction_event
+		Version: 8,
+		Name:    "single row instruction with events",
+		Up: func(tx *gorm.DB) error {
+			err := tableOptions(tx).AutoMigrate(&v8InstructionEvent{})
+			if err != nil {
+				return err
+			}
+
+			if err := collapseInstructions(tx); err != nil {
+				return err
+			}
+
+			name := businessIdIndexName(&Instruction{}, "instruction_id")
+			if tx.Migrator().HasIndex(&Instruction{}, name) {
+				return nil
+			}
+			return tx.Exec("CREATE UNIQUE INDEX " + name + " ON " + INSTRUCTION_TABLE_NAME +
+				" (instruction_id)").Error
+		},
+		// 回滚只恢复表结构，合并掉的历史行不再拆分
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			name := businessIdIndexName(&Instruction{}, "instruction_id")
+			if m.HasIndex(&Instruction{}, name) {
+				if err := m.DropIndex(&Instruction{}, name); err != nil {
+					return err
+				}
+			}
+			return m.DropTable(&v8InstructionEvent{})
+		},
+	},
+	{
+		// 已有的指令不进入队列
+		Version: 9,
+		Name:    "instruction execution queue",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(&v9InstructionTask{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v9InstructionTask{})
+		},
+	},
+	{
+		Version: 10,
+		Name:    "instruction results reported by the exec system",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(&v10InstructionTask{}, &v10InstructionResult{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&v10InstructionResult{}); err != nil {
+				return err
+			}
+
+			for _, column := range []string{"Progress", "ProgressMessage", "ProgressAt"} {
+				if err := m.DropColumn(&v10InstructionTask{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 11,
+		Name:    "scheduled instructions",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(&v11Instruction{}, &v11InstructionTask{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropColumn(&v11InstructionTask{}, "WindowEnd"); err != nil {
+				return err
+			}
+
+			for _, column := range []string{"ScheduledTime", "ScheduleWindowEnd"} {
+				if err := m.DropColumn(&v11Instruction{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 12,
+		Name:    "instruction approval",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(&v12Instruction{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, column := range []string{"Reviewer", "ReviewTime"} {
+				if err := m.DropColumn(&v12Instruction{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 13,
+		Name:    "conjunction screening",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(&v13Conjunction{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v13Conjunction{})
+		},
+	},
+	{
+		Version: 14,
+		Name:    "avoidance policy",
+		Up: func(tx *gorm.DB) error {
+			return tableOptions(tx).AutoMigrate(&v14AvoidancePolicy{}, &v14Instruction{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropColumn(&v14Instruction{}, "ConjunctionTca"); err != nil {
+				return err
+			}
+			return m.DropTable(&v14AvoidancePolicy{})
+		},
+	},
+	{
+		// 已有的内置角色只在创建时写入初始权限，升级时补上卫星目录的权限
+		Version: 15,
+		Name:    "grant satellite catalog permissions to built-in roles",
+		Up: func(tx *gorm.DB) error {
+			return changeBuiltInPermissions(tx, catalogPermissionGrants, true)
+		},
+		Down: func(tx *gorm.DB) error {
+			return changeBuiltInPermissions(tx, catalogPermissionGrants, false)
+		},
+	},
+}
+
+// 参考数据的业务 id 在未删除的记录中唯一，删除为软删除，DeletedAt 为删除时间（毫秒）
+type referenceTable struct {
+	model  ModelStruct
+	column string
+}
+
+var referenceTables = []referenceTable{
+	{&Debris{}, "debris_id"},
+	{&Orbit{}, "orbit_id"},
+	{&Constellation{}, "constellation_id"},
+}
+
+// 遥测数值由字符串改为带单位的数值列
+type telemetryColumn struct {
+	model  ModelStruct
+	legacy string
+	column string
+	unit   *Unit
+}
+
+var telemetryColumns = []telemetryColumn{
+	{&CommState{}, "comm_bandwidth", "comm_bandwidth_mbps", UnitMbps},
+	{&CommState{}, "comm_delay", "comm_delay_ms", UnitMs},
+	{&CommState{}, "link_load", "link_load_percent", UnitPercent},
+	{&NetState{}, "network_bandwidth", "network_bandwidth_mbps", UnitMbps},
+	{&Control{}, "satellite_power", "satellite_power_w", UnitWatt},
+}
+
+// numericTelemetry 解析旧的字符串列写入数值列后删除旧列，有无法解析的值时迁移失败，
+// 需要先修正或清空这些记录，旧列保留不变
+func numericTelemetry(tx *gorm.DB, t telemetryColumn) error {
+	m := tx.Migrator()
+	if !m.HasColumn(t.model, t.legacy) {
+		return nil
+	}
+
+	type legacyRow struct {
+		Id    int32
+		Value string
+	}
+	rows := make([]*legacyRow, 0)
+	err := tx.Table(t.model.TableName()).Select("id, " + t.legacy + " AS value").
+		Where(t.legacy + " <> ''").Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	values := make(map[int32]float64, len(rows))
+	invalidIds := make([]string, 0)
+	for _, row := range rows {
+		value, err := ParseQuantity(row.Value, t.unit)
+		if err != nil {
+			invalidIds = append(invalidIds, strconv.Itoa(int(row.Id)))
+			continue
+		}
+		values[row.Id] = value
+	}
+	if len(invalidIds) != 0 {
+		return fmt.Errorf("the %s.%s of the rows with id [%s] can not be parsed as %s, correct or clear them first",
+			t.model.TableName(), t.legacy, strings.Join(invalidIds, ","), t.unit.Symbol)
+	}
+
+	for _, row := range rows {
+		err = tx.Table(t.model.TableName()).Where("id = ?", row.Id).
+			Update(t.column, values[row.Id]).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// sqlite 驱动删除列时会重建表，comm_state 表中与表同名的列会被误改名，这里直接删除列
+	return tx.Exec("ALTER TABLE " + t.model.TableName() + " DROP COLUMN " + t.legacy).Error
+}
+
+// stringTelemetry 回滚时将数值连同单位写回字符串列
+func stringTelemetry(tx *gorm.DB, t telemetryColumn) error {
+	type numericRow struct {
+		Id    int32
+		Value float64
+	}
+	rows := make([]*numericRow, 0)
+	err := tx.Table(t.model.TableName()).Select("id, " + t.column + " AS value").Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		err = tx.Table(t.model.TableName()).Where("id = ?", row.Id).
+			Update(t.legacy, strconv.FormatFloat(row.Value, 'f', -1, 64)+t.unit.Symbol).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var constellationHealthColumns = []string{
+	"satellite_total_num", "satellite_up_num", "satellite_down_num", "satellite_link_state",
+}
+
+// uniqueBusinessId 已有的重复记录只保留最新的一条，其余标记为删除，
+// 删除时间取记录 id 以保证唯一索引不冲突
+func uniqueBusinessId(tx *gorm.DB, model ModelStruct, column string) error {
+	m := tx.Migrator()
+	if !m.HasColumn(model, "deleted_at") {
+		if err := m.AddColumn(model, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+
+	dups := make([]string, 0)
+	err := tx.Model(model).Group(column).Having("COUNT(*) > 1").Pluck(column, &dups).Error
+	if err != nil {
+		return err
+	}
+
+	for _, dup := range dups {
+		ids := make([]int32, 0)
+		err = tx.Model(model).Where(column+" = ?", dup).Order("id desc").Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(model).Where("id IN ?", ids[1:]).
+			Update("deleted_at", gorm.Expr("id")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	name := businessIdIndexName(model, column)
+	if m.HasIndex(model, name) {
+		return nil
+	}
+	return tx.Exec("CREATE UNIQUE INDEX " + name + " ON " + model.TableName() +
+		" (" + column + ", deleted_at)").Error
+}
+
+func businessIdIndexName(model ModelStruct, column string) string {
+	return "uk_" + model.TableName() + "_" + column
+}
+
+// collapseInstructions 每条指令保留最早的一行，状态和执行时间取最新的一行，
+// 相邻两行的状态变化记为一次事件，事件时间取该行的记录时间，操作人未知
+func collapseInstructions(tx *gorm.DB) error {
+	rows := make([]*Instruction, 0)
+	err := tx.Select("id, instruction_id, exec_state, exec_instruction_time, last_time").
+		Order("instruction_id, id").Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].InstructionId == rows[start].InstructionId {
+			end++
+		}
+
+		group := rows[start:end]
+		start = end
+
+		events := make([]*InstructionEvent, 0, len(group))
+		var from InstructionExecState
+		var execTime int64
+		for _, row := range group {
+			if row.ExecInstructionTime != 0 {
+				execTime = row.ExecInstructionTime
+			}
+			if row.ExecState == from {
+				continue
+			}
+			events = append(events, &InstructionEvent{
+				InstructionId: row.InstructionId,
+				FromState:     from,
+				ToState:       row.ExecState,
+				Note:          "历史记录迁移",
+				EventTime:     row.LastTime / 1000,
+			})
+			from = row.ExecState
+		}
+
+		if len(events) != 0 {
+			if err := tx.Create(&events).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(group) == 1 {
+			continue
+		}
+
+		ids := make([]int32, 0, len(group)-1)
+		for _, row := range group[1:] {
+			ids = append(ids, row.Id)
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&Instruction{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&Instruction{}).Where("id = ?", group[0].Id).
+			Updates(map[string]interface{}{
+				"exec_state":            from,
+				"exec_instruction_time": execTime,
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// catalogPermissionGrants 内置角色在版本 15 获得的权限
+var catalogPermissionGrants = map[string][]string{
+	CONTROL_STR: {"satellite:read", "satellite:write"},
+	EXEC_STR:    {"satellite:read"},
+}
+
+// changeBuiltInPermissions 为已有的内置角色添加或收回权限，角色不存在时由启动时的初始化创建
+func changeBuiltInPermissions(tx *gorm.DB, grants map[string][]string, grant bool) error {
+	for roleName, perms := range grants {
+		role := new(Role)
+		err := tx.Where("role_name = ? AND built_in = ?", roleName, true).First(role).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		changed := make(map[string]bool)
+		for _, perm := range perms {
+			changed[perm] = true
+		}
+
+		permissions := make([]string, 0)
+		for _, perm := range strings.Split(role.Permissions, ",") {
+			if len(perm) != 0 && !changed[perm] {
+				permissions = append(permissions, perm)
+			}
+		}
+		if grant {
+			permissions = append(permissions, perms...)
+		}
+		sort.Strings(permissions)
+
+		err = tx.Model(&Role{}).Where("id = ?", role.Id).
+			Update("permissions", strings.Join(permissions, ",")).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
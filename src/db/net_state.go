@@ -4,12 +4,12 @@ const NETSTATE_TABLE_NAME = "net_state"
 
 type NetState struct {
 	GeneralField
-	SatelliteId      string `gorm:"index"`
-	SatelliteName    string `gorm:"index"`
-	OrbitId          string
-	NetworkSegment   string
-	NetworkState     State
-	NetworkBandwidth string
+	SatelliteId          string `gorm:"index"`
+	SatelliteName        string `gorm:"index"`
+	OrbitId              string
+	NetworkSegment       string
+	NetworkState         State
+	NetworkBandwidthMbps float64
 }
 
 func (n *NetState) TableName() string {
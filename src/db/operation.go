@@ -7,7 +7,7 @@ type Operation struct {
 	Operator        string `gorm:"index"`
 	OperatorIp      string
 	OperationTime   int64
-	OperationRecord string `gorm:"type:longtext"`
+	OperationRecord LongText
 	SatelliteId     string `gorm:"index"`
 	SatelliteName   string
 }
@@ -1,5 +1,7 @@
 package db
 
+import "gorm.io/plugin/soft_delete"
+
 const ORBIT_TABLE_NAME = "orbit"
 
 type Orbit struct {
@@ -11,6 +13,7 @@ type Orbit struct {
 	OrbitAngle             float64
 	AscendingNodeLongitude float64
 	Perigee                float64
+	DeletedAt              soft_delete.DeletedAt `gorm:"softDelete:milli;default:0"`
 }
 
 func (o *Orbit) TableName() string {
@@ -0,0 +1,21 @@
+package db
+
+const PWDRESETTOKEN_TABLE_NAME = "pwd_reset_token"
+
+// PwdResetToken 找回密码的一次性 token，只保存哈希值
+type PwdResetToken struct {
+	GeneralField
+	UserId    int32  `gorm:"index"`
+	TokenHash string `gorm:"uniqueIndex;size:64"`
+	ExpiresAt int64
+	UsedAt    int64
+}
+
+func (p *PwdResetToken) TableName() string {
+	return PWDRESETTOKEN_TABLE_NAME
+}
+
+func init() {
+	pwdResetToken := new(PwdResetToken)
+	TableSlice = append(TableSlice, &pwdResetToken)
+}
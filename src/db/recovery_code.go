@@ -0,0 +1,20 @@
+package db
+
+const RECOVERYCODE_TABLE_NAME = "recovery_code"
+
+// RecoveryCode 两步验证的恢复码，每个只能使用一次，只保存哈希值
+type RecoveryCode struct {
+	GeneralField
+	UserId   int32  `gorm:"index"`
+	CodeHash string `gorm:"size:64"`
+	UsedAt   int64
+}
+
+func (r *RecoveryCode) TableName() string {
+	return RECOVERYCODE_TABLE_NAME
+}
+
+func init() {
+	recoveryCode := new(RecoveryCode)
+	TableSlice = append(TableSlice, &recoveryCode)
+}
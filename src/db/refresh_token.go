@@ -0,0 +1,23 @@
+package db
+
+const REFRESHTOKEN_TABLE_NAME = "refresh_token"
+
+// RefreshToken 刷新 token，只保存哈希值。同一次登录产生的刷新 token 属于同一个 SessionId，
+// 每次刷新后旧 token 即被吊销，旧 token 被再次使用时吊销整个会话
+type RefreshToken struct {
+	GeneralField
+	UserId    int32  `gorm:"index"`
+	SessionId string `gorm:"index;size:64"`
+	TokenHash string `gorm:"uniqueIndex;size:64"`
+	ExpiresAt int64
+	RevokedAt int64
+}
+
+func (r *RefreshToken) TableName() string {
+	return REFRESHTOKEN_TABLE_NAME
+}
+
+func init() {
+	refreshToken := new(RefreshToken)
+	TableSlice = append(TableSlice, &refreshToken)
+}
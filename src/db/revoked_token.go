@@ -0,0 +1,20 @@
+package db
+
+const REVOKEDTOKEN_TABLE_NAME = "revoked_token"
+
+// RevokedToken 已吊销但尚未过期的 access token
+type RevokedToken struct {
+	GeneralField
+	Jti       string `gorm:"uniqueIndex;size:64"`
+	UserId    int32  `gorm:"index"`
+	ExpiresAt int64  `gorm:"index"`
+}
+
+func (r *RevokedToken) TableName() string {
+	return REVOKEDTOKEN_TABLE_NAME
+}
+
+func init() {
+	revokedToken := new(RevokedToken)
+	TableSlice = append(TableSlice, &revokedToken)
+}
@@ -0,0 +1,40 @@
+package db
+
+const (
+	ROLE_TABLE_NAME = "role"
+
+	USER_ROLE_BINDING_TABLE_NAME = "user_role_binding"
+)
+
+// Role 角色，即一组权限的集合，Permissions 以逗号分隔
+type Role struct {
+	GeneralField
+	RoleName    string `gorm:"uniqueIndex;size:64"`
+	Description string
+	Permissions string `gorm:"type:text"`
+	// BuiltIn 内置角色与 UserRoleType 同名，不能删除
+	BuiltIn bool
+}
+
+func (r *Role) TableName() string {
+	return ROLE_TABLE_NAME
+}
+
+// UserRoleBinding 用户与角色的多对多关系
+type UserRoleBinding struct {
+	GeneralField
+	UserId int32 `gorm:"uniqueIndex:idx_user_role_binding"`
+	RoleId int32 `gorm:"uniqueIndex:idx_user_role_binding;index"`
+}
+
+func (u *UserRoleBinding) TableName() string {
+	return USER_ROLE_BINDING_TABLE_NAME
+}
+
+func init() {
+	role := new(Role)
+	TableSlice = append(TableSlice, &role)
+
+	userRoleBinding := new(UserRoleBinding)
+	TableSlice = append(TableSlice, &userRoleBinding)
+}
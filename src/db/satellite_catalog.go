@@ -0,0 +1,54 @@
+package db
+
+import "gorm.io/plugin/soft_delete"
+
+const SATELLITE_CATALOG_TABLE_NAME = "satellite_catalog"
+
+type SatelliteStatus int32
+
+const (
+	SATELLITE_PLANNED SatelliteStatus = iota + 1
+	SATELLITE_IN_ORBIT
+	SATELLITE_RETIRED
+)
+
+const (
+	SATELLITE_PLANNED_STR = "待发射"
+
+	SATELLITE_IN_ORBIT_STR = "在轨"
+
+	SATELLITE_RETIRED_STR = "退役"
+)
+
+var SatelliteStatusName = map[SatelliteStatus]string{
+	SATELLITE_PLANNED:  SATELLITE_PLANNED_STR,
+	SATELLITE_IN_ORBIT: SATELLITE_IN_ORBIT_STR,
+	SATELLITE_RETIRED:  SATELLITE_RETIRED_STR,
+}
+
+var SatelliteStatusValue = map[string]SatelliteStatus{
+	SATELLITE_PLANNED_STR:  SATELLITE_PLANNED,
+	SATELLITE_IN_ORBIT_STR: SATELLITE_IN_ORBIT,
+	SATELLITE_RETIRED_STR:  SATELLITE_RETIRED,
+}
+
+// SatelliteCatalog 卫星主数据，遥测、故障和指令中的卫星 id 都必须在目录中
+type SatelliteCatalog struct {
+	GeneralField
+	SatelliteId     string `gorm:"index"`
+	SatelliteName   string `gorm:"index"`
+	OrbitId         string `gorm:"index"`
+	ConstellationId string `gorm:"index"`
+	LaunchDate      int64
+	Status          SatelliteStatus
+	DeletedAt       soft_delete.DeletedAt `gorm:"softDelete:milli;default:0"`
+}
+
+func (s *SatelliteCatalog) TableName() string {
+	return SATELLITE_CATALOG_TABLE_NAME
+}
+
+func init() {
+	satelliteCatalog := new(SatelliteCatalog)
+	TableSlice = append(TableSlice, &satelliteCatalog)
+}
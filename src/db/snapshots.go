@@ -0,0 +1,182 @@
+package db
+
+import "gorm.io/plugin/soft_delete"
+
+// 基线之后各迁移版本新增的表和列的快照。新表为发布时完整的表结构，已有的表只包含该版本新增的列，
+// 这里的结构体和表名不能再修改，枚举类型统一写为 int32
+
+// 版本 4
+type v4SatelliteCatalog struct {
+	Id              int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime        int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId     string `gorm:"index"`
+	SatelliteName   string `gorm:"index"`
+	OrbitId         string `gorm:"index"`
+	ConstellationId string `gorm:"index"`
+	LaunchDate      int64
+	Status          int32
+	DeletedAt       soft_delete.DeletedAt `gorm:"softDelete:milli;default:0"`
+}
+
+func (v *v4SatelliteCatalog) TableName() string { return "satellite_catalog" }
+
+// 版本 6
+type v6CommState struct {
+	CommBandwidthMbps float64
+	CommDelayMs       float64
+	LinkLoadPercent   float64
+}
+
+func (v *v6CommState) TableName() string { return "comm_state" }
+
+type v6NetState struct {
+	NetworkBandwidthMbps float64
+}
+
+func (v *v6NetState) TableName() string { return "net_state" }
+
+type v6Control struct {
+	SatellitePowerW float64
+}
+
+func (v *v6Control) TableName() string { return "control" }
+
+// 版本 7
+type v7Fault struct {
+	FaultState     int32 `gorm:"index"`
+	Assignee       string
+	AcknowledgedAt int64
+	ResolvedAt     int64
+	ClosedAt       int64
+}
+
+func (v *v7Fault) TableName() string { return "fault" }
+
+type v7FaultTransition struct {
+	Id             int32 `gorm:"primaryKey;autoIncrement"`
+	LastTime       int64 `gorm:"autoCreateTime:milli;index"`
+	FaultId        int32 `gorm:"index"`
+	FromState      int32
+	ToState        int32
+	Operator       string
+	Assignee       string
+	Note           string `gorm:"type:text"`
+	TransitionTime int64
+}
+
+func (v *v7FaultTransition) TableName() string { return "fault_transition" }
+
+// 版本 8
+type v8InstructionEvent struct {
+	Id            int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime      int64  `gorm:"autoCreateTime:milli;index"`
+	InstructionId string `gorm:"index"`
+	FromState     int32
+	ToState       int32
+	Operator      string
+	Note          string `gorm:"type:text"`
+	EventTime     int64
+}
+
+func (v *v8InstructionEvent) TableName() string { return "instruction_event" }
+
+// 版本 9
+type v9InstructionTask struct {
+	Id             int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime       int64  `gorm:"autoCreateTime:milli;index"`
+	InstructionId  string `gorm:"uniqueIndex"`
+	TaskState      int32  `gorm:"index"`
+	Attempts       int32
+	MaxAttempts    int32
+	TimeoutMs      int64
+	NextRunAt      int64 `gorm:"index"`
+	Worker         string
+	LeaseExpiresAt int64
+	LastError      string `gorm:"type:text"`
+}
+
+func (v *v9InstructionTask) TableName() string { return "instruction_task" }
+
+// 版本 10
+type v10InstructionTask struct {
+	Progress        int32
+	ProgressMessage string
+	ProgressAt      int64
+}
+
+func (v *v10InstructionTask) TableName() string { return "instruction_task" }
+
+type v10InstructionResult struct {
+	Id            int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime      int64  `gorm:"autoCreateTime:milli;index"`
+	InstructionId string `gorm:"index"`
+	Attempt       int32
+	ExecState     int32
+	ErrorCode     string
+	ErrorMessage  string `gorm:"type:text"`
+	Telemetry     string `gorm:"type:text"`
+	StartedAt     int64
+	FinishedAt    int64
+	ReportedBy    string
+}
+
+func (v *v10InstructionResult) TableName() string { return "instruction_result" }
+
+// 版本 11
+type v11Instruction struct {
+	ScheduledTime     int64
+	ScheduleWindowEnd int64
+}
+
+func (v *v11Instruction) TableName() string { return "instruction" }
+
+type v11InstructionTask struct {
+	WindowEnd int64
+}
+
+func (v *v11InstructionTask) TableName() string { return "instruction_task" }
+
+// 版本 12
+type v12Instruction struct {
+	Reviewer   string
+	ReviewTime int64
+}
+
+func (v *v12Instruction) TableName() string { return "instruction" }
+
+// 版本 13
+type v13Conjunction struct {
+	Id             int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime       int64  `gorm:"autoCreateTime:milli;index"`
+	SatelliteId    string `gorm:"index"`
+	SatelliteName  string
+	DebrisId       string `gorm:"index"`
+	DebrisName     string
+	Tca            int64
+	MissDistanceKm float64
+	Treaten        int32 `gorm:"index"`
+	ScreenedAt     int64
+}
+
+func (v *v13Conjunction) TableName() string { return "conjunction" }
+
+// 版本 14
+type v14AvoidancePolicy struct {
+	Id              int32  `gorm:"primaryKey;autoIncrement"`
+	LastTime        int64  `gorm:"autoCreateTime:milli;index"`
+	PolicyName      string `gorm:"index"`
+	SatelliteId     string `gorm:"index"`
+	ConstellationId string `gorm:"index"`
+	Mode            int32
+	Source          string
+	ContentTemplate string `gorm:"type:text"`
+	Enabled         bool
+}
+
+func (v *v14AvoidancePolicy) TableName() string { return "avoidance_policy" }
+
+type v14Instruction struct {
+	ConjunctionTca int64
+}
+
+func (v *v14Instruction) TableName() string { return "instruction" }
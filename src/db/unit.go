@@ -0,0 +1,79 @@
+package db
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Unit 遥测数值的单位，入库时统一换算为 Symbol，Factors 为各写法到 Symbol 的倍数
+type Unit struct {
+	Symbol  string
+	Factors map[string]float64
+	// Max 为 0 表示没有上限
+	Max float64
+}
+
+var (
+	UnitMbps = &Unit{
+		Symbol: "Mbps",
+		Factors: map[string]float64{
+			"bps": 1e-6, "kbps": 1e-3, "mbps": 1, "gbps": 1e3,
+		},
+	}
+
+	UnitMs = &Unit{
+		Symbol: "ms",
+		Factors: map[string]float64{
+			"us": 1e-3, "μs": 1e-3, "µs": 1e-3, "ms": 1, "s": 1e3,
+		},
+	}
+
+	UnitPercent = &Unit{
+		Symbol: "%",
+		Factors: map[string]float64{
+			"%": 1, "percent": 1,
+		},
+		Max: 100,
+	}
+
+	UnitWatt = &Unit{
+		Symbol: "W",
+		Factors: map[string]float64{
+			"mw": 1e-3, "w": 1, "kw": 1e3,
+		},
+	}
+)
+
+var quantityRegexp = regexp.MustCompile(`^([+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?)\s*(.*)$`)
+
+// ParseQuantity parse the value such as "120Mbps" or "35 ms" in the unit,
+// the value without the unit is in the Symbol of the unit
+func ParseQuantity(input string, unit *Unit) (float64, error) {
+	input = strings.TrimSpace(input)
+	matches := quantityRegexp.FindStringSubmatch(input)
+	if matches == nil {
+		return 0, errors.New("invalid quantity: " + input)
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, errors.New("invalid quantity: " + input)
+	}
+
+	factor := 1.0
+	if suffix := strings.ToLower(matches[2]); len(suffix) != 0 {
+		f, ok := unit.Factors[suffix]
+		if !ok {
+			return 0, errors.New("the unit of " + input + " is not " + unit.Symbol)
+		}
+		factor = f
+	}
+
+	value *= factor
+	if unit.Max != 0 && value > unit.Max {
+		return 0, errors.New("the quantity " + input + " is out of range")
+	}
+	return value, nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuantity(t *testing.T) {
+	cases := []struct {
+		input string
+		unit  *Unit
+		value float64
+	}{
+		{"120Mbps", UnitMbps, 120},
+		{"1.5 Gbps", UnitMbps, 1500},
+		{"512kbps", UnitMbps, 0.512},
+		{"120", UnitMbps, 120},
+		{"35 ms", UnitMs, 35},
+		{"2s", UnitMs, 2000},
+		{"500us", UnitMs, 0.5},
+		{"75%", UnitPercent, 75},
+		{" 30 percent ", UnitPercent, 30},
+		{"1.2kW", UnitWatt, 1200},
+		{"800 W", UnitWatt, 800},
+		{"1e3mW", UnitWatt, 1},
+	}
+	for _, c := range cases {
+		value, err := ParseQuantity(c.input, c.unit)
+		require.Nil(t, err, c.input)
+		require.InDelta(t, c.value, value, 1e-9, c.input)
+	}
+
+	for _, input := range []string{"", "fast", "-5ms", "12 Mbps/s", "120W"} {
+		_, err := ParseQuantity(input, UnitMbps)
+		require.NotNil(t, err, input)
+	}
+
+	_, err := ParseQuantity("120%", UnitPercent)
+	require.NotNil(t, err)
+}
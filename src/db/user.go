@@ -9,6 +9,7 @@ const (
 	EXEC
 	TRACE
 	MONITOR
+	ADMIN
 )
 
 const (
@@ -19,6 +20,8 @@ const (
 	TRACE_STR = "溯源系统"
 
 	MONITOR_STR = "监控系统"
+
+	ADMIN_STR = "管理员"
 )
 
 var UserRoleTypeName = map[UserRoleType]string{
@@ -26,6 +29,7 @@ var UserRoleTypeName = map[UserRoleType]string{
 	EXEC:    EXEC_STR,
 	TRACE:   TRACE_STR,
 	MONITOR: MONITOR_STR,
+	ADMIN:   ADMIN_STR,
 }
 
 var UserRoleTypeValue = map[string]UserRoleType{
@@ -33,6 +37,35 @@ var UserRoleTypeValue = map[string]UserRoleType{
 	EXEC_STR:    EXEC,
 	TRACE_STR:   TRACE,
 	MONITOR_STR: MONITOR,
+	ADMIN_STR:   ADMIN,
+}
+
+type UserStateType int32
+
+const (
+	USER_ENABLED UserStateType = iota + 1
+	USER_DISABLED
+	USER_PENDING
+)
+
+const (
+	USER_ENABLED_STR = "启用"
+
+	USER_DISABLED_STR = "禁用"
+
+	USER_PENDING_STR = "待审批"
+)
+
+var UserStateTypeName = map[UserStateType]string{
+	USER_ENABLED:  USER_ENABLED_STR,
+	USER_DISABLED: USER_DISABLED_STR,
+	USER_PENDING:  USER_PENDING_STR,
+}
+
+var UserStateTypeValue = map[string]UserStateType{
+	USER_ENABLED_STR:  USER_ENABLED,
+	USER_DISABLED_STR: USER_DISABLED,
+	USER_PENDING_STR:  USER_PENDING,
 }
 
 type User struct {
@@ -43,6 +76,16 @@ type User struct {
 	UserNickName string
 	UserPhoneNum string
 	UserEmail    string
+	UserState    UserStateType `gorm:"default:1"`
+	// ServiceAccount 服务账号不能用密码登录，只能使用 API Key
+	ServiceAccount bool
+	// TokenVersion 自增后该用户已签发的 token 全部失效
+	TokenVersion int32
+	// TotpSecret 两步验证的 base32 密钥，TotpEnabled 为 false 时表示尚未完成绑定
+	TotpSecret  string
+	TotpEnabled bool
+	// TotpLastCounter 最近一次使用的动态码时间步，防止动态码被重放
+	TotpLastCounter int64
 }
 
 func (u *User) TableName() string {
@@ -0,0 +1,26 @@
+package db
+
+const USERSESSION_TABLE_NAME = "user_session"
+
+// UserSession 一次登录产生的会话，同一会话的 access token 和刷新 token 携带相同的 SessionId，
+// 会话被终止后其下所有 token 都不能再使用
+type UserSession struct {
+	GeneralField
+	UserId       int32  `gorm:"index"`
+	SessionId    string `gorm:"uniqueIndex;size:64"`
+	LoginIp      string
+	UserAgent    string
+	LoginTime    int64
+	LastActiveAt int64
+	ExpiresAt    int64 `gorm:"index"`
+	RevokedAt    int64
+}
+
+func (u *UserSession) TableName() string {
+	return USERSESSION_TABLE_NAME
+}
+
+func init() {
+	userSession := new(UserSession)
+	TableSlice = append(TableSlice, &userSession)
+}
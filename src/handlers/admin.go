@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminGetUserList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+		searchInput := c.Query("searchConditions")
+		userRoleStr := c.Query("userRole")
+		userStateStr := c.Query("userState")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.User),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchInput: searchInput,
+			SearchIndex: make([]string, 0),
+			QueryMap:    make(map[string]string),
+		}
+
+		if len(searchInput) != 0 {
+			params.SearchIndex = append(params.SearchIndex, "user_name")
+			params.SearchIndex = append(params.SearchIndex, "user_nick_name")
+			params.SearchIndex = append(params.SearchIndex, "user_email")
+		}
+
+		if len(userRoleStr) != 0 {
+			userRole, ok := db.UserRoleTypeValue[userRoleStr]
+			if !ok {
+				ParamsValueJSONResp("user role type not as expected", c)
+				return
+			}
+			params.QueryMap["user_role"] = strconv.Itoa(int(userRole))
+		}
+
+		if len(userStateStr) != 0 {
+			userState, ok := db.UserStateTypeValue[userStateStr]
+			if !ok {
+				ParamsValueJSONResp("user state type not as expected", c)
+				return
+			}
+			params.QueryMap["user_state"] = strconv.Itoa(int(userState))
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		resp := make([]*models.UserDetails, 0)
+
+		for sqlRows.Next() {
+			var user db.User
+			err := s.ScanRows(sqlRows, &user)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, &models.UserDetails{
+				UserName:       user.UserName,
+				UserNickName:   user.UserNickName,
+				UserRole:       db.UserRoleTypeName[user.UserRole],
+				UserPhoneNum:   user.UserPhoneNum,
+				UserEmail:      user.UserEmail,
+				UserState:      db.UserStateTypeName[user.UserState],
+				ServiceAccount: user.ServiceAccount,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       user.Id,
+					LastTime: user.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+// AdminUpdateUserState enable, disable or approve the user,
+// all the tokens of a disabled user are revoked
+func AdminUpdateUserState(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.UpdateUserStateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName, req.UserState)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		userState, ok := db.UserStateTypeValue[req.UserState]
+		if !ok || userState == db.USER_PENDING {
+			ParamsValueJSONResp("user state type not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		if req.UserName == claims.Name {
+			ParamsValueJSONResp("can not change the state of yourself", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.UpdateObject(&db.User{
+			GeneralField: db.GeneralField{Id: user.Id},
+			UserState:    userState,
+		})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if userState == db.USER_DISABLED {
+			err = s.RevokeAllUserTokens(user.Id)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+		}
+
+		err = recordOperation(s, c, claims.Name,
+			"修改用户状态："+req.UserName+"，"+db.UserStateTypeName[user.UserState]+
+				" -> "+req.UserState)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func AdminUpdateUserRole(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.UpdateUserRoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName, req.UserRole)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		userRole, ok := db.UserRoleTypeValue[req.UserRole]
+		if !ok {
+			ParamsValueJSONResp("user role type not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		if req.UserName == claims.Name {
+			ParamsValueJSONResp("can not change the role of yourself", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.UpdateObject(&db.User{
+			GeneralField: db.GeneralField{Id: user.Id},
+			UserRole:     userRole,
+		})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		// 修改主角色时同时替换其拥有的全部角色
+		err = s.SetUserRoles(user.Id, []string{req.UserRole})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name,
+			"修改用户角色："+req.UserName+"，"+db.UserRoleTypeName[user.UserRole]+
+				" -> "+req.UserRole)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func AdminResetUserPwd(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ResetUserPwdReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName, req.NewPwd)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.UpdateUserPwd(user.Id, req.NewPwd)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "重置用户密码："+req.UserName)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func AdminDeleteUser(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.DeleteUserReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		if req.UserName == claims.Name {
+			ParamsValueJSONResp("can not delete yourself", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.DeleteUser(user.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name,
+			"删除用户："+req.UserName+"，"+db.UserRoleTypeName[user.UserRole])
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// AdminAddInviteCode the plaintext code is only returned here
+func AdminAddInviteCode(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.AddInviteCodeReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserRole)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		userRole, ok := db.UserRoleTypeValue[req.UserRole]
+		if !ok {
+			ParamsValueJSONResp("user role type not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		code, inviteCode, err := s.CreateInviteCode(userRole, claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name,
+			"生成邀请码："+inviteCode.CodePrefix+"，"+req.UserRole)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.NewInviteCode{
+			InviteCode: code,
+			UserRole:   req.UserRole,
+			ExpiresAt:  inviteCode.ExpiresAt,
+		}, c)
+	}
+}
+
+func AdminGetInviteCodeList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.InviteCode),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchIndex: make([]string, 0),
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		resp := make([]*models.InviteCodeInfo, 0)
+
+		for sqlRows.Next() {
+			var inviteCode db.InviteCode
+			err := s.ScanRows(sqlRows, &inviteCode)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, &models.InviteCodeInfo{
+				CodePrefix: inviteCode.CodePrefix,
+				UserRole:   db.UserRoleTypeName[inviteCode.UserRole],
+				CreatedBy:  inviteCode.CreatedBy,
+				ExpiresAt:  inviteCode.ExpiresAt,
+				UsedBy:     inviteCode.UsedBy,
+				UsedTime:   inviteCode.UsedTime,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       inviteCode.Id,
+					LastTime: inviteCode.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DenyApiKey the routes of the user itself, such as logout and changing the
+// password, can only be accessed by the users who login
+func DenyApiKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			c.Abort()
+			return
+		}
+
+		if claims.Purpose == services.TOKEN_PURPOSE_API_KEY {
+			WithoutPermissionJSONResp("the api key can not access the route", c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminAddServiceAccount the service account has no password and can only use api keys
+func AdminAddServiceAccount(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.AddServiceAccountReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName, req.UserRole)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = checkTheKeyRule(req.UserName)
+		if err != nil {
+			ParamsFormatErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		role, ok := db.UserRoleTypeValue[req.UserRole]
+		if !ok {
+			ParamsValueJSONResp("user role type not as expected", c)
+			return
+		}
+
+		if role == db.ADMIN {
+			ParamsValueJSONResp("the service account can not be admin", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.User), "user_name", req.UserName)
+		if err == nil {
+			UniqueIndexJSONResp("用户名已存在", c)
+			return
+		}
+
+		nickName := req.UserNickName
+		if len(nickName) == 0 {
+			nickName = req.UserName
+		}
+
+		err = s.RegisterUser(&db.User{
+			UserName:       req.UserName,
+			UserRole:       role,
+			UserNickName:   nickName,
+			UserState:      db.USER_ENABLED,
+			ServiceAccount: true,
+		}, nil)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "添加服务账号："+req.UserName+"，"+req.UserRole)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// AdminAddApiKey the plaintext key is only returned here
+func AdminAddApiKey(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.AddApiKeyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName, req.KeyName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		if len(req.Scopes) == 0 {
+			ParamsMissingJSONResp("the scopes of the api key are required", c)
+			return
+		}
+
+		if req.ExpireDays < 0 {
+			ParamsValueJSONResp("the expire days can not be negative", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		var expiresAt int64
+		if req.ExpireDays > 0 {
+			expiresAt = time.Now().AddDate(0, 0, int(req.ExpireDays)).Unix()
+		}
+
+		key, apiKey, err := s.CreateApiKey(user, req.KeyName, req.Scopes, expiresAt, claims.Name)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "生成API Key："+req.UserName+"，"+
+			apiKey.KeyPrefix+"，"+apiKey.Scopes)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.NewApiKey{
+			ApiKey:    key,
+			KeyPrefix: apiKey.KeyPrefix,
+			ExpiresAt: apiKey.ExpiresAt,
+		}, c)
+	}
+}
+
+func AdminGetApiKeyList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+		searchConditions := c.Query("searchConditions")
+		userName := c.Query("userName")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		queryMap := make(map[string]string)
+		if len(userName) != 0 {
+			queryMap["user_name"] = userName
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.ApiKey),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchInput: searchConditions,
+			SearchIndex: []string{"key_name", "key_prefix"},
+			QueryMap:    queryMap,
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		resp := make([]*models.ApiKeyInfo, 0)
+
+		for sqlRows.Next() {
+			var apiKey db.ApiKey
+			err := s.ScanRows(sqlRows, &apiKey)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, &models.ApiKeyInfo{
+				UserName:   apiKey.UserName,
+				KeyName:    apiKey.KeyName,
+				KeyPrefix:  apiKey.KeyPrefix,
+				Scopes:     services.SplitPermissions(apiKey.Scopes),
+				CreatedBy:  apiKey.CreatedBy,
+				ExpiresAt:  apiKey.ExpiresAt,
+				LastUsedAt: apiKey.LastUsedAt,
+				LastUsedIp: apiKey.LastUsedIp,
+				RevokedAt:  apiKey.RevokedAt,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       apiKey.Id,
+					LastTime: apiKey.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+func AdminRevokeApiKey(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.RevokeApiKeyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.KeyPrefix)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		keyPrefix := strings.TrimSpace(req.KeyPrefix)
+		err = s.RevokeApiKey(keyPrefix)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "吊销API Key："+keyPrefix)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ControlAddAvoidancePolicy the source of the generated instructions is the policy name if empty
+func ControlAddAvoidancePolicy(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.AvoidancePolicyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.PolicyName, req.Mode)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = checkTheKeyRule(req.PolicyName)
+		if err != nil {
+			ParamsFormatErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		mode, ok := db.AvoidanceModeValue[req.Mode]
+		if !ok {
+			ParamsValueJSONResp("avoidance mode not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.AvoidancePolicy), "policy_name", req.PolicyName)
+		if err == nil {
+			UniqueIndexJSONResp("策略已存在", c)
+			return
+		}
+
+		policy := &db.AvoidancePolicy{
+			PolicyName: req.PolicyName,
+		}
+		setAvoidancePolicy(policy, &req, mode)
+
+		err = s.CheckAvoidancePolicyScope(policy)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.InsertOneObjertToDB(policy)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "添加规避策略："+req.PolicyName, nil,
+			newAvoidancePolicyInfo(policy))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ControlUpdateAvoidancePolicy replace all the fields of the policy except the policy name
+func ControlUpdateAvoidancePolicy(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.AvoidancePolicyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.PolicyName, req.Mode)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		mode, ok := db.AvoidanceModeValue[req.Mode]
+		if !ok {
+			ParamsValueJSONResp("avoidance mode not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		policy := new(db.AvoidancePolicy)
+		err = s.QueryObjectByCondition(policy, "policy_name", req.PolicyName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		before := newAvoidancePolicyInfo(policy)
+		setAvoidancePolicy(policy, &req, mode)
+
+		err = s.CheckAvoidancePolicyScope(policy)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.ReplaceObject(policy)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "修改规避策略："+req.PolicyName, before,
+			newAvoidancePolicyInfo(policy))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ControlDeleteAvoidancePolicy the instructions generated by the policy are kept
+func ControlDeleteAvoidancePolicy(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.DeleteAvoidancePolicyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.PolicyName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		policy := new(db.AvoidancePolicy)
+		err = s.QueryObjectByCondition(policy, "policy_name", req.PolicyName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.DeleteObject(policy)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "删除规避策略："+req.PolicyName,
+			newAvoidancePolicyInfo(policy), nil)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetAvoidancePolicyList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+		searchConditions := c.Query("searchConditions")
+		satelliteId := c.Query("satelliteId")
+		constellationId := c.Query("constellationId")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		queryMap := make(map[string]string)
+		if len(satelliteId) != 0 {
+			queryMap["satellite_id"] = satelliteId
+		}
+		if len(constellationId) != 0 {
+			queryMap["constellation_id"] = constellationId
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.AvoidancePolicy),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchInput: searchConditions,
+			SearchIndex: []string{"policy_name", "source"},
+			QueryMap:    queryMap,
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		resp := make([]*models.AvoidancePolicyInfo, 0)
+
+		for sqlRows.Next() {
+			var policy db.AvoidancePolicy
+			err := s.ScanRows(sqlRows, &policy)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, newAvoidancePolicyInfo(&policy))
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+func setAvoidancePolicy(policy *db.AvoidancePolicy, req *models.AvoidancePolicyReq,
+	mode db.AvoidanceMode) {
+
+	policy.SatelliteId = req.SatelliteId
+	policy.ConstellationId = req.ConstellationId
+	policy.Mode = mode
+	policy.Source = req.Source
+	if len(policy.Source) == 0 {
+		policy.Source = req.PolicyName
+	}
+	policy.ContentTemplate = req.ContentTemplate
+	policy.Enabled = req.Enabled
+}
+
+func newAvoidancePolicyInfo(policy *db.AvoidancePolicy) *models.AvoidancePolicyInfo {
+	return &models.AvoidancePolicyInfo{
+		PolicyName:      policy.PolicyName,
+		SatelliteId:     policy.SatelliteId,
+		ConstellationId: policy.ConstellationId,
+		Mode:            db.AvoidanceModeName[policy.Mode],
+		Source:          policy.Source,
+		ContentTemplate: policy.ContentTemplate,
+		Enabled:         policy.Enabled,
+		BaseRespInfo: models.BaseRespInfo{
+			Id:       policy.Id,
+			LastTime: policy.LastTime,
+		},
+	}
+}
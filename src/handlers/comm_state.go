@@ -11,19 +11,14 @@ import (
 
 func ExecAddCommState(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddCommStateReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName, req.LinkLoad,
-			req.OrbitId, req.CommState, req.CommPort, req.CommDelay, req.CommBandwidth)
+		err := isStringRequiredParamsEmpty(req.SatelliteId, string(req.LinkLoad),
+			req.CommState, req.CommPort, string(req.CommDelay), string(req.CommBandwidth))
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -35,21 +30,44 @@ func ExecAddCommState(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		satellite, ok := resolveSatellite(s, c, req.SatelliteId, req.OrbitId)
+		if !ok {
+			return
+		}
+
 		state, ok := db.StateValue[req.CommState]
 		if !ok {
 			ParamsValueJSONResp("comm state type not as expected", c)
 			return
 		}
 
+		commBandwidth, err := db.ParseQuantity(string(req.CommBandwidth), db.UnitMbps)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		commDelay, err := db.ParseQuantity(string(req.CommDelay), db.UnitMs)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		linkLoad, err := db.ParseQuantity(string(req.LinkLoad), db.UnitPercent)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
 		commState := &db.CommState{
-			SatelliteId:   req.SatelliteId,
-			SatelliteName: req.SatelliteName,
-			OrbitId:       req.OrbitId,
-			CommState:     state,
-			CommDelay:     req.CommDelay,
-			CommPort:      req.CommPort,
-			CommBandwidth: req.CommBandwidth,
-			LinkLoad:      req.LinkLoad,
+			SatelliteId:       req.SatelliteId,
+			SatelliteName:     satellite.SatelliteName,
+			OrbitId:           satellite.OrbitId,
+			CommState:         state,
+			CommPort:          req.CommPort,
+			CommBandwidthMbps: commBandwidth,
+			CommDelayMs:       commDelay,
+			LinkLoadPercent:   linkLoad,
 		}
 
 		err = s.InsertOneObjertToDB(commState)
@@ -65,11 +83,6 @@ func ExecAddCommState(s *services.Server) gin.HandlerFunc {
 func ExecGetCommStateList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -131,14 +144,14 @@ func ExecGetCommStateList(s *services.Server) gin.HandlerFunc {
 			}
 
 			resp = append(resp, &models.CommStateInfo{
-				SatelliteId:   commState.SatelliteId,
-				SatelliteName: commState.SatelliteName,
-				OrbitId:       commState.OrbitId,
-				CommState:     db.StateName[commState.CommState],
-				CommPort:      commState.CommPort,
-				CommDelay:     commState.CommDelay,
-				CommBandwidth: commState.CommBandwidth,
-				LinkLoad:      commState.LinkLoad,
+				SatelliteId:       commState.SatelliteId,
+				SatelliteName:     commState.SatelliteName,
+				OrbitId:           commState.OrbitId,
+				CommState:         db.StateName[commState.CommState],
+				CommPort:          commState.CommPort,
+				CommBandwidthMbps: commState.CommBandwidthMbps,
+				CommDelayMs:       commState.CommDelayMs,
+				LinkLoadPercent:   commState.LinkLoadPercent,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       commState.Id,
 					LastTime: commState.LastTime,
@@ -153,11 +166,6 @@ func ExecGetCommStateList(s *services.Server) gin.HandlerFunc {
 func TraceGetCommStateList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -220,14 +228,14 @@ func TraceGetCommStateList(s *services.Server) gin.HandlerFunc {
 			}
 
 			resp = append(resp, &models.CommStateInfo{
-				SatelliteId:   commState.SatelliteId,
-				SatelliteName: commState.SatelliteName,
-				OrbitId:       commState.OrbitId,
-				CommState:     db.StateName[commState.CommState],
-				CommPort:      commState.CommPort,
-				CommDelay:     commState.CommDelay,
-				CommBandwidth: commState.CommBandwidth,
-				LinkLoad:      commState.LinkLoad,
+				SatelliteId:       commState.SatelliteId,
+				SatelliteName:     commState.SatelliteName,
+				OrbitId:           commState.OrbitId,
+				CommState:         db.StateName[commState.CommState],
+				CommPort:          commState.CommPort,
+				CommBandwidthMbps: commState.CommBandwidthMbps,
+				CommDelayMs:       commState.CommDelayMs,
+				LinkLoadPercent:   commState.LinkLoadPercent,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       commState.Id,
 					LastTime: commState.LastTime,
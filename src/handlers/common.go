@@ -2,9 +2,7 @@ package handlers
 
 import (
 	"errors"
-	"go-web-demo/src/db"
 	"go-web-demo/src/models"
-	"go-web-demo/src/services"
 	"net/http"
 	"regexp"
 
@@ -136,6 +134,33 @@ func PwdErrorJSONResp(err string, c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+func LoginLockedJSONResp(err string, c *gin.Context) {
+	resp := models.StandardResp{
+		Code: models.RESP_CODE_LOGIN_LOCKED,
+		Msg:  models.RESP_MSG_LOGIN_LOCKED,
+		Data: err,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func UserNotEnabledJSONResp(err string, c *gin.Context) {
+	resp := models.StandardResp{
+		Code: models.RESP_CODE_USER_NOT_ENABLED,
+		Msg:  models.RESP_MSG_USER_NOT_ENABLED,
+		Data: err,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func TotpErrorJSONResp(err string, c *gin.Context) {
+	resp := models.StandardResp{
+		Code: models.RESP_CODE_TOTP_ERROR,
+		Msg:  models.RESP_MSG_TOTP_ERROR,
+		Data: err,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 func NotInChainJSONResp(err string, c *gin.Context) {
 	resp := models.StandardResp{
 		Code: models.RESP_CODE_NOT_IN_CHAIN,
@@ -193,30 +218,3 @@ func checkTheKeyRule(key string) error {
 	}
 	return nil
 }
-
-func checkTheAccessPermission(c *gin.Context, dbRole ...db.UserRoleType) error {
-
-	token, ok := c.Get("token")
-	if !ok {
-		return errors.New("get token from context failed")
-	}
-
-	tokenClaims, ok := token.(*services.MyClaims)
-	if !ok {
-		return errors.New("token type error")
-	}
-
-	roleType, ok := db.UserRoleTypeValue[tokenClaims.Role]
-
-	if !ok {
-		return errors.New("the user role not found")
-	}
-
-	for _, v := range dbRole {
-		if roleType == v {
-			return nil
-		}
-	}
-
-	return errors.New("access without permission")
-}
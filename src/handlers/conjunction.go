@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ControlGetConjunctionList the approaches found by the latest screening
+func ControlGetConjunctionList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+		searchConditions := c.Query("searchConditions")
+		satelliteId := c.Query("satelliteId")
+		treatenStr := c.Query("threaten")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		queryMap := make(map[string]string)
+		if len(satelliteId) != 0 {
+			queryMap["satellite_id"] = satelliteId
+		}
+		if len(treatenStr) != 0 {
+			treaten, ok := db.ThreatDegreeValue[treatenStr]
+			if !ok {
+				ParamsValueJSONResp("threat degree not as expected", c)
+				return
+			}
+			queryMap["treaten"] = strconv.Itoa(int(treaten))
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.Conjunction),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchInput: searchConditions,
+			SearchIndex: []string{"debris_id", "debris_name"},
+			QueryMap:    queryMap,
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		resp := make([]*models.ConjunctionInfo, 0)
+
+		for sqlRows.Next() {
+			var conjunction db.Conjunction
+			err := s.ScanRows(sqlRows, &conjunction)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, &models.ConjunctionInfo{
+				SatelliteId:    conjunction.SatelliteId,
+				SatelliteName:  conjunction.SatelliteName,
+				DebrisId:       conjunction.DebrisId,
+				DebrisName:     conjunction.DebrisName,
+				Tca:            conjunction.Tca,
+				MissDistanceKm: conjunction.MissDistanceKm,
+				Treaten:        db.ThreatDegreeName[conjunction.Treaten],
+				ScreenedAt:     conjunction.ScreenedAt,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       conjunction.Id,
+					LastTime: conjunction.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
@@ -11,19 +11,13 @@ import (
 
 func ControlAddConstellation(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddConstellationReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		err := isStringRequiredParamsEmpty(req.ConstellationId, req.ConstellationName,
-			req.SatelliteLinkState)
+		err := isStringRequiredParamsEmpty(req.ConstellationId, req.ConstellationName)
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -35,19 +29,22 @@ func ControlAddConstellation(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
-		satelliteLinkState, ok := db.StateValue[req.SatelliteLinkState]
-		if !ok {
-			ParamsValueJSONResp("satellite link state type not as expected", c)
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.Constellation), "constellation_id", req.ConstellationId)
+		if err == nil {
+			UniqueIndexJSONResp("星座已存在", c)
 			return
 		}
 
 		constellation := &db.Constellation{
-			ConstellationId:    req.ConstellationId,
-			ConstellationName:  req.ConstellationName,
-			SatelliteLinkState: satelliteLinkState,
-			SatelliteTotalNum:  req.SatelliteTotalNum,
-			SatelliteUpNum:     req.SatelliteUpNum,
-			SatelliteDownNum:   req.SatelliteDownNum,
+			ConstellationId:   req.ConstellationId,
+			ConstellationName: req.ConstellationName,
 		}
 
 		err = s.InsertOneObjertToDB(constellation)
@@ -56,18 +53,154 @@ func ControlAddConstellation(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		err = recordChange(s, c, claims.Name, "添加星座："+req.ConstellationId,
+			nil, newConstellationInfo(constellation, nil))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
 		SuccessfulJSONResp("", c)
 	}
 }
 
-func ControlGetConstellationList(s *services.Server) gin.HandlerFunc {
+func ControlGetConstellation(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		constellationId := c.Query("constellationId")
+
+		err := isStringRequiredParamsEmpty(constellationId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		constellation := new(db.Constellation)
+		err = s.QueryObjectByCondition(constellation, "constellation_id", constellationId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		healths, err := s.GetConstellationHealth([]string{constellationId})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(newConstellationInfo(constellation, healths[constellationId]), c)
+	}
+}
+
+// ControlUpdateConstellation replace all the fields of the constellation except the constellation id
+func ControlUpdateConstellation(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AddConstellationReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.ConstellationId, req.ConstellationName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		constellation := new(db.Constellation)
+		err = s.QueryObjectByCondition(constellation, "constellation_id", req.ConstellationId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		before := newConstellationInfo(constellation, nil)
+		constellation.ConstellationName = req.ConstellationName
+
+		err = s.ReplaceObject(constellation)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "修改星座："+req.ConstellationId,
+			before, newConstellationInfo(constellation, nil))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ControlDeleteConstellation the constellation is retired by soft delete, the id can be added again
+func ControlDeleteConstellation(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var req models.DeleteConstellationReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.ConstellationId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		constellation := new(db.Constellation)
+		err = s.QueryObjectByCondition(constellation, "constellation_id", req.ConstellationId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		count, err := s.CountCatalogSatellites("constellation_id", req.ConstellationId)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
 
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
+		if count != 0 {
+			ParamsValueJSONResp("the constellation is used by the satellites in the catalog", c)
+			return
+		}
+
+		err = s.DeleteObject(constellation)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "删除星座："+req.ConstellationId,
+			newConstellationInfo(constellation, nil), nil)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
 
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetConstellationList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -118,7 +251,8 @@ func ControlGetConstellationList(s *services.Server) gin.HandlerFunc {
 
 		defer sqlRows.Close()
 
-		resp := make([]*models.ConstellationInfo, 0)
+		constellations := make([]*db.Constellation, 0)
+		constellationIds := make([]string, 0)
 
 		for sqlRows.Next() {
 			var constellation db.Constellation
@@ -128,18 +262,20 @@ func ControlGetConstellationList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.ConstellationInfo{
-				ConstellationId:    constellation.ConstellationId,
-				ConstellationName:  constellation.ConstellationName,
-				SatelliteLinkState: db.StateName[constellation.SatelliteLinkState],
-				SatelliteTotalNum:  constellation.SatelliteTotalNum,
-				SatelliteUpNum:     constellation.SatelliteUpNum,
-				SatelliteDownNum:   constellation.SatelliteDownNum,
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       constellation.Id,
-					LastTime: constellation.LastTime,
-				},
-			})
+			constellations = append(constellations, &constellation)
+			constellationIds = append(constellationIds, constellation.ConstellationId)
+		}
+
+		healths, err := s.GetConstellationHealth(constellationIds)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.ConstellationInfo, 0, len(constellations))
+		for _, constellation := range constellations {
+			resp = append(resp, newConstellationInfo(constellation,
+				healths[constellation.ConstellationId]))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
@@ -149,11 +285,6 @@ func ControlGetConstellationList(s *services.Server) gin.HandlerFunc {
 func TraceGetConstellationList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -190,6 +321,7 @@ func TraceGetConstellationList(s *services.Server) gin.HandlerFunc {
 			SearchInput: searchInput,
 			SearchIndex: make([]string, 0),
 			GroupIndex:  "constellation_id",
+			Unscoped:    true,
 		}
 
 		if len(searchInput) != 0 {
@@ -205,7 +337,8 @@ func TraceGetConstellationList(s *services.Server) gin.HandlerFunc {
 
 		defer sqlRows.Close()
 
-		resp := make([]*models.ConstellationInfo, 0)
+		constellations := make([]*db.Constellation, 0)
+		constellationIds := make([]string, 0)
 
 		for sqlRows.Next() {
 			var constellation db.Constellation
@@ -215,20 +348,96 @@ func TraceGetConstellationList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.ConstellationInfo{
-				ConstellationId:    constellation.ConstellationId,
-				ConstellationName:  constellation.ConstellationName,
-				SatelliteLinkState: db.StateName[constellation.SatelliteLinkState],
-				SatelliteTotalNum:  constellation.SatelliteTotalNum,
-				SatelliteUpNum:     constellation.SatelliteUpNum,
-				SatelliteDownNum:   constellation.SatelliteDownNum,
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       constellation.Id,
-					LastTime: constellation.LastTime,
-				},
-			})
+			constellations = append(constellations, &constellation)
+			constellationIds = append(constellationIds, constellation.ConstellationId)
+		}
+
+		healths, err := s.GetConstellationHealth(constellationIds)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.ConstellationInfo, 0, len(constellations))
+		for _, constellation := range constellations {
+			resp = append(resp, newConstellationInfo(constellation,
+				healths[constellation.ConstellationId]))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
 	}
 }
+
+// GetConstellationMembers the members of the constellation in the catalog and their latest states,
+// the drill-down of the constellation list and the monitor
+func GetConstellationMembers(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		constellationId := c.Query("constellationId")
+
+		err := isStringRequiredParamsEmpty(constellationId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.Constellation), "constellation_id", constellationId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		members, err := s.GetConstellationMembers([]string{constellationId})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.ConstellationMemberInfo, 0, len(members[constellationId]))
+		for _, member := range members[constellationId] {
+			info := &models.ConstellationMemberInfo{
+				SatelliteId:   member.Satellite.SatelliteId,
+				SatelliteName: member.Satellite.SatelliteName,
+				OrbitId:       member.Satellite.OrbitId,
+				Status:        db.SatelliteStatusName[member.Satellite.Status],
+			}
+
+			if member.RunState != nil {
+				info.RunState = db.StateName[member.RunState.RunState]
+				info.RunStateTime = member.RunState.LastTime
+			}
+
+			if member.NetState != nil {
+				info.NetworkState = db.StateName[member.NetState.NetworkState]
+				info.NetStateTime = member.NetState.LastTime
+			}
+
+			resp = append(resp, info)
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+// newConstellationInfo the health is derived from the members, it is left empty if nil
+func newConstellationInfo(constellation *db.Constellation,
+	health *services.ConstellationHealth) *models.ConstellationInfo {
+
+	info := &models.ConstellationInfo{
+		ConstellationId:   constellation.ConstellationId,
+		ConstellationName: constellation.ConstellationName,
+		BaseRespInfo: models.BaseRespInfo{
+			Id:       constellation.Id,
+			LastTime: constellation.LastTime,
+		},
+	}
+
+	if health != nil {
+		info.SatelliteTotalNum = health.SatelliteTotalNum
+		info.SatelliteUpNum = health.SatelliteUpNum
+		info.SatelliteDownNum = health.SatelliteDownNum
+		info.SatelliteLinkState = db.StateName[health.SatelliteLinkState]
+	}
+
+	return info
+}
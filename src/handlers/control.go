@@ -11,19 +11,14 @@ import (
 
 func ExecAddControl(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddControlsReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName,
-			req.SatelliteAttitude, req.SatellitePower)
+		err := isStringRequiredParamsEmpty(req.SatelliteId,
+			req.SatelliteAttitude, string(req.SatellitePower))
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -35,12 +30,23 @@ func ExecAddControl(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		satellite, ok := resolveSatellite(s, c, req.SatelliteId, "")
+		if !ok {
+			return
+		}
+
+		satellitePower, err := db.ParseQuantity(string(req.SatellitePower), db.UnitWatt)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
 		control := &db.Control{
 			SatelliteId:          req.SatelliteId,
-			SatelliteName:        req.SatelliteName,
+			SatelliteName:        satellite.SatelliteName,
 			SatelliteAttitude:    req.SatelliteAttitude,
 			SatelliteTemperature: req.SatelliteTemperature,
-			SatellitePower:       req.SatellitePower,
+			SatellitePowerW:      satellitePower,
 		}
 
 		err = s.InsertOneObjertToDB(control)
@@ -56,11 +62,6 @@ func ExecAddControl(s *services.Server) gin.HandlerFunc {
 func ExecGetControlList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -126,7 +127,7 @@ func ExecGetControlList(s *services.Server) gin.HandlerFunc {
 				SatelliteName:        control.SatelliteName,
 				SatelliteAttitude:    control.SatelliteAttitude,
 				SatelliteTemperature: control.SatelliteTemperature,
-				SatellitePower:       control.SatellitePower,
+				SatellitePowerW:      control.SatellitePowerW,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       control.Id,
 					LastTime: control.LastTime,
@@ -141,11 +142,6 @@ func ExecGetControlList(s *services.Server) gin.HandlerFunc {
 func TraceGetControlList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -212,7 +208,7 @@ func TraceGetControlList(s *services.Server) gin.HandlerFunc {
 				SatelliteName:        control.SatelliteName,
 				SatelliteAttitude:    control.SatelliteAttitude,
 				SatelliteTemperature: control.SatelliteTemperature,
-				SatellitePower:       control.SatellitePower,
+				SatellitePowerW:      control.SatellitePowerW,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       control.Id,
 					LastTime: control.LastTime,
@@ -12,11 +12,6 @@ import (
 func ControlAddDebris(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddDebirsReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
@@ -42,6 +37,19 @@ func ControlAddDebris(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.Debris), "debris_id", req.DebrisId)
+		if err == nil {
+			UniqueIndexJSONResp("碎片已存在", c)
+			return
+		}
+
 		debirs := &db.Debris{
 			DebrisId:     req.DebrisId,
 			DebrisName:   req.DebrisName,
@@ -49,7 +57,7 @@ func ControlAddDebris(s *services.Server) gin.HandlerFunc {
 			Angle:        req.Angle,
 			Speed:        req.Speed,
 			Height:       req.Height,
-			Volunme:      req.Volume,
+			Volume:       req.Volume,
 			Type:         debrisType,
 		}
 
@@ -59,18 +67,155 @@ func ControlAddDebris(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		err = recordChange(s, c, claims.Name, "添加碎片："+req.DebrisId, nil, newDebrisInfo(debirs))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		s.TriggerScreening()
+
 		SuccessfulJSONResp("", c)
 	}
 }
 
-func ControlGetDebrisList(s *services.Server) gin.HandlerFunc {
+func ControlGetDebris(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
+		debrisId := c.Query("debrisId")
+
+		err := isStringRequiredParamsEmpty(debrisId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		debris := new(db.Debris)
+		err = s.QueryObjectByCondition(debris, "debris_id", debrisId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
 			return
 		}
 
+		SuccessfulJSONResp(newDebrisInfo(debris), c)
+	}
+}
+
+// ControlUpdateDebris replace all the fields of the debris except the debris id
+func ControlUpdateDebris(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.AddDebirsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.DebrisId,
+			req.DebrisName, req.Type)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		debrisType, ok := db.DebrisTypeValue[req.Type]
+		if !ok {
+			ParamsValueJSONResp("debirs type not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		debris := new(db.Debris)
+		err = s.QueryObjectByCondition(debris, "debris_id", req.DebrisId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		before := newDebrisInfo(debris)
+		debris.DebrisName = req.DebrisName
+		debris.DebrisSource = req.DebrisSource
+		debris.Angle = req.Angle
+		debris.Speed = req.Speed
+		debris.Height = req.Height
+		debris.Volume = req.Volume
+		debris.Type = debrisType
+
+		err = s.ReplaceObject(debris)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "修改碎片："+req.DebrisId, before, newDebrisInfo(debris))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		s.TriggerScreening()
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ControlDeleteDebris the debris is retired by soft delete, the id can be added again
+func ControlDeleteDebris(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.DeleteDebrisReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.DebrisId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		debris := new(db.Debris)
+		err = s.QueryObjectByCondition(debris, "debris_id", req.DebrisId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.DeleteObject(debris)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "删除碎片："+req.DebrisId, newDebrisInfo(debris), nil)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		s.TriggerScreening()
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetDebrisList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -131,20 +276,7 @@ func ControlGetDebrisList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.DebrisInfo{
-				DebrisId:     debris.DebrisId,
-				DebrisName:   debris.DebrisName,
-				DebrisSource: debris.DebrisSource,
-				Angle:        debris.Angle,
-				Speed:        debris.Speed,
-				Height:       debris.Height,
-				Volume:       debris.Volunme,
-				Type:         db.DebrisTypeName[debris.Type],
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       debris.Id,
-					LastTime: debris.LastTime,
-				},
-			})
+			resp = append(resp, newDebrisInfo(&debris))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
@@ -154,11 +286,6 @@ func ControlGetDebrisList(s *services.Server) gin.HandlerFunc {
 func TraceGetDebrisList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -195,6 +322,7 @@ func TraceGetDebrisList(s *services.Server) gin.HandlerFunc {
 			SearchInput: searchInput,
 			SearchIndex: make([]string, 0),
 			GroupIndex:  "debris_id",
+			Unscoped:    true,
 		}
 
 		if len(searchInput) != 0 {
@@ -220,22 +348,26 @@ func TraceGetDebrisList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.DebrisInfo{
-				DebrisId:     debris.DebrisId,
-				DebrisName:   debris.DebrisName,
-				DebrisSource: debris.DebrisSource,
-				Angle:        debris.Angle,
-				Speed:        debris.Speed,
-				Height:       debris.Height,
-				Volume:       debris.Volunme,
-				Type:         db.DebrisTypeName[debris.Type],
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       debris.Id,
-					LastTime: debris.LastTime,
-				},
-			})
+			resp = append(resp, newDebrisInfo(&debris))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
 	}
 }
+
+func newDebrisInfo(debris *db.Debris) *models.DebrisInfo {
+	return &models.DebrisInfo{
+		DebrisId:     debris.DebrisId,
+		DebrisName:   debris.DebrisName,
+		DebrisSource: debris.DebrisSource,
+		Angle:        debris.Angle,
+		Speed:        debris.Speed,
+		Height:       debris.Height,
+		Volume:       debris.Volume,
+		Type:         db.DebrisTypeName[debris.Type],
+		BaseRespInfo: models.BaseRespInfo{
+			Id:       debris.Id,
+			LastTime: debris.LastTime,
+		},
+	}
+}
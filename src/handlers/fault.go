@@ -12,19 +12,14 @@ import (
 
 func ExecAddFault(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddFaultReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName,
-			req.OrbitId, req.FaultType, req.FaultDescription, req.RepairState)
+		err := isStringRequiredParamsEmpty(req.SatelliteId,
+			req.FaultType, req.FaultDescription)
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -36,9 +31,8 @@ func ExecAddFault(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
-		repairState, ok := db.StateValue[req.RepairState]
+		satellite, ok := resolveSatellite(s, c, req.SatelliteId, req.OrbitId)
 		if !ok {
-			ParamsValueJSONResp("repair state type not as expected", c)
 			return
 		}
 
@@ -51,12 +45,13 @@ func ExecAddFault(s *services.Server) gin.HandlerFunc {
 		faultTime := time.Now().Unix()
 		fault := &db.Fault{
 			SatelliteId:      req.SatelliteId,
-			SatelliteName:    req.SatelliteName,
-			OrbitId:          req.OrbitId,
+			SatelliteName:    satellite.SatelliteName,
+			OrbitId:          satellite.OrbitId,
 			FaultType:        faultType,
-			FaultDescription: req.FaultDescription,
+			FaultDescription: db.LongText(req.FaultDescription),
 			FaultTime:        faultTime,
-			RepairState:      repairState,
+			RepairState:      db.WRONG,
+			FaultState:       db.FAULT_OPEN,
 		}
 
 		err = s.InsertOneObjertToDB(fault)
@@ -72,15 +67,12 @@ func ExecAddFault(s *services.Server) gin.HandlerFunc {
 func ExecGetFaultList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.EXEC, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
 		searchInput := c.Query("searchConditions")
+		faultStateStr := c.Query("faultState")
+		assignee := c.Query("assignee")
 
 		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
 		if err != nil {
@@ -105,6 +97,19 @@ func ExecGetFaultList(s *services.Server) gin.HandlerFunc {
 			sortType = services.SORTTYPE_TIME
 		}
 
+		queryMap := make(map[string]string)
+		if len(faultStateStr) != 0 {
+			faultState, ok := db.FaultStateValue[faultStateStr]
+			if !ok {
+				ParamsValueJSONResp("fault state not as expected", c)
+				return
+			}
+			queryMap["fault_state"] = strconv.Itoa(int(faultState))
+		}
+		if len(assignee) != 0 {
+			queryMap["assignee"] = assignee
+		}
+
 		params := &services.QueryObjectsParams{
 			ModelStruct: new(db.Fault),
 			Page:        int32(page),
@@ -112,6 +117,7 @@ func ExecGetFaultList(s *services.Server) gin.HandlerFunc {
 			SortType:    sortType,
 			SearchInput: searchInput,
 			SearchIndex: make([]string, 0),
+			QueryMap:    queryMap,
 		}
 
 		if len(searchInput) != 0 {
@@ -137,19 +143,7 @@ func ExecGetFaultList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.FaultInfo{
-				SatelliteId:      fault.SatelliteId,
-				SatelliteName:    fault.SatelliteName,
-				OrbitId:          fault.OrbitId,
-				FaultType:        db.FaultTypeName[fault.FaultType],
-				FaultDescription: fault.FaultDescription,
-				FaultTime:        fault.FaultTime,
-				RepairState:      db.StateName[fault.RepairState],
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       fault.Id,
-					LastTime: fault.LastTime,
-				},
-			})
+			resp = append(resp, newFaultInfo(&fault))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
@@ -159,15 +153,11 @@ func ExecGetFaultList(s *services.Server) gin.HandlerFunc {
 func TraceGetFaultList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
 		searchInput := c.Query("searchConditions")
+		faultStateStr := c.Query("faultState")
 
 		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
 		if err != nil {
@@ -192,6 +182,16 @@ func TraceGetFaultList(s *services.Server) gin.HandlerFunc {
 			sortType = services.SORTTYPE_TIME
 		}
 
+		queryMap := make(map[string]string)
+		if len(faultStateStr) != 0 {
+			faultState, ok := db.FaultStateValue[faultStateStr]
+			if !ok {
+				ParamsValueJSONResp("fault state not as expected", c)
+				return
+			}
+			queryMap["fault_state"] = strconv.Itoa(int(faultState))
+		}
+
 		params := &services.QueryLatestObjectsParams{
 			ModelStruct: new(db.Fault),
 			Page:        int32(page),
@@ -200,6 +200,7 @@ func TraceGetFaultList(s *services.Server) gin.HandlerFunc {
 			SearchInput: searchInput,
 			SearchIndex: make([]string, 0),
 			GroupIndex:  "satellite_id",
+			QueryMap:    queryMap,
 		}
 
 		if len(searchInput) != 0 {
@@ -225,21 +226,126 @@ func TraceGetFaultList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.FaultInfo{
-				SatelliteId:      fault.SatelliteId,
-				SatelliteName:    fault.SatelliteName,
-				OrbitId:          fault.OrbitId,
-				FaultType:        db.FaultTypeName[fault.FaultType],
-				FaultDescription: fault.FaultDescription,
-				FaultTime:        fault.FaultTime,
-				RepairState:      db.StateName[fault.RepairState],
+			resp = append(resp, newFaultInfo(&fault))
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+// ExecTransitFault move the fault forward, such as acknowledging, assigning and repairing
+func ExecTransitFault(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.TransitFaultReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.FaultState)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		faultState, ok := db.FaultStateValue[req.FaultState]
+		if !ok {
+			ParamsValueJSONResp("fault state not as expected", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		before := new(db.Fault)
+		err = s.QueryObjectById(before, req.FaultId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		fault, err := s.TransitFault(req.FaultId, faultState, claims.Name, req.Assignee, req.Note)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "故障状态变更："+strconv.Itoa(int(req.FaultId)),
+			newFaultInfo(before), newFaultInfo(fault))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(newFaultInfo(fault), c)
+	}
+}
+
+func ExecGetFaultTransitions(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		faultIdStr := c.Query("faultId")
+
+		err := isStringRequiredParamsEmpty(faultIdStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		faultId, err := strconv.Atoi(faultIdStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		transitions, err := s.ListFaultTransitions(int32(faultId))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.FaultTransitionInfo, 0, len(transitions))
+		for _, transition := range transitions {
+			resp = append(resp, &models.FaultTransitionInfo{
+				FaultId:        transition.FaultId,
+				FromState:      db.FaultStateName[transition.FromState],
+				ToState:        db.FaultStateName[transition.ToState],
+				Operator:       transition.Operator,
+				Assignee:       transition.Assignee,
+				Note:           transition.Note,
+				TransitionTime: transition.TransitionTime,
 				BaseRespInfo: models.BaseRespInfo{
-					Id:       fault.Id,
-					LastTime: fault.LastTime,
+					Id:       transition.Id,
+					LastTime: transition.LastTime,
 				},
 			})
 		}
 
-		SuccessfulJSONRespWithPage(resp, total, c)
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+func newFaultInfo(fault *db.Fault) *models.FaultInfo {
+	return &models.FaultInfo{
+		SatelliteId:      fault.SatelliteId,
+		SatelliteName:    fault.SatelliteName,
+		OrbitId:          fault.OrbitId,
+		FaultType:        db.FaultTypeName[fault.FaultType],
+		FaultDescription: string(fault.FaultDescription),
+		FaultTime:        fault.FaultTime,
+		RepairState:      db.StateName[fault.RepairState],
+		FaultState:       db.FaultStateName[fault.FaultState],
+		Assignee:         fault.Assignee,
+		AcknowledgedAt:   fault.AcknowledgedAt,
+		ResolvedAt:       fault.ResolvedAt,
+		ClosedAt:         fault.ClosedAt,
+		BaseRespInfo: models.BaseRespInfo{
+			Id:       fault.Id,
+			LastTime: fault.LastTime,
+		},
 	}
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"go-web-demo/src/db"
 	"go-web-demo/src/models"
 	"go-web-demo/src/services"
@@ -12,11 +13,6 @@ import (
 
 func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddInstructionReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
@@ -24,8 +20,7 @@ func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 		}
 
 		err := isStringRequiredParamsEmpty(req.InstructionId,
-			req.InstructionContent, req.DebrisId, req.DebrisName,
-			req.SatelliteId, req.SatelliteName)
+			req.InstructionContent, req.DebrisId, req.SatelliteId)
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -37,6 +32,34 @@ func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		if req.TimeoutSeconds < 0 {
+			ParamsValueJSONResp("the timeout can not be negative", c)
+			return
+		}
+
+		if req.ExecTime < 0 || req.ExecWindowEnd < 0 {
+			ParamsValueJSONResp("the execution time can not be negative", c)
+			return
+		}
+
+		if req.ExecWindowEnd != 0 && (req.ExecWindowEnd <= time.Now().Unix() ||
+			req.ExecWindowEnd < req.ExecTime) {
+			ParamsValueJSONResp("the execution window has ended or ends before it starts", c)
+			return
+		}
+
+		satellite, ok := resolveSatellite(s, c, req.SatelliteId, "")
+		if !ok {
+			return
+		}
+
+		debris := new(db.Debris)
+		err = s.QueryObjectByCondition(debris, "debris_id", req.DebrisId)
+		if err != nil {
+			NotExistJSONResp("the debris does not exist: "+req.DebrisId, c)
+			return
+		}
+
 		token, ok1 := c.Get("token")
 		claims, ok2 := token.(*services.MyClaims)
 		if !ok1 || !ok2 {
@@ -44,21 +67,29 @@ func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		err = s.QueryObjectByCondition(new(db.Instruction), "instruction_id", req.InstructionId)
+		if err == nil {
+			UniqueIndexJSONResp("指令已存在", c)
+			return
+		}
+
 		genInstructionTime := time.Now().Unix()
-		noExecI := &db.Instruction{
+		instruction := &db.Instruction{
 			InstructionId:      req.InstructionId,
 			InstructionSource:  claims.Name,
 			Type:               db.OPERATION,
-			ExecState:          db.NOTEXEC,
 			InstructionContent: req.InstructionContent,
 			DebrisId:           req.DebrisId,
-			DebrisName:         req.DebrisName,
+			DebrisName:         debris.DebrisName,
 			SatelliteId:        req.SatelliteId,
-			SatelliteName:      req.SatelliteName,
+			SatelliteName:      satellite.SatelliteName,
+			Treaten:            s.ConjunctionThreat(req.SatelliteId, req.DebrisId),
 			GenInstructionTime: genInstructionTime,
+			ScheduledTime:      req.ExecTime,
+			ScheduleWindowEnd:  req.ExecWindowEnd,
 		}
-		// 未执行指令信息入库
-		err = s.InsertOneObjertToDB(noExecI)
+		// 指令入库并进入执行队列，由执行器异步执行
+		err = s.CreateInstruction(instruction, claims.Name, time.Duration(req.TimeoutSeconds)*time.Second)
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
@@ -69,8 +100,8 @@ func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 			OperatorIp:      c.ClientIP(),
 			OperationTime:   genInstructionTime,
 			SatelliteId:     req.SatelliteId,
-			SatelliteName:   req.SatelliteName,
-			OperationRecord: "编辑指令：" + req.InstructionId,
+			SatelliteName:   satellite.SatelliteName,
+			OperationRecord: db.LongText("编辑指令：" + req.InstructionId),
 		}
 
 		// 编辑操作入库
@@ -80,63 +111,53 @@ func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
-		execInstructionTime := time.Now().Unix()
-		inExecI := &db.Instruction{
-			InstructionId:       req.InstructionId,
-			InstructionSource:   claims.Name,
-			Type:                db.OPERATION,
-			ExecState:           db.INEXEC,
-			InstructionContent:  req.InstructionContent,
-			DebrisId:            req.DebrisId,
-			DebrisName:          req.DebrisName,
-			SatelliteId:         req.SatelliteId,
-			SatelliteName:       req.SatelliteName,
-			GenInstructionTime:  genInstructionTime,
-			ExecInstructionTime: execInstructionTime,
+		SuccessfulJSONResp(&models.NewInstruction{InstructionId: req.InstructionId}, c)
+	}
+}
+
+// ControlReviewInstruction another user approves or rejects the instruction
+func ControlReviewInstruction(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ReviewInstructionReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
 		}
-		// 执行指令入库
-		err = s.InsertOneObjertToDB(inExecI)
+
+		err := isStringRequiredParamsEmpty(req.InstructionId)
 		if err != nil {
-			ServerErrorJSONResp(err.Error(), c)
+			ParamsMissingJSONResp(err.Error(), c)
 			return
 		}
 
-		//假设执行中-------------------------------
-		time.Sleep(time.Millisecond * 500)
-		//-----------------------------------------
+		if !req.Approve && len(req.Reason) == 0 {
+			ParamsMissingJSONResp("the reason of the rejection is required", c)
+			return
+		}
 
-		endExecI := &db.Instruction{
-			InstructionId:       req.InstructionId,
-			InstructionSource:   claims.Name,
-			Type:                db.OPERATION,
-			ExecState:           db.EXECSUCCESS,
-			InstructionContent:  req.InstructionContent,
-			DebrisId:            req.DebrisId,
-			DebrisName:          req.DebrisName,
-			SatelliteId:         req.SatelliteId,
-			SatelliteName:       req.SatelliteName,
-			GenInstructionTime:  genInstructionTime,
-			ExecInstructionTime: execInstructionTime,
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
 		}
 
-		// 执行结果入库
-		err = s.InsertOneObjertToDB(endExecI)
+		_, err = s.ReviewInstruction(req.InstructionId, claims.Name, req.Approve, req.Reason)
 		if err != nil {
-			ServerErrorJSONResp(err.Error(), c)
+			ParamsValueJSONResp(err.Error(), c)
 			return
 		}
 
-		operation = &db.Operation{
-			Operator:        claims.Name,
-			OperationTime:   execInstructionTime,
-			OperatorIp:      c.ClientIP(),
-			SatelliteId:     req.SatelliteId,
-			SatelliteName:   req.SatelliteName,
-			OperationRecord: "执行指令：" + req.InstructionId,
+		record := "审批通过指令：" + req.InstructionId
+		if !req.Approve {
+			record = "驳回指令：" + req.InstructionId
+		}
+		if len(req.Reason) != 0 {
+			record += "，" + req.Reason
 		}
 
-		// 执行操作入库
-		err = s.InsertOneObjertToDB(operation)
+		err = recordOperation(s, c, claims.Name, record)
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
@@ -146,18 +167,53 @@ func ControlAddInstruction(s *services.Server) gin.HandlerFunc {
 	}
 }
 
-func ControlGetInstructionList(s *services.Server) gin.HandlerFunc {
+// ControlCancelInstruction cancel the instruction before it is executed
+func ControlCancelInstruction(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
+		var req models.CancelInstructionReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.InstructionId, req.Reason)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		_, err = s.CancelInstruction(req.InstructionId, claims.Name, req.Reason)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
 			return
 		}
 
+		err = recordOperation(s, c, claims.Name, "取消指令："+req.InstructionId+"，"+req.Reason)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetInstructionList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
 		searchInput := c.Query("searchConditions")
+		execStateStr := c.Query("execState")
 
 		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
 		if err != nil {
@@ -196,7 +252,14 @@ func ControlGetInstructionList(s *services.Server) gin.HandlerFunc {
 			params.SearchIndex = append(params.SearchIndex, "instruction_id")
 		}
 
-		params.QueryMap["exec_state"] = strconv.Itoa(int(db.NOTEXEC))
+		if len(execStateStr) != 0 {
+			execState, ok := db.ExecStateValue[execStateStr]
+			if !ok {
+				ParamsValueJSONResp("exec state not as expected", c)
+				return
+			}
+			params.QueryMap["exec_state"] = strconv.Itoa(int(execState))
+		}
 
 		sqlRows, total, err := s.QueryObjectsWithPage(params)
 		if err != nil {
@@ -206,7 +269,7 @@ func ControlGetInstructionList(s *services.Server) gin.HandlerFunc {
 
 		defer sqlRows.Close()
 
-		resp := make([]*models.InstructionInfo, 0)
+		resp := make([]*models.InstructionDetails, 0)
 
 		for sqlRows.Next() {
 			var instruction db.Instruction
@@ -216,22 +279,7 @@ func ControlGetInstructionList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.InstructionInfo{
-				InstructionId:       instruction.InstructionId,
-				InstructionSource:   instruction.InstructionSource,
-				InstructionContent:  instruction.InstructionContent,
-				InstructionType:     db.InstructionTypeName[instruction.Type],
-				ExecInstructionTime: instruction.ExecInstructionTime,
-				GenInstructionTime:  instruction.GenInstructionTime,
-				DebrisId:            instruction.DebrisId,
-				DebrisName:          instruction.DebrisName,
-				SatelliteId:         instruction.SatelliteId,
-				SatelliteName:       instruction.SatelliteName,
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       instruction.Id,
-					LastTime: instruction.LastTime,
-				},
-			})
+			resp = append(resp, newInstructionDetails(&instruction))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
@@ -241,11 +289,6 @@ func ControlGetInstructionList(s *services.Server) gin.HandlerFunc {
 func ExecGetExecResultList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -305,39 +348,208 @@ func ExecGetExecResultList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.InstructionDetails{
-				InstructionInfo: models.InstructionInfo{
-					InstructionId:       instruction.InstructionId,
-					InstructionSource:   instruction.InstructionSource,
-					InstructionContent:  instruction.InstructionContent,
-					InstructionType:     db.InstructionTypeName[instruction.Type],
-					ExecInstructionTime: instruction.ExecInstructionTime,
-					GenInstructionTime:  instruction.GenInstructionTime,
-					DebrisId:            instruction.DebrisId,
-					DebrisName:          instruction.DebrisName,
-					SatelliteId:         instruction.SatelliteId,
-					SatelliteName:       instruction.SatelliteName,
-					BaseRespInfo: models.BaseRespInfo{
-						Id:       instruction.Id,
-						LastTime: instruction.LastTime,
-					},
-				},
-				ExecState: db.ExecStateName[instruction.ExecState],
-			})
+			resp = append(resp, newInstructionDetails(&instruction))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
 	}
 }
 
-func TraceGetInstructionList(s *services.Server) gin.HandlerFunc {
+// ExecClaimInstruction the exec system takes the earliest queued instruction,
+// the data is null when there is none
+func ExecClaimInstruction(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
+		if !s.RemoteExecution() {
+			ParamsValueJSONResp("the instructions are executed by the server", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
 			return
 		}
 
+		task, instruction, err := s.ClaimInstructionTask(claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if task == nil {
+			SuccessfulJSONResp(nil, c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.ClaimedInstruction{
+			InstructionDetails: *newInstructionDetails(instruction),
+			Attempt:            task.Attempts,
+			Deadline:           task.LeaseExpiresAt,
+		}, c)
+	}
+}
+
+func ExecReportInstructionProgress(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ReportInstructionProgressReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.InstructionId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		if req.Progress < 0 || req.Progress > 100 {
+			ParamsValueJSONResp("the progress must be between 0 and 100", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.ReportInstructionProgress(req.InstructionId, claims.Name, req.Progress, req.Message)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ExecReportInstructionResult the final result of the claimed instruction,
+// the failed instruction is queued again until the max attempts
+func ExecReportInstructionResult(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ReportInstructionResultReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.InstructionId, req.ExecState)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		execState, ok := db.ExecStateValue[req.ExecState]
+		if !ok || (execState != db.EXECSUCCESS && execState != db.EXECFAIL) {
+			ParamsValueJSONResp("exec state not as expected", c)
+			return
+		}
+
+		if execState == db.EXECFAIL && len(req.ErrorCode) == 0 {
+			ParamsMissingJSONResp("the error code of the failure is required", c)
+			return
+		}
+
+		if req.StartedAt < 0 || req.FinishedAt < 0 ||
+			(req.StartedAt != 0 && req.FinishedAt != 0 && req.FinishedAt < req.StartedAt) {
+			ParamsValueJSONResp("the finished time can not be before the started time", c)
+			return
+		}
+
+		telemetry := ""
+		if len(req.Telemetry) != 0 && string(req.Telemetry) != "null" {
+			var snapshot map[string]interface{}
+			if err := json.Unmarshal(req.Telemetry, &snapshot); err != nil {
+				ParamsTypeErrorJSONResp("the telemetry must be a json object", c)
+				return
+			}
+			telemetry = string(req.Telemetry)
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.ReportInstructionResult(req.InstructionId, claims.Name, &db.InstructionResult{
+			ExecState:    execState,
+			ErrorCode:    req.ErrorCode,
+			ErrorMessage: req.ErrorMessage,
+			Telemetry:    telemetry,
+			StartedAt:    req.StartedAt,
+			FinishedAt:   req.FinishedAt,
+		})
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "上报指令结果："+req.InstructionId+"，"+req.ExecState)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ExecGetInstructionResults(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		instructionId := c.Query("instructionId")
+
+		err := isStringRequiredParamsEmpty(instructionId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		results, err := s.ListInstructionResults(instructionId)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.InstructionResultInfo, 0, len(results))
+		for _, result := range results {
+			var telemetry json.RawMessage
+			if len(result.Telemetry) != 0 {
+				telemetry = json.RawMessage(result.Telemetry)
+			}
+
+			resp = append(resp, &models.InstructionResultInfo{
+				InstructionId: result.InstructionId,
+				Attempt:       result.Attempt,
+				ExecState:     db.ExecStateName[result.ExecState],
+				ErrorCode:     result.ErrorCode,
+				ErrorMessage:  result.ErrorMessage,
+				Telemetry:     telemetry,
+				StartedAt:     result.StartedAt,
+				FinishedAt:    result.FinishedAt,
+				ReportedBy:    result.ReportedBy,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       result.Id,
+					LastTime: result.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+func TraceGetInstructionList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -366,21 +578,20 @@ func TraceGetInstructionList(s *services.Server) gin.HandlerFunc {
 			sortType = services.SORTTYPE_TIME
 		}
 
-		params := &services.QueryLatestObjectsParams{
+		params := &services.QueryObjectsParams{
 			ModelStruct: new(db.Instruction),
 			Page:        int32(page),
 			PageSize:    int32(pageSize),
 			SortType:    sortType,
 			SearchInput: searchInput,
 			SearchIndex: make([]string, 0),
-			GroupIndex:  "instruction_id",
 		}
 
 		if len(searchInput) != 0 {
 			params.SearchIndex = append(params.SearchIndex, "instruction_id")
 		}
 
-		sqlRows, total, err := s.QueryLatestObjectsWithPage(params)
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
@@ -388,7 +599,7 @@ func TraceGetInstructionList(s *services.Server) gin.HandlerFunc {
 
 		defer sqlRows.Close()
 
-		resp := make([]*models.InstructionInfo, 0)
+		resp := make([]*models.InstructionDetails, 0)
 
 		for sqlRows.Next() {
 			var instruction db.Instruction
@@ -398,24 +609,111 @@ func TraceGetInstructionList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.InstructionInfo{
-				InstructionId:       instruction.InstructionId,
-				InstructionSource:   instruction.InstructionSource,
-				InstructionContent:  instruction.InstructionContent,
-				InstructionType:     db.InstructionTypeName[instruction.Type],
-				ExecInstructionTime: instruction.ExecInstructionTime,
-				GenInstructionTime:  instruction.GenInstructionTime,
-				DebrisId:            instruction.DebrisId,
-				DebrisName:          instruction.DebrisName,
-				SatelliteId:         instruction.SatelliteId,
-				SatelliteName:       instruction.SatelliteName,
+			resp = append(resp, newInstructionDetails(&instruction))
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+func GetInstructionEvents(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		instructionId := c.Query("instructionId")
+
+		err := isStringRequiredParamsEmpty(instructionId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		events, err := s.ListInstructionEvents(instructionId)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.InstructionEventInfo, 0, len(events))
+		for _, event := range events {
+			resp = append(resp, &models.InstructionEventInfo{
+				InstructionId: event.InstructionId,
+				FromState:     db.ExecStateName[event.FromState],
+				ToState:       db.ExecStateName[event.ToState],
+				Operator:      event.Operator,
+				Note:          event.Note,
+				EventTime:     event.EventTime,
 				BaseRespInfo: models.BaseRespInfo{
-					Id:       instruction.Id,
-					LastTime: instruction.LastTime,
+					Id:       event.Id,
+					LastTime: event.LastTime,
 				},
 			})
 		}
 
-		SuccessfulJSONRespWithPage(resp, total, c)
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+// ControlGetSatelliteTimeline the queued and running instructions of the satellite
+func ControlGetSatelliteTimeline(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		satelliteId := c.Query("satelliteId")
+
+		err := isStringRequiredParamsEmpty(satelliteId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		_, ok := resolveSatellite(s, c, satelliteId, "")
+		if !ok {
+			return
+		}
+
+		timeline, err := s.GetSatelliteTimeline(satelliteId)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.TimelineItemInfo, 0, len(timeline))
+		for _, entry := range timeline {
+			resp = append(resp, &models.TimelineItemInfo{
+				InstructionDetails: *newInstructionDetails(entry.Instruction),
+				TaskState:          db.TaskStateName[entry.Task.TaskState],
+				NextRunAt:          entry.Task.NextRunAt,
+				Attempts:           entry.Task.Attempts,
+				Progress:           entry.Task.Progress,
+			})
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+func newInstructionDetails(instruction *db.Instruction) *models.InstructionDetails {
+	return &models.InstructionDetails{
+		InstructionInfo: models.InstructionInfo{
+			InstructionId:       instruction.InstructionId,
+			InstructionSource:   instruction.InstructionSource,
+			InstructionContent:  instruction.InstructionContent,
+			InstructionType:     db.InstructionTypeName[instruction.Type],
+			ExecInstructionTime: instruction.ExecInstructionTime,
+			GenInstructionTime:  instruction.GenInstructionTime,
+			ScheduledTime:       instruction.ScheduledTime,
+			ScheduleWindowEnd:   instruction.ScheduleWindowEnd,
+			Reviewer:            instruction.Reviewer,
+			ReviewTime:          instruction.ReviewTime,
+			ConjunctionTca:      instruction.ConjunctionTca,
+			DebrisId:            instruction.DebrisId,
+			DebrisName:          instruction.DebrisName,
+			SatelliteId:         instruction.SatelliteId,
+			SatelliteName:       instruction.SatelliteName,
+			BaseRespInfo: models.BaseRespInfo{
+				Id:       instruction.Id,
+				LastTime: instruction.LastTime,
+			},
+		},
+		ExecState: db.ExecStateName[instruction.ExecState],
 	}
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"math/big"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS 公开 token 校验公钥，供其他服务校验本服务签发的 token。
+// 对称密钥（HS512）不公开。
+func GetJWKS(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := models.JWKSet{
+			Keys: make([]*models.JWK, 0),
+		}
+
+		for _, key := range s.GetSigningKeys() {
+			switch publicKey := key.PublicKey.(type) {
+			case *rsa.PublicKey:
+				resp.Keys = append(resp.Keys, &models.JWK{
+					Kty: "RSA",
+					Kid: key.Kid,
+					Alg: key.Method.Alg(),
+					Use: "sig",
+					N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+					E: base64.RawURLEncoding.EncodeToString(
+						big.NewInt(int64(publicKey.E)).Bytes()),
+				})
+
+			case ed25519.PublicKey:
+				resp.Keys = append(resp.Keys, &models.JWK{
+					Kty: "OKP",
+					Kid: key.Kid,
+					Alg: key.Method.Alg(),
+					Use: "sig",
+					Crv: "Ed25519",
+					X:   base64.RawURLEncoding.EncodeToString(publicKey),
+				})
+			}
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -1,20 +1,54 @@
 package handlers
 
 import (
+	"go-web-demo/src/configs"
 	"go-web-demo/src/db"
 	"go-web-demo/src/models"
 	"go-web-demo/src/services"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func JWTAuthMiddleware(s *services.Server) gin.HandlerFunc {
+// 登录失败原因
+const (
+	LOGIN_FAIL_REASON_NOT_EXIST = "用户不存在"
+
+	LOGIN_FAIL_REASON_PWD_ERROR = "密码错误"
+
+	LOGIN_FAIL_REASON_LOCKED = "账号或IP已锁定"
+
+	LOGIN_FAIL_REASON_NOT_ENABLED = "用户已禁用或等待审批"
+
+	LOGIN_FAIL_REASON_TOTP_ERROR = "动态码错误"
+
+	LOGIN_FAIL_REASON_SERVICE_ACCOUNT = "服务账号不能登录"
+)
+
+// JWTAuthMiddleware only the access token is accepted unless the purpose of the
+// token is in allowPurposes
+func JWTAuthMiddleware(s *services.Server, allowPurposes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		token := c.Request.Header.Get("token")
 		if len(token) == 0 {
+			// 服务账号使用 API Key 认证
+			apiKey := c.Request.Header.Get(services.API_KEY_HEADER)
+			if len(apiKey) != 0 && isPurposeAllowed(services.TOKEN_PURPOSE_API_KEY, allowPurposes) {
+				claims, err := s.AuthenticateApiKey(apiKey, c.ClientIP())
+				if err != nil {
+					TokenErrorJSONResp(err.Error(), c)
+					c.Abort()
+					return
+				}
+
+				c.Set("token", claims)
+				c.Next()
+				return
+			}
+
 			ParamsMissingJSONResp("the token not found", c)
 			c.Abort()
 			return
@@ -27,11 +61,36 @@ func JWTAuthMiddleware(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		if !isPurposeAllowed(claims.Purpose, allowPurposes) {
+			TokenErrorJSONResp("the token can not be used here", c)
+			c.Abort()
+			return
+		}
+
+		err = s.CheckTokenRevoked(claims)
+		if err != nil {
+			TokenErrorJSONResp(err.Error(), c)
+			c.Abort()
+			return
+		}
+
 		c.Set("token", claims)
 		c.Next()
 	}
 }
 
+func isPurposeAllowed(purpose string, allowPurposes []string) bool {
+	if len(purpose) == 0 {
+		return true
+	}
+	for _, p := range allowPurposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
 func Login(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -47,47 +106,294 @@ func Login(s *services.Server) gin.HandlerFunc {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
 		}
+
+		lockedUntil, err := s.CheckLoginLocked(req.UserName, c.ClientIP())
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if lockedUntil != 0 {
+			_ = recordLoginLog(s, c, req.UserName, db.LOGIN_FAIL, LOGIN_FAIL_REASON_LOCKED)
+			LoginLockedJSONResp("locked until "+
+				time.Unix(lockedUntil, 0).Format("2006-01-02 15:04:05"), c)
+			return
+		}
+
 		user := new(db.User)
 		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
 		if err != nil {
+			recordLoginFailure(s, c, req.UserName, LOGIN_FAIL_REASON_NOT_EXIST)
 			NotExistJSONResp(err.Error(), c)
 			return
 		}
 
-		if req.UserPwd != user.UserPwd {
+		if user.ServiceAccount {
+			_ = recordLoginLog(s, c, user.UserName, db.LOGIN_FAIL, LOGIN_FAIL_REASON_SERVICE_ACCOUNT)
+			WithoutPermissionJSONResp("service account can only use api keys", c)
+			return
+		}
+
+		match, needRehash := s.VerifyPassword(user.UserPwd, req.UserPwd)
+		if !match {
+			recordLoginFailure(s, c, req.UserName, LOGIN_FAIL_REASON_PWD_ERROR)
 			PwdErrorJSONResp("", c)
 			return
 		}
 
-		tokenExpiresAt := time.Now().Add(2 * time.Hour).Unix()
-		token, err := s.GenToken(user.Id,
-			user.UserName, db.UserRoleTypeName[user.UserRole], tokenExpiresAt)
+		if user.UserState != db.USER_ENABLED {
+			_ = recordLoginLog(s, c, user.UserName, db.LOGIN_FAIL, LOGIN_FAIL_REASON_NOT_ENABLED)
+			UserNotEnabledJSONResp(db.UserStateTypeName[user.UserState], c)
+			return
+		}
+
+		// 明文或旧参数的密码在登录成功后升级为新的哈希，失败不影响本次登录
+		if needRehash {
+			hashedPwd, err := s.HashPassword(req.UserPwd)
+			if err == nil {
+				_ = s.UpdateObject(&db.User{
+					GeneralField: db.GeneralField{Id: user.Id},
+					UserPwd:      hashedPwd,
+				})
+			}
+		}
+
+		// 启用两步验证的用户在动态码验证通过后才清除失败计数，防止暴力猜测动态码
+		if user.TotpEnabled {
+			challengeToken, expiresAt, err := s.IssueChallengeToken(user, services.TOKEN_PURPOSE_2FA)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			SuccessfulJSONResp(&models.LoginInfo{
+				UserNickName:      user.UserNickName,
+				UserRole:          db.UserRoleTypeName[user.UserRole],
+				Expires:           expiresAt,
+				TwoFactorRequired: true,
+				ChallengeToken:    challengeToken,
+			}, c)
+			return
+		}
+
+		_ = s.ClearLoginFailures(user.UserName)
+
+		required, err := s.IsTotpRequired(user.Id)
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		loginLog := &db.LoginLog{
-			UserName:  user.UserName,
-			LoginIp:   c.ClientIP(),
-			LoginTime: time.Now().Unix(),
+		if required {
+			enrollToken, expiresAt, err := s.IssueChallengeToken(user, services.TOKEN_PURPOSE_TOTP_ENROLL)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			_ = recordLoginLog(s, c, user.UserName, db.LOGIN_SUCCESS, "")
+			SuccessfulJSONResp(&models.LoginInfo{
+				UserNickName:       user.UserNickName,
+				UserRole:           db.UserRoleTypeName[user.UserRole],
+				Expires:            expiresAt,
+				Token:              enrollToken,
+				TotpEnrollRequired: true,
+			}, c)
+			return
+		}
+
+		tokenPair, err := s.StartSession(user, c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
 		}
 
-		err = s.InsertOneObjertToDB(loginLog)
+		err = recordLoginLog(s, c, user.UserName, db.LOGIN_SUCCESS, "")
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
 
 		SuccessfulJSONResp(&models.LoginInfo{
-			UserNickName: user.UserNickName,
-			UserRole:     db.UserRoleTypeName[user.UserRole],
-			Expires:      tokenExpiresAt,
-			Token:        token,
+			UserNickName:   user.UserNickName,
+			UserRole:       db.UserRoleTypeName[user.UserRole],
+			Expires:        tokenPair.AccessExpiresAt,
+			Token:          tokenPair.AccessToken,
+			RefreshToken:   tokenPair.RefreshToken,
+			RefreshExpires: tokenPair.RefreshExpiresAt,
+		}, c)
+	}
+}
+
+// Login2FA finish the login with the challenge token and the TOTP or recovery code
+func Login2FA(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.Login2FAReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.ChallengeToken, req.Code)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		claims, err := s.ParseToken(req.ChallengeToken)
+		if err != nil {
+			TokenErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if claims.Purpose != services.TOKEN_PURPOSE_2FA {
+			TokenErrorJSONResp("not a challenge token", c)
+			return
+		}
+
+		err = s.CheckTokenRevoked(claims)
+		if err != nil {
+			TokenErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		lockedUntil, err := s.CheckLoginLocked(claims.Name, c.ClientIP())
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if lockedUntil != 0 {
+			_ = recordLoginLog(s, c, claims.Name, db.LOGIN_FAIL, LOGIN_FAIL_REASON_LOCKED)
+			LoginLockedJSONResp("locked until "+
+				time.Unix(lockedUntil, 0).Format("2006-01-02 15:04:05"), c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectById(user, claims.Id)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		if user.UserState != db.USER_ENABLED {
+			_ = recordLoginLog(s, c, user.UserName, db.LOGIN_FAIL, LOGIN_FAIL_REASON_NOT_ENABLED)
+			UserNotEnabledJSONResp(db.UserStateTypeName[user.UserState], c)
+			return
+		}
+
+		err = s.VerifySecondFactor(user, req.Code)
+		if err != nil {
+			recordLoginFailure(s, c, user.UserName, LOGIN_FAIL_REASON_TOTP_ERROR)
+			TotpErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		// 挑战 token 只能使用一次
+		err = s.RevokeToken(claims)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		_ = s.ClearLoginFailures(user.UserName)
+
+		tokenPair, err := s.StartSession(user, c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordLoginLog(s, c, user.UserName, db.LOGIN_SUCCESS, "")
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.LoginInfo{
+			UserNickName:   user.UserNickName,
+			UserRole:       db.UserRoleTypeName[user.UserRole],
+			Expires:        tokenPair.AccessExpiresAt,
+			Token:          tokenPair.AccessToken,
+			RefreshToken:   tokenPair.RefreshToken,
+			RefreshExpires: tokenPair.RefreshExpiresAt,
+		}, c)
+	}
+}
+
+// recordLoginFailure count the failure for lockout and write the login log
+func recordLoginFailure(s *services.Server, c *gin.Context, userName, reason string) {
+	_ = s.RecordLoginFailure(userName, c.ClientIP())
+	_ = recordLoginLog(s, c, userName, db.LOGIN_FAIL, reason)
+}
+
+func recordLoginLog(s *services.Server, c *gin.Context, userName string,
+	result db.LoginResultType, reason string) error {
+	return s.InsertOneObjertToDB(&db.LoginLog{
+		UserName:    userName,
+		LoginIp:     c.ClientIP(),
+		LoginTime:   time.Now().Unix(),
+		UserAgent:   c.Request.UserAgent(),
+		LoginResult: result,
+		FailReason:  reason,
+	})
+}
+
+func RefreshToken(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.RefreshTokenReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.RefreshToken)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		user, tokenPair, err := s.RefreshTokens(req.RefreshToken)
+		if err != nil {
+			TokenErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.LoginInfo{
+			UserNickName:   user.UserNickName,
+			UserRole:       db.UserRoleTypeName[user.UserRole],
+			Expires:        tokenPair.AccessExpiresAt,
+			Token:          tokenPair.AccessToken,
+			RefreshToken:   tokenPair.RefreshToken,
+			RefreshExpires: tokenPair.RefreshExpiresAt,
 		}, c)
 	}
 }
 
+func Logout(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get the token from context failed", c)
+			return
+		}
+
+		err := s.RevokeToken(claims)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
 func Register(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req models.RegisterReq
@@ -96,8 +402,14 @@ func Register(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		registerMode := s.GetRegisterMode()
+		if registerMode == configs.REGISTER_MODE_OFF {
+			WithoutPermissionJSONResp("self registration is closed", c)
+			return
+		}
+
 		err := isStringRequiredParamsEmpty(req.UserName, req.UserPwd,
-			req.UserPhoneNum, req.UserNickName, req.UserEmail, req.UserRole)
+			req.UserPhoneNum, req.UserNickName, req.UserEmail)
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -121,12 +433,6 @@ func Register(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
-		role, ok := db.UserRoleTypeValue[req.UserRole]
-		if !ok {
-			ParamsValueJSONResp("role type error", c)
-			return
-		}
-
 		user := new(db.User)
 		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
 		if err == nil {
@@ -134,20 +440,66 @@ func Register(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
-		err = s.InsertOneObjertToDB(&db.User{
+		var role db.UserRoleType
+		var inviteCode *db.InviteCode
+		state := db.USER_ENABLED
+
+		switch registerMode {
+		case configs.REGISTER_MODE_INVITE:
+			// 角色由邀请码决定
+			err = isStringRequiredParamsEmpty(req.InviteCode)
+			if err != nil {
+				ParamsMissingJSONResp(err.Error(), c)
+				return
+			}
+
+			inviteCode, err = s.QueryInviteCode(req.InviteCode)
+			if err != nil {
+				WithoutPermissionJSONResp(err.Error(), c)
+				return
+			}
+			role = inviteCode.UserRole
+
+		default:
+			var ok bool
+			role, ok = db.UserRoleTypeValue[req.UserRole]
+			if !ok {
+				ParamsValueJSONResp("role type error", c)
+				return
+			}
+
+			// 管理员只能由管理员或邀请码产生
+			if role == db.ADMIN {
+				WithoutPermissionJSONResp("can not register as admin", c)
+				return
+			}
+
+			if registerMode == configs.REGISTER_MODE_APPROVAL {
+				state = db.USER_PENDING
+			}
+		}
+
+		hashedPwd, err := s.HashPassword(req.UserPwd)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.RegisterUser(&db.User{
 			UserName:     req.UserName,
 			UserRole:     role,
-			UserPwd:      req.UserPwd,
+			UserPwd:      hashedPwd,
 			UserNickName: req.UserNickName,
 			UserPhoneNum: req.UserPhoneNum,
 			UserEmail:    req.UserEmail,
-		})
+			UserState:    state,
+		}, inviteCode)
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		SuccessfulJSONResp("", c)
+		SuccessfulJSONResp(db.UserStateTypeName[state], c)
 	}
 }
 
@@ -169,6 +521,18 @@ func GetUserInfo(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		roles, perms, err := s.GetUserPermissions(user.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		permissions := make([]string, 0, len(perms))
+		for perm := range perms {
+			permissions = append(permissions, perm)
+		}
+		sort.Strings(permissions)
+
 		SuccessfulJSONResp(&models.UserInfo{
 			Id:           user.Id,
 			UserName:     user.UserName,
@@ -176,6 +540,10 @@ func GetUserInfo(s *services.Server) gin.HandlerFunc {
 			UserNickName: user.UserNickName,
 			UserPhoneNum: user.UserPhoneNum,
 			UserEmail:    user.UserEmail,
+			UserState:    db.UserStateTypeName[user.UserState],
+			UserRoles:    roles,
+			TotpEnabled:  user.TotpEnabled,
+			Permissions:  permissions,
 			Expires:      claims.StandardClaims.ExpiresAt,
 		}, c)
 
@@ -185,14 +553,11 @@ func GetUserInfo(s *services.Server) gin.HandlerFunc {
 func ControlGetLoginLogList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.CONTROL, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
+		searchInput := c.Query("searchConditions")
+		loginResultStr := c.Query("loginResult")
 
 		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
 		if err != nil {
@@ -222,7 +587,23 @@ func ControlGetLoginLogList(s *services.Server) gin.HandlerFunc {
 			Page:        int32(page),
 			PageSize:    int32(pageSize),
 			SortType:    sortType,
+			SearchInput: searchInput,
 			SearchIndex: make([]string, 0),
+			QueryMap:    make(map[string]string),
+		}
+
+		if len(searchInput) != 0 {
+			params.SearchIndex = append(params.SearchIndex, "user_name")
+			params.SearchIndex = append(params.SearchIndex, "login_ip")
+		}
+
+		if len(loginResultStr) != 0 {
+			loginResult, ok := db.LoginResultTypeValue[loginResultStr]
+			if !ok {
+				ParamsValueJSONResp("login result type not as expected", c)
+				return
+			}
+			params.QueryMap["login_result"] = strconv.Itoa(int(loginResult))
 		}
 
 		sqlRows, total, err := s.QueryObjectsWithPage(params)
@@ -244,9 +625,12 @@ func ControlGetLoginLogList(s *services.Server) gin.HandlerFunc {
 			}
 
 			resp = append(resp, &models.LoginLogInfo{
-				UserName:  loginLog.UserName,
-				LoginTime: loginLog.LoginTime,
-				LoginIp:   loginLog.LoginIp,
+				UserName:    loginLog.UserName,
+				LoginTime:   loginLog.LoginTime,
+				LoginIp:     loginLog.LoginIp,
+				UserAgent:   loginLog.UserAgent,
+				LoginResult: db.LoginResultTypeName[loginLog.LoginResult],
+				FailReason:  loginLog.FailReason,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       loginLog.Id,
 					LastTime: loginLog.LastTime,
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminGetLoginLockList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+		searchInput := c.Query("searchConditions")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.LoginLock),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchInput: searchInput,
+			SearchIndex: make([]string, 0),
+		}
+
+		if len(searchInput) != 0 {
+			params.SearchIndex = append(params.SearchIndex, "lock_target")
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		now := time.Now().Unix()
+		resp := make([]*models.LoginLockInfo, 0)
+
+		for sqlRows.Next() {
+			var loginLock db.LoginLock
+			err := s.ScanRows(sqlRows, &loginLock)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, &models.LoginLockInfo{
+				LockKey:       loginLock.LockKey,
+				LockType:      db.LockTypeName[loginLock.LockType],
+				LockTarget:    loginLock.LockTarget,
+				FailCount:     loginLock.FailCount,
+				FirstFailTime: loginLock.FirstFailTime,
+				LockedUntil:   loginLock.LockedUntil,
+				IsLocked:      loginLock.LockedUntil > now,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       loginLock.Id,
+					LastTime: loginLock.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+func AdminClearLoginLock(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ClearLoginLockReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.LockKey)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		loginLock := new(db.LoginLock)
+		err = s.QueryObjectByCondition(loginLock, "lock_key", req.LockKey)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.ClearLoginLock(req.LockKey)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "解除登录锁定："+req.LockKey)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
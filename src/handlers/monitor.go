@@ -20,31 +20,18 @@ func MonitorGetAllState(s *services.Server) gin.HandlerFunc {
 
 		var resp models.AllState
 
-		fault := new(db.Fault)
-		sqlRows, err := s.QueryOneObjectWithLatest(fault, "satellite_id")
+		// 存在未修复的故障即为异常
+		var openFaultNum int64
+		err := s.GetGormObject().Model(&db.Fault{}).
+			Where("fault_state IN ?", db.UnresolvedFaultStates).Count(&openFaultNum).Error
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
-
-		defer sqlRows.Close()
-
-		for sqlRows.Next() {
-			var f db.Fault
-			err := s.ScanRows(sqlRows, &f)
-			if err != nil {
-				ServerErrorJSONResp(err.Error(), c)
-				return
-			}
-
-			if f.RepairState == db.WRONG {
-				resp.FaultState = true
-				break
-			}
-		}
+		resp.FaultState = openFaultNum != 0
 
 		satellite := new(db.Satellite)
-		sqlRows, err = s.QueryOneObjectWithLatest(satellite, "satellite_id")
+		sqlRows, err := s.QueryOneObjectWithLatest(satellite, "satellite_id")
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
@@ -66,27 +53,34 @@ func MonitorGetAllState(s *services.Server) gin.HandlerFunc {
 			}
 		}
 
-		constellation := new(db.Constellation)
-		sqlRows, err = s.QueryOneObjectWithLatest(constellation, "constellation_id")
+		// 星座状态由成员卫星的最新状态计算
+		constellations := make([]*db.Constellation, 0)
+		err = s.GetGormObject().Model(&db.Constellation{}).
+			Order("constellation_id").Find(&constellations).Error
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		defer sqlRows.Close()
+		constellationIds := make([]string, 0, len(constellations))
+		for _, conl := range constellations {
+			constellationIds = append(constellationIds, conl.ConstellationId)
+		}
 
-		for sqlRows.Next() {
-			var conl db.Constellation
-			err := s.ScanRows(sqlRows, &conl)
-			if err != nil {
-				ServerErrorJSONResp(err.Error(), c)
-				return
-			}
+		healths, err := s.GetConstellationHealth(constellationIds)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
 
-			if conl.SatelliteLinkState == db.WRONG {
+		resp.Constellations = make([]*models.ConstellationInfo, 0, len(constellations))
+		for _, conl := range constellations {
+			health := healths[conl.ConstellationId]
+			if health.SatelliteLinkState == db.WRONG || health.SatelliteDownNum != 0 {
 				resp.ConstellationState = true
-				break
 			}
+
+			resp.Constellations = append(resp.Constellations, newConstellationInfo(conl, health))
 		}
 
 		netState := new(db.NetState)
@@ -252,6 +246,51 @@ func MonitorGetFaultInfo(s *services.Server) gin.HandlerFunc {
 	}
 }
 
+// MonitorGetFaultStats the open faults and the mean time to acknowledge and to resolve,
+// per satellite and per fault type
+func MonitorGetFaultStats(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		bySatellite, err := s.GetFaultStats(true)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		byFaultType, err := s.GetFaultStats(false)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := &models.FaultStats{
+			BySatellite: make([]*models.FaultStatsInfo, 0, len(bySatellite)),
+			ByFaultType: make([]*models.FaultStatsInfo, 0, len(byFaultType)),
+		}
+
+		for _, stats := range bySatellite {
+			resp.BySatellite = append(resp.BySatellite, &models.FaultStatsInfo{
+				SatelliteId:   stats.SatelliteId,
+				SatelliteName: stats.SatelliteName,
+				OpenNum:       stats.OpenNum,
+				MttaSeconds:   stats.Mtta,
+				MttrSeconds:   stats.Mttr,
+			})
+		}
+
+		for _, stats := range byFaultType {
+			resp.ByFaultType = append(resp.ByFaultType, &models.FaultStatsInfo{
+				FaultType:   db.FaultTypeName[stats.FaultType],
+				OpenNum:     stats.OpenNum,
+				MttaSeconds: stats.Mtta,
+				MttrSeconds: stats.Mttr,
+			})
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
 func MonitorGetEarlWarning(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var resp []models.EarlyWarningInfo
@@ -261,7 +300,7 @@ func MonitorGetEarlWarning(s *services.Server) gin.HandlerFunc {
 			Select("instruction.debris_id, instruction.debris_name, instruction.treaten, "+
 				"instruction.satellite_name, instruction.satellite_id, debris.speed, debris.height").
 			Joins("inner join debris on debris.debris_id = instruction.debris_id").
-			Where("(instruction.treaten = ? OR instruction.treaten = ?) AND instruction.exec_state = ?", db.LOW, db.HIGH, db.NOTEXEC).
+			Where("instruction.treaten = ? OR instruction.treaten = ?", db.LOW, db.HIGH).
 			Order("instruction.last_time desc").Limit(20).Find(&resp).Error
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
@@ -294,7 +333,7 @@ func MonitorGetLatestThreat(s *services.Server) gin.HandlerFunc {
 		var instruction db.Instruction
 		var resp models.LatestInfo
 		err = s.GetGormObject().Model(&db.Instruction{}).Select("id").
-			Where("exec_state = ? AND last_time >= ?", db.NOTEXEC, time).
+			Where("last_time >= ?", time).
 			Where("treaten = ?", db.HIGH).
 			First(&instruction).Error
 		if err == nil {
@@ -326,7 +365,7 @@ func MonitorGetLatestInstruction(s *services.Server) gin.HandlerFunc {
 		var instruction db.Instruction
 		var resp models.LatestInfo
 		err = s.GetGormObject().Model(&db.Instruction{}).Select("id").
-			Where("type = ? AND exec_state = ? AND last_time >= ?", db.OPERATION, db.NOTEXEC, time).
+			Where("type = ? AND last_time >= ?", db.OPERATION, time).
 			First(&instruction).Error
 		if err == nil {
 			resp.IsLatest = true
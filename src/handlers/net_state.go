@@ -11,19 +11,14 @@ import (
 
 func ExecAddNetState(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddNetStateReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName,
-			req.OrbitId, req.NetworkSegment, req.NetworkState, req.NetworkBandwidth)
+		err := isStringRequiredParamsEmpty(req.SatelliteId,
+			req.NetworkSegment, req.NetworkState, string(req.NetworkBandwidth))
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -35,19 +30,30 @@ func ExecAddNetState(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		satellite, ok := resolveSatellite(s, c, req.SatelliteId, req.OrbitId)
+		if !ok {
+			return
+		}
+
 		networkState, ok := db.StateValue[req.NetworkState]
 		if !ok {
 			ParamsValueJSONResp("network state type not as expected", c)
 			return
 		}
 
+		networkBandwidth, err := db.ParseQuantity(string(req.NetworkBandwidth), db.UnitMbps)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
 		netState := &db.NetState{
-			SatelliteId:      req.SatelliteId,
-			SatelliteName:    req.SatelliteName,
-			OrbitId:          req.OrbitId,
-			NetworkSegment:   req.NetworkSegment,
-			NetworkState:     networkState,
-			NetworkBandwidth: req.NetworkBandwidth,
+			SatelliteId:          req.SatelliteId,
+			SatelliteName:        satellite.SatelliteName,
+			OrbitId:              satellite.OrbitId,
+			NetworkSegment:       req.NetworkSegment,
+			NetworkState:         networkState,
+			NetworkBandwidthMbps: networkBandwidth,
 		}
 
 		err = s.InsertOneObjertToDB(netState)
@@ -63,11 +69,6 @@ func ExecAddNetState(s *services.Server) gin.HandlerFunc {
 func ExecGetNetStateList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -129,12 +130,12 @@ func ExecGetNetStateList(s *services.Server) gin.HandlerFunc {
 			}
 
 			resp = append(resp, &models.NetStateInfo{
-				SatelliteId:      netState.SatelliteId,
-				SatelliteName:    netState.SatelliteName,
-				OrbitId:          netState.OrbitId,
-				NetworkSegment:   netState.NetworkSegment,
-				NetworkState:     db.StateName[netState.NetworkState],
-				NetworkBandwidth: netState.NetworkBandwidth,
+				SatelliteId:          netState.SatelliteId,
+				SatelliteName:        netState.SatelliteName,
+				OrbitId:              netState.OrbitId,
+				NetworkSegment:       netState.NetworkSegment,
+				NetworkState:         db.StateName[netState.NetworkState],
+				NetworkBandwidthMbps: netState.NetworkBandwidthMbps,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       netState.Id,
 					LastTime: netState.LastTime,
@@ -149,11 +150,6 @@ func ExecGetNetStateList(s *services.Server) gin.HandlerFunc {
 func TraceGetNetStateList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -216,12 +212,12 @@ func TraceGetNetStateList(s *services.Server) gin.HandlerFunc {
 			}
 
 			resp = append(resp, &models.NetStateInfo{
-				SatelliteId:      netState.SatelliteId,
-				SatelliteName:    netState.SatelliteName,
-				OrbitId:          netState.OrbitId,
-				NetworkSegment:   netState.NetworkSegment,
-				NetworkState:     db.StateName[netState.NetworkState],
-				NetworkBandwidth: netState.NetworkBandwidth,
+				SatelliteId:          netState.SatelliteId,
+				SatelliteName:        netState.SatelliteName,
+				OrbitId:              netState.OrbitId,
+				NetworkSegment:       netState.NetworkSegment,
+				NetworkState:         db.StateName[netState.NetworkState],
+				NetworkBandwidthMbps: netState.NetworkBandwidthMbps,
 				BaseRespInfo: models.BaseRespInfo{
 					Id:       netState.Id,
 					LastTime: netState.LastTime,
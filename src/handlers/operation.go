@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"encoding/json"
 	"go-web-demo/src/db"
 	"go-web-demo/src/models"
 	"go-web-demo/src/services"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,11 +14,6 @@ import (
 func ControlGetOperationList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -82,7 +79,7 @@ func ControlGetOperationList(s *services.Server) gin.HandlerFunc {
 				Operator:        operation.Operator,
 				OperationTime:   operation.OperationTime,
 				OperationIp:     operation.OperatorIp,
-				OperationRecord: operation.OperationRecord,
+				OperationRecord: string(operation.OperationRecord),
 				SatelliteId:     operation.SatelliteId,
 				SatelliteName:   operation.SatelliteName,
 				BaseRespInfo: models.BaseRespInfo{
@@ -99,11 +96,6 @@ func ControlGetOperationList(s *services.Server) gin.HandlerFunc {
 func TraceGetOperationList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -170,7 +162,7 @@ func TraceGetOperationList(s *services.Server) gin.HandlerFunc {
 				Operator:        operation.Operator,
 				OperationTime:   operation.OperationTime,
 				OperationIp:     operation.OperatorIp,
-				OperationRecord: operation.OperationRecord,
+				OperationRecord: string(operation.OperationRecord),
 				SatelliteId:     operation.SatelliteId,
 				SatelliteName:   operation.SatelliteName,
 				BaseRespInfo: models.BaseRespInfo{
@@ -183,3 +175,27 @@ func TraceGetOperationList(s *services.Server) gin.HandlerFunc {
 		SuccessfulJSONRespWithPage(resp, total, c)
 	}
 }
+
+// recordChange write the operation record with the values before and after the change,
+// before is nil when adding and after is nil when deleting
+func recordChange(s *services.Server, c *gin.Context, operator, record string,
+	before, after interface{}) error {
+	change, err := json.Marshal(&models.OperationChange{
+		Before: before,
+		After:  after,
+	})
+	if err != nil {
+		return err
+	}
+	return recordOperation(s, c, operator, record+"，"+string(change))
+}
+
+// recordOperation write the operation record of the current user
+func recordOperation(s *services.Server, c *gin.Context, operator, record string) error {
+	return s.InsertOneObjertToDB(&db.Operation{
+		Operator:        operator,
+		OperatorIp:      c.ClientIP(),
+		OperationTime:   time.Now().Unix(),
+		OperationRecord: db.LongText(record),
+	})
+}
@@ -11,11 +11,6 @@ import (
 
 func ControlAddOrbit(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddOrbitReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
@@ -34,6 +29,33 @@ func ControlAddOrbit(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		err = services.CheckOrbitElements(req.OrbitSemiMajorAxis, req.OrbitEccentricity,
+			req.OrbitAngle, req.AscendingNodeLongitude, req.Perigee)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = services.CheckOrbitType(req.OrbitType,
+			services.DeriveOrbitParams(req.OrbitSemiMajorAxis, req.OrbitEccentricity).Class)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.Orbit), "orbit_id", req.OrbitId)
+		if err == nil {
+			UniqueIndexJSONResp("轨道已存在", c)
+			return
+		}
+
 		orbit := &db.Orbit{
 			OrbitId:                req.OrbitId,
 			OrbitType:              req.OrbitType,
@@ -50,18 +72,170 @@ func ControlAddOrbit(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		err = recordChange(s, c, claims.Name, "添加轨道："+req.OrbitId, nil, newOrbitInfo(orbit))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		s.TriggerScreening()
+
 		SuccessfulJSONResp("", c)
 	}
 }
 
-func ControlGetOrbitList(s *services.Server) gin.HandlerFunc {
+func ControlGetOrbit(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		orbitId := c.Query("orbitId")
+
+		err := isStringRequiredParamsEmpty(orbitId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		orbit := new(db.Orbit)
+		err = s.QueryObjectByCondition(orbit, "orbit_id", orbitId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(newOrbitInfo(orbit), c)
+	}
+}
+
+// ControlUpdateOrbit replace all the fields of the orbit except the orbit id
+func ControlUpdateOrbit(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AddOrbitReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.OrbitId, req.OrbitType)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = services.CheckOrbitElements(req.OrbitSemiMajorAxis, req.OrbitEccentricity,
+			req.OrbitAngle, req.AscendingNodeLongitude, req.Perigee)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = services.CheckOrbitType(req.OrbitType,
+			services.DeriveOrbitParams(req.OrbitSemiMajorAxis, req.OrbitEccentricity).Class)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		orbit := new(db.Orbit)
+		err = s.QueryObjectByCondition(orbit, "orbit_id", req.OrbitId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		before := newOrbitInfo(orbit)
+		orbit.OrbitType = req.OrbitType
+		orbit.OrbitSemiMajorAxis = req.OrbitSemiMajorAxis
+		orbit.OrbitEccentricity = req.OrbitEccentricity
+		orbit.OrbitAngle = req.OrbitAngle
+		orbit.AscendingNodeLongitude = req.AscendingNodeLongitude
+		orbit.Perigee = req.Perigee
+
+		err = s.ReplaceObject(orbit)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "修改轨道："+req.OrbitId, before, newOrbitInfo(orbit))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		s.TriggerScreening()
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ControlDeleteOrbit the orbit is retired by soft delete, the id can be added again
+func ControlDeleteOrbit(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var req models.DeleteOrbitReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.OrbitId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		orbit := new(db.Orbit)
+		err = s.QueryObjectByCondition(orbit, "orbit_id", req.OrbitId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		count, err := s.CountCatalogSatellites("orbit_id", req.OrbitId)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if count != 0 {
+			ParamsValueJSONResp("the orbit is used by the satellites in the catalog", c)
+			return
+		}
+
+		err = s.DeleteObject(orbit)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
 
-		if err := checkTheAccessPermission(c, db.CONTROL); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
+		err = recordChange(s, c, claims.Name, "删除轨道："+req.OrbitId, newOrbitInfo(orbit), nil)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
 			return
 		}
 
+		s.TriggerScreening()
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetOrbitList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -121,21 +295,38 @@ func ControlGetOrbitList(s *services.Server) gin.HandlerFunc {
 				return
 			}
 
-			resp = append(resp, &models.OrbitInfo{
-				OrbitId:                orbit.OrbitId,
-				OrbitType:              orbit.OrbitType,
-				OrbitEccentricity:      orbit.OrbitEccentricity,
-				OrbitSemiMajorAxis:     orbit.OrbitSemiMajorAxis,
-				OrbitAngle:             orbit.OrbitAngle,
-				AscendingNodeLongitude: orbit.AscendingNodeLongitude,
-				Perigee:                orbit.Perigee,
-				BaseRespInfo: models.BaseRespInfo{
-					Id:       orbit.Id,
-					LastTime: orbit.LastTime,
-				},
-			})
+			resp = append(resp, newOrbitInfo(&orbit))
 		}
 
 		SuccessfulJSONRespWithPage(resp, total, c)
 	}
 }
+
+// newOrbitInfo with the parameters derived from the elements
+func newOrbitInfo(orbit *db.Orbit) *models.OrbitInfo {
+	info := &models.OrbitInfo{
+		OrbitId:                orbit.OrbitId,
+		OrbitType:              orbit.OrbitType,
+		OrbitEccentricity:      orbit.OrbitEccentricity,
+		OrbitSemiMajorAxis:     orbit.OrbitSemiMajorAxis,
+		OrbitAngle:             orbit.OrbitAngle,
+		AscendingNodeLongitude: orbit.AscendingNodeLongitude,
+		Perigee:                orbit.Perigee,
+		BaseRespInfo: models.BaseRespInfo{
+			Id:       orbit.Id,
+			LastTime: orbit.LastTime,
+		},
+	}
+
+	params := services.DeriveOrbitParams(orbit.OrbitSemiMajorAxis, orbit.OrbitEccentricity)
+	if params != nil {
+		info.PeriodMinutes = params.PeriodMinutes
+		info.ApogeeAltitudeKm = params.ApogeeAltitudeKm
+		info.PerigeeAltitudeKm = params.PerigeeAltitudeKm
+		info.MeanMotion = params.MeanMotion
+		info.OrbitClass = params.Class
+		info.OrbitTypeMatched = services.CheckOrbitType(orbit.OrbitType, params.Class) == nil
+	}
+
+	return info
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission the user of the token must hold any of the permissions,
+// declared on the routes in routers
+func RequirePermission(s *services.Server, perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			c.Abort()
+			return
+		}
+
+		ok, err := s.CheckClaimsPermission(claims, perms...)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			c.Abort()
+			return
+		}
+
+		if !ok {
+			WithoutPermissionJSONResp("access without permission: "+strings.Join(perms, ","), c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func AdminGetPermissionList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		resp := make([]*models.PermissionInfo, 0, len(services.PermissionName))
+		for perm, desc := range services.PermissionName {
+			resp = append(resp, &models.PermissionInfo{
+				Permission:  perm,
+				Description: desc,
+			})
+		}
+
+		sort.Slice(resp, func(i, j int) bool {
+			return resp[i].Permission < resp[j].Permission
+		})
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+func AdminGetRoleList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		roles, err := s.ListRoles()
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		resp := make([]*models.RoleInfo, 0, len(roles))
+		for _, role := range roles {
+			resp = append(resp, &models.RoleInfo{
+				RoleName:    role.RoleName,
+				Description: role.Description,
+				Permissions: services.SplitPermissions(role.Permissions),
+				BuiltIn:     role.BuiltIn,
+				BaseRespInfo: models.BaseRespInfo{
+					Id:       role.Id,
+					LastTime: role.LastTime,
+				},
+			})
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+func AdminAddRole(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.RoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.RoleName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = services.CheckPermissions(req.Permissions)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.Role), "role_name", req.RoleName)
+		if err == nil {
+			UniqueIndexJSONResp("角色已存在", c)
+			return
+		}
+
+		permissions := services.JoinPermissions(req.Permissions)
+		err = s.AddRole(&db.Role{
+			RoleName:    req.RoleName,
+			Description: req.Description,
+			Permissions: permissions,
+		})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "添加角色："+req.RoleName+"，"+permissions)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func AdminUpdateRole(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.RoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.RoleName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = services.CheckPermissions(req.Permissions)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		// 管理员角色始终拥有全部权限，防止误操作后无人能够管理
+		if req.RoleName == db.ADMIN_STR {
+			ParamsValueJSONResp("can not change the admin role", c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		role := new(db.Role)
+		err = s.QueryObjectByCondition(role, "role_name", req.RoleName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		oldPermissions := role.Permissions
+		role.Description = req.Description
+		role.Permissions = services.JoinPermissions(req.Permissions)
+		err = s.UpdateRole(role)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "修改角色："+req.RoleName+"，"+
+			oldPermissions+" -> "+role.Permissions)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func AdminDeleteRole(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.DeleteRoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.RoleName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		role := new(db.Role)
+		err = s.QueryObjectByCondition(role, "role_name", req.RoleName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		if role.BuiltIn {
+			ParamsValueJSONResp("can not delete the built-in role", c)
+			return
+		}
+
+		err = s.DeleteRole(role.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "删除角色："+req.RoleName)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// AdminSetUserRoles replace all the roles of the user, the UserRole of the user is kept
+func AdminSetUserRoles(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.SetUserRolesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		if req.UserName == claims.Name {
+			ParamsValueJSONResp("can not change the roles of yourself", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		roleNames := make([]string, 0, len(req.RoleNames))
+		seen := make(map[string]bool)
+		for _, name := range req.RoleNames {
+			if len(name) != 0 && !seen[name] {
+				seen[name] = true
+				roleNames = append(roleNames, name)
+			}
+		}
+
+		err = s.SetUserRoles(user.Id, roleNames)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "设置用户角色："+req.UserName+"，"+
+			strings.Join(roleNames, ","))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ControlAddSatellite(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AddSatelliteReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName,
+			req.OrbitId, req.Status)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = checkTheKeyRule(req.SatelliteId)
+		if err != nil {
+			ParamsFormatErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		status, ok := db.SatelliteStatusValue[req.Status]
+		if !ok {
+			ParamsValueJSONResp("satellite status not as expected", c)
+			return
+		}
+
+		err = s.CheckCatalogReferences(req.OrbitId, req.ConstellationId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.QueryObjectByCondition(new(db.SatelliteCatalog), "satellite_id", req.SatelliteId)
+		if err == nil {
+			UniqueIndexJSONResp("卫星已存在", c)
+			return
+		}
+
+		satellite := &db.SatelliteCatalog{
+			SatelliteId:     req.SatelliteId,
+			SatelliteName:   req.SatelliteName,
+			OrbitId:         req.OrbitId,
+			ConstellationId: req.ConstellationId,
+			LaunchDate:      req.LaunchDate,
+			Status:          status,
+		}
+
+		err = s.InsertOneObjertToDB(satellite)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "添加卫星："+req.SatelliteId, nil, newSatelliteInfo(satellite))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetSatellite(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		satelliteId := c.Query("satelliteId")
+
+		err := isStringRequiredParamsEmpty(satelliteId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		satellite, err := s.GetCatalogSatellite(satelliteId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(newSatelliteInfo(satellite), c)
+	}
+}
+
+// ControlUpdateSatellite replace all the fields of the satellite except the satellite id,
+// the name is copied into the records reported later
+func ControlUpdateSatellite(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AddSatelliteReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName,
+			req.OrbitId, req.Status)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		status, ok := db.SatelliteStatusValue[req.Status]
+		if !ok {
+			ParamsValueJSONResp("satellite status not as expected", c)
+			return
+		}
+
+		err = s.CheckCatalogReferences(req.OrbitId, req.ConstellationId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		satellite, err := s.GetCatalogSatellite(req.SatelliteId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		before := newSatelliteInfo(satellite)
+		satellite.SatelliteName = req.SatelliteName
+		satellite.OrbitId = req.OrbitId
+		satellite.ConstellationId = req.ConstellationId
+		satellite.LaunchDate = req.LaunchDate
+		satellite.Status = status
+
+		err = s.ReplaceObject(satellite)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "修改卫星："+req.SatelliteId, before, newSatelliteInfo(satellite))
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ControlDeleteSatellite the satellite is retired by soft delete, the records reported
+// before are kept
+func ControlDeleteSatellite(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.DeleteSatelliteReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.SatelliteId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		satellite, err := s.GetCatalogSatellite(req.SatelliteId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.DeleteObject(satellite)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordChange(s, c, claims.Name, "删除卫星："+req.SatelliteId, newSatelliteInfo(satellite), nil)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ControlGetSatelliteList(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		pageStr := c.Query("page")
+		pageSizeStr := c.Query("pageSize")
+		sortTypeStr := c.Query("sortType")
+		searchInput := c.Query("searchConditions")
+		orbitId := c.Query("orbitId")
+		constellationId := c.Query("constellationId")
+
+		err := isStringRequiredParamsEmpty(pageSizeStr, pageStr)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		sortType, ok := services.SortTypeValue[sortTypeStr]
+		if !ok {
+			sortType = services.SORTTYPE_TIME
+		}
+
+		queryMap := make(map[string]string)
+		if len(orbitId) != 0 {
+			queryMap["orbit_id"] = orbitId
+		}
+		if len(constellationId) != 0 {
+			queryMap["constellation_id"] = constellationId
+		}
+
+		params := &services.QueryObjectsParams{
+			ModelStruct: new(db.SatelliteCatalog),
+			Page:        int32(page),
+			PageSize:    int32(pageSize),
+			SortType:    sortType,
+			SearchInput: searchInput,
+			SearchIndex: []string{"satellite_id", "satellite_name"},
+			QueryMap:    queryMap,
+		}
+
+		sqlRows, total, err := s.QueryObjectsWithPage(params)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		defer sqlRows.Close()
+
+		resp := make([]*models.SatelliteInfo, 0)
+
+		for sqlRows.Next() {
+			var satellite db.SatelliteCatalog
+			err := s.ScanRows(sqlRows, &satellite)
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+
+			resp = append(resp, newSatelliteInfo(&satellite))
+		}
+
+		SuccessfulJSONRespWithPage(resp, total, c)
+	}
+}
+
+// resolveSatellite the satellite must be in the catalog, the orbit given by the client
+// is optional but must be the same as the catalog, the response is written on failure
+func resolveSatellite(s *services.Server, c *gin.Context, satelliteId,
+	orbitId string) (*db.SatelliteCatalog, bool) {
+
+	satellite, err := s.GetCatalogSatellite(satelliteId)
+	if err != nil {
+		NotExistJSONResp(err.Error(), c)
+		return nil, false
+	}
+
+	if len(orbitId) != 0 && orbitId != satellite.OrbitId {
+		ParamsValueJSONResp("the orbit is not the same as the catalog: "+satellite.OrbitId, c)
+		return nil, false
+	}
+
+	return satellite, true
+}
+
+func newSatelliteInfo(satellite *db.SatelliteCatalog) *models.SatelliteInfo {
+	return &models.SatelliteInfo{
+		SatelliteId:     satellite.SatelliteId,
+		SatelliteName:   satellite.SatelliteName,
+		OrbitId:         satellite.OrbitId,
+		ConstellationId: satellite.ConstellationId,
+		LaunchDate:      satellite.LaunchDate,
+		Status:          db.SatelliteStatusName[satellite.Status],
+		BaseRespInfo: models.BaseRespInfo{
+			Id:       satellite.Id,
+			LastTime: satellite.LastTime,
+		},
+	}
+}
@@ -11,19 +11,13 @@ import (
 
 func ExecAddSatelliteState(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		var req models.AddSatelliteState
 		if err := c.ShouldBindJSON(&req); err != nil {
 			ParamsTypeErrorJSONResp(err.Error(), c)
 			return
 		}
 
-		err := isStringRequiredParamsEmpty(req.SatelliteId, req.SatelliteName,
-			req.OrbitId, req.RunState)
+		err := isStringRequiredParamsEmpty(req.SatelliteId, req.RunState)
 		if err != nil {
 			ParamsMissingJSONResp(err.Error(), c)
 			return
@@ -35,22 +29,27 @@ func ExecAddSatelliteState(s *services.Server) gin.HandlerFunc {
 			return
 		}
 
+		satellite, ok := resolveSatellite(s, c, req.SatelliteId, req.OrbitId)
+		if !ok {
+			return
+		}
+
 		runState, ok := db.StateValue[req.RunState]
 		if !ok {
 			ParamsValueJSONResp("run state type not as expected", c)
 			return
 		}
 
-		satellite := &db.Satellite{
+		satelliteState := &db.Satellite{
 			SatelliteId:   req.SatelliteId,
-			SatelliteName: req.SatelliteName,
-			OrbitId:       req.OrbitId,
+			SatelliteName: satellite.SatelliteName,
+			OrbitId:       satellite.OrbitId,
 			RunState:      runState,
 			MeanAnomaly:   req.MeanAnomaly,
 			Speed:         req.Speed,
 		}
 
-		err = s.InsertOneObjertToDB(satellite)
+		err = s.InsertOneObjertToDB(satelliteState)
 		if err != nil {
 			ServerErrorJSONResp(err.Error(), c)
 			return
@@ -63,11 +62,6 @@ func ExecAddSatelliteState(s *services.Server) gin.HandlerFunc {
 func ExecGetSatelliteStateList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.EXEC); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -150,11 +144,6 @@ func ExecGetSatelliteStateList(s *services.Server) gin.HandlerFunc {
 func TraceGetSatelliteStateList(s *services.Server) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		if err := checkTheAccessPermission(c, db.TRACE); err != nil {
-			WithoutPermissionJSONResp(err.Error(), c)
-			return
-		}
-
 		pageStr := c.Query("page")
 		pageSizeStr := c.Query("pageSize")
 		sortTypeStr := c.Query("sortType")
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserSessions the active sessions of the user, the current one is marked
+func GetUserSessions(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		resp, err := getSessionInfoList(s, claims.Id, claims.Sid)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+func TerminateSession(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.TerminateSessionReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.SessionId)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.TerminateSession(claims.Id, req.SessionId)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "终止会话："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// TerminateOtherSessions keep the current session only
+func TerminateOtherSessions(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err := s.TerminateOtherSessions(claims.Id, claims.Sid)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "终止其他会话："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func AdminGetUserSessions(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		userName := c.Query("userName")
+
+		err := isStringRequiredParamsEmpty(userName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", userName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		resp, err := getSessionInfoList(s, user.Id, claims.Sid)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(resp, c)
+	}
+}
+
+// AdminTerminateUserSession terminate one session of the user, or all of them
+// when the session id is empty
+func AdminTerminateUserSession(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.TerminateUserSessionReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		record := "终止用户全部会话：" + req.UserName
+		if len(req.SessionId) == 0 {
+			err = s.TerminateOtherSessions(user.Id, "")
+			if err != nil {
+				ServerErrorJSONResp(err.Error(), c)
+				return
+			}
+		} else {
+			err = s.TerminateSession(user.Id, req.SessionId)
+			if err != nil {
+				NotExistJSONResp(err.Error(), c)
+				return
+			}
+			record = "终止用户会话：" + req.UserName
+		}
+
+		err = recordOperation(s, c, claims.Name, record)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func getSessionInfoList(s *services.Server, userId int32, currentSessionId string) ([]*models.SessionInfo, error) {
+	sessions, err := s.ListUserSessions(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, &models.SessionInfo{
+			SessionId:    session.SessionId,
+			LoginIp:      session.LoginIp,
+			UserAgent:    session.UserAgent,
+			LoginTime:    session.LoginTime,
+			LastActiveAt: session.LastActiveAt,
+			ExpiresAt:    session.ExpiresAt,
+			Current:      session.SessionId == currentSessionId,
+			BaseRespInfo: models.BaseRespInfo{
+				Id:       session.Id,
+				LastTime: session.LastTime,
+			},
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TotpSetup generate the secret and the provisioning uri shown as the QR code
+func TotpSetup(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err := s.QueryObjectById(user, claims.Id)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		secret, uri, err := s.SetupTotp(user)
+		if err != nil {
+			ParamsValueJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.TotpSetupInfo{
+			Secret:          secret,
+			ProvisioningUri: uri,
+		}, c)
+	}
+}
+
+// TotpEnable the recovery codes are only returned here. The enrollment token
+// is revoked, the user needs to login again with the code
+func TotpEnable(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.TotpCodeReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.Code)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectById(user, claims.Id)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		codes, err := s.EnableTotp(user, req.Code)
+		if err != nil {
+			TotpErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if claims.Purpose == services.TOKEN_PURPOSE_TOTP_ENROLL {
+			_ = s.RevokeToken(claims)
+		}
+
+		err = recordOperation(s, c, claims.Name, "启用两步验证："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.RecoveryCodes{RecoveryCodes: codes}, c)
+	}
+}
+
+// TotpDisable not allowed when the roles of the user require 2FA
+func TotpDisable(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.DisableTotpReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserPwd, req.Code)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		required, err := s.IsTotpRequired(claims.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		if required {
+			WithoutPermissionJSONResp("2FA is required for the role", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectById(user, claims.Id)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		match, _ := s.VerifyPassword(user.UserPwd, req.UserPwd)
+		if !match {
+			PwdErrorJSONResp("", c)
+			return
+		}
+
+		err = s.VerifySecondFactor(user, req.Code)
+		if err != nil {
+			TotpErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.DisableTotp(user.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "关闭两步验证："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// TotpGenRecoveryCodes replace the recovery codes, the old ones can not be used any more
+func TotpGenRecoveryCodes(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.TotpCodeReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.Code)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectById(user, claims.Id)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.VerifySecondFactor(user, req.Code)
+		if err != nil {
+			TotpErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		codes, err := s.GenRecoveryCodes(user.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "重新生成恢复码："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp(&models.RecoveryCodes{RecoveryCodes: codes}, c)
+	}
+}
+
+// AdminResetUserTotp clear the 2FA of the user who lost the device,
+// the user enrolls again on the next login if the role requires it
+func AdminResetUserTotp(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ResetUserTotpReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.DisableTotp(user.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = s.RevokeAllUserTokens(user.Id)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "重置两步验证："+req.UserName)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"go-web-demo/src/db"
+	"go-web-demo/src/models"
+	"go-web-demo/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UpdateUserInfo(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.UpdateUserInfoReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserNickName, req.UserPhoneNum, req.UserEmail)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		err = checkThePhoneNum(req.UserPhoneNum)
+		if err != nil {
+			ParamsFormatErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = checkTheEmail(req.UserEmail)
+		if err != nil {
+			ParamsFormatErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		err = s.UpdateObject(&db.User{
+			GeneralField: db.GeneralField{Id: claims.Id},
+			UserNickName: req.UserNickName,
+			UserPhoneNum: req.UserPhoneNum,
+			UserEmail:    req.UserEmail,
+		})
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "修改个人信息："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ChangePwd all the tokens of the user are revoked, the user needs to login again
+func ChangePwd(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ChangePwdReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.OldPwd, req.NewPwd)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		token, ok1 := c.Get("token")
+		claims, ok2 := token.(*services.MyClaims)
+		if !ok1 || !ok2 {
+			ServerErrorJSONResp("get token failed", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectById(user, claims.Id)
+		if err != nil {
+			NotExistJSONResp(err.Error(), c)
+			return
+		}
+
+		match, _ := s.VerifyPassword(user.UserPwd, req.OldPwd)
+		if !match {
+			PwdErrorJSONResp("", c)
+			return
+		}
+
+		err = s.UpdateUserPwd(user.Id, req.NewPwd)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, claims.Name, "修改密码："+claims.Name)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+// ForgetPwd mail the reset token to the user. The response is the same whether
+// the user exists or not, so that it can not be used to guess the user names
+func ForgetPwd(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ForgetPwdReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.UserName)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		if !s.MailEnabled() {
+			ServerErrorJSONResp("the mail sender is not configured", c)
+			return
+		}
+
+		user := new(db.User)
+		err = s.QueryObjectByCondition(user, "user_name", req.UserName)
+		if err == nil && user.UserState == db.USER_ENABLED {
+			_ = s.SendPwdResetMail(user)
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
+
+func ResetPwd(s *services.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req models.ResetPwdReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ParamsTypeErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err := isStringRequiredParamsEmpty(req.ResetToken, req.NewPwd)
+		if err != nil {
+			ParamsMissingJSONResp(err.Error(), c)
+			return
+		}
+
+		user, err := s.ResetPwdWithToken(req.ResetToken, req.NewPwd)
+		if err == services.ErrPwdResetTokenInvalid {
+			TokenErrorJSONResp(err.Error(), c)
+			return
+		} else if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		err = recordOperation(s, c, user.UserName, "找回密码："+user.UserName)
+		if err != nil {
+			ServerErrorJSONResp(err.Error(), c)
+			return
+		}
+
+		SuccessfulJSONResp("", c)
+	}
+}
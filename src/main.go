@@ -9,6 +9,7 @@ import (
 	"go-web-demo/src/routers"
 	"go-web-demo/src/services"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,14 +24,42 @@ func main() {
 
 	sugaredLogger, logger := loggers.InitLogger(conf.LogConfig)
 
-	db, err := db.GormInit(conf.DBConfig, db.TableSlice, sugaredLogger)
+	gormDb, err := db.GormInit(conf.DBConfig, sugaredLogger)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(gormDb, os.Args[2:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if conf.DBConfig.AutoMigrate {
+		err = db.MigrateUp(gormDb, 0)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// 表结构与程序版本不一致时拒绝启动
+	err = db.CheckSchemaVersion(gormDb)
+	if err != nil {
+		panic(err)
+	}
+
+	keyStore, err := services.NewKeyStore(conf.TokenConfig, sugaredLogger)
 	if err != nil {
 		panic(err)
 	}
 
 	server, err := services.NewServer(services.WithConfig(conf),
 		services.WithGinEngin(),
-		services.WithGormDb(db),
+		services.WithGormDb(gormDb),
+		services.WithKeyStore(keyStore),
+		services.WithMailSender(services.NewSMTPSender(conf.MailConfig)),
 		services.WithLog(logger),
 		services.WithSuLog(sugaredLogger),
 	)
@@ -38,6 +67,22 @@ func main() {
 		panic(err)
 	}
 
+	err = server.InitRoles()
+	if err != nil {
+		panic(err)
+	}
+
+	err = server.InitAdmin()
+	if err != nil {
+		panic(err)
+	}
+
+	server.StartTokenCleaner()
+
+	server.StartInstructionExecutor()
+
+	server.StartConjunctionScreening()
+
 	err = Start(server)
 	if err != nil {
 		panic(err)
@@ -61,9 +106,13 @@ func Start(s *services.Server) error {
 
 	routers.LoadNoTokenRouter(s)
 
+	routers.LoadWellKnownRouter(s)
+
 	routers.LoadMonitorRouter(s)
 
-	s.GetGinEngine().Use(handlers.JWTAuthMiddleware(s))
+	routers.LoadTotpRouter(s)
+
+	s.GetGinEngine().Use(handlers.JWTAuthMiddleware(s, services.TOKEN_PURPOSE_API_KEY))
 
 	routers.LoadUserRouter(s)
 
@@ -73,6 +122,10 @@ func Start(s *services.Server) error {
 
 	routers.LoadTraceRouter(s)
 
+	routers.LoadMonitorAuthRouter(s)
+
+	routers.LoadAdminRouter(s)
+
 	err := s.GetGinEngine().Run(":" + s.GetSeverPort())
 	if err != nil {
 		return err
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go-web-demo/src/db"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const MIGRATE_USAGE = "usage: demo migrate [up [version] | down <version> | status]"
+
+// runMigrate the migrate command, up to the latest version by default
+func runMigrate(gormDb *gorm.DB, args []string) error {
+	action := "up"
+	if len(args) != 0 {
+		action = args[0]
+	}
+
+	var target int64
+	var err error
+	if len(args) > 1 {
+		target, err = strconv.ParseInt(args[1], 10, 32)
+		if err != nil || target < 0 {
+			return errors.New(MIGRATE_USAGE)
+		}
+	}
+
+	switch action {
+	case "up":
+		err = db.MigrateUp(gormDb, int32(target))
+	case "down":
+		// 回滚必须明确指定目标版本
+		if len(args) < 2 {
+			return errors.New(MIGRATE_USAGE)
+		}
+		err = db.MigrateDown(gormDb, int32(target))
+	case "status":
+	default:
+		return errors.New(MIGRATE_USAGE)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := db.CurrentVersion(gormDb)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("schema version: %d, binary version: %d\n", current, db.LatestVersion())
+	return nil
+}
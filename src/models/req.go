@@ -1,5 +1,26 @@
 package models
 
+import "encoding/json"
+
+// Quantity 带单位的遥测数值，可以是数字或 "120Mbps"、"35 ms" 这样的字符串，
+// 入库时换算为对应列的单位
+type Quantity string
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*q = Quantity(str)
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return err
+	}
+	*q = Quantity(num.String())
+	return nil
+}
+
 type RegisterReq struct {
 	UserName     string `json:"userName"`
 	UserPwd      string `json:"userPwd"`
@@ -7,6 +28,8 @@ type RegisterReq struct {
 	UserNickName string `json:"userNickName"`
 	UserPhoneNum string `json:"userPhoneNum"`
 	UserEmail    string `json:"userEmail"`
+	// InviteCode is required when the register mode is invite
+	InviteCode string `json:"inviteCode"`
 }
 
 type LoginReq struct {
@@ -14,6 +37,14 @@ type LoginReq struct {
 	UserPwd  string `json:"userPwd"`
 }
 
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type ClearLoginLockReq struct {
+	LockKey string `json:"lockKey"`
+}
+
 type AddDebirsReq struct {
 	DebrisId     string  `json:"debrisId"`
 	DebrisName   string  `json:"debrisName"`
@@ -32,25 +63,86 @@ type AddInstructionReq struct {
 	SatelliteId        string `json:"satelliteId"`
 	SatelliteName      string `json:"satelliteName"`
 	InstructionContent string `json:"instructionContent"`
+	// TimeoutSeconds 单次执行的超时时间，0 表示使用配置的默认值
+	TimeoutSeconds int64 `json:"timeoutSeconds"`
+	// ExecTime 要求的执行时间，ExecWindowEnd 之后不再开始执行（秒），0 表示立即执行、不限结束时间
+	ExecTime      int64 `json:"execTime"`
+	ExecWindowEnd int64 `json:"execWindowEnd"`
 }
 
+// ReviewInstructionReq 驳回时必须填写原因
+type ReviewInstructionReq struct {
+	InstructionId string `json:"instructionId"`
+	Approve       bool   `json:"approve"`
+	Reason        string `json:"reason"`
+}
+
+// AvoidancePolicyReq satelliteId 和 constellationId 只能填写一个，contentTemplate 为空时使用默认模板
+type AvoidancePolicyReq struct {
+	PolicyName      string `json:"policyName"`
+	SatelliteId     string `json:"satelliteId"`
+	ConstellationId string `json:"constellationId"`
+	Mode            string `json:"mode"`
+	Source          string `json:"source"`
+	ContentTemplate string `json:"contentTemplate"`
+	Enabled         bool   `json:"enabled"`
+}
+
+type DeleteAvoidancePolicyReq struct {
+	PolicyName string `json:"policyName"`
+}
+
+type CancelInstructionReq struct {
+	InstructionId string `json:"instructionId"`
+	Reason        string `json:"reason"`
+}
+
+type ReportInstructionProgressReq struct {
+	InstructionId string `json:"instructionId"`
+	// Progress 0-100
+	Progress int32  `json:"progress"`
+	Message  string `json:"message"`
+}
+
+// ReportInstructionResultReq execState 为执行成功或执行失败，失败时必须带错误码，时间单位为秒
+type ReportInstructionResultReq struct {
+	InstructionId string          `json:"instructionId"`
+	ExecState     string          `json:"execState"`
+	ErrorCode     string          `json:"errorCode"`
+	ErrorMessage  string          `json:"errorMessage"`
+	Telemetry     json.RawMessage `json:"telemetry"`
+	StartedAt     int64           `json:"startedAt"`
+	FinishedAt    int64           `json:"finishedAt"`
+}
+
+// AddOrbitReq 轨道根数，半长轴单位为千米，角度单位为度
 type AddOrbitReq struct {
-	OrbitId                string  `json:"orbitId"`
-	OrbitType              string  `json:"orbitType"`
-	OrbitEccentricity      float64 `json:"orbitEccentricity"`
-	OrbitSemiMajorAxis     float64 `json:"orbitSemiMajorAxis"`
-	OrbitAngle             float64 `json:"orbitAngle"`
+	OrbitId   string `json:"orbitId"`
+	OrbitType string `json:"orbitType"`
+	// 偏心率，[0, 1)
+	OrbitEccentricity float64 `json:"orbitEccentricity"`
+	// 半长轴，千米，近地点需高于地球表面
+	OrbitSemiMajorAxis float64 `json:"orbitSemiMajorAxis"`
+	// 轨道倾角，度，[0, 180]
+	OrbitAngle float64 `json:"orbitAngle"`
+	// 升交点赤经，度，[0, 360)
 	AscendingNodeLongitude float64 `json:"ascendingNodeLongitude"`
-	Perigee                float64 `json:"perigee"`
+	// 近地点幅角，度，[0, 360)
+	Perigee float64 `json:"perigee"`
 }
 
 type AddConstellationReq struct {
-	ConstellationId    string `json:"constellationId"`
-	ConstellationName  string `json:"constellationName"`
-	SatelliteTotalNum  int32  `json:"satelliteTotalNum"`
-	SatelliteUpNum     int32  `json:"satelliteUpNum"`
-	SatelliteDownNum   int32  `json:"satelliteDownNum"`
-	SatelliteLinkState string `json:"satelliteLinkState"`
+	ConstellationId   string `json:"constellationId"`
+	ConstellationName string `json:"constellationName"`
+}
+
+type AddSatelliteReq struct {
+	SatelliteId     string `json:"satelliteId"`
+	SatelliteName   string `json:"satelliteName"`
+	OrbitId         string `json:"orbitId"`
+	ConstellationId string `json:"constellationId"`
+	LaunchDate      int64  `json:"launchDate"`
+	Status          string `json:"status"`
 }
 
 type AddSatelliteState struct {
@@ -63,11 +155,11 @@ type AddSatelliteState struct {
 }
 
 type AddControlsReq struct {
-	SatelliteId          string  `json:"satelliteId"`
-	SatelliteName        string  `json:"satelliteName"`
-	SatelliteAttitude    string  `json:"satelliteAttitude"`
-	SatelliteTemperature float64 `json:"satelliteTemperature"`
-	SatellitePower       string  `json:"satellitePower"`
+	SatelliteId          string   `json:"satelliteId"`
+	SatelliteName        string   `json:"satelliteName"`
+	SatelliteAttitude    string   `json:"satelliteAttitude"`
+	SatelliteTemperature float64  `json:"satelliteTemperature"`
+	SatellitePower       Quantity `json:"satellitePower"`
 }
 
 type AddFaultReq struct {
@@ -76,25 +168,152 @@ type AddFaultReq struct {
 	OrbitId          string `json:"orbitId"`
 	FaultType        string `json:"faultType"`
 	FaultDescription string `json:"faultDescription"`
-	RepairState      string `json:"repairState"`
+}
+
+type TransitFaultReq struct {
+	FaultId    int32  `json:"faultId"`
+	FaultState string `json:"faultState"`
+	Assignee   string `json:"assignee"`
+	Note       string `json:"note"`
 }
 
 type AddNetStateReq struct {
-	SatelliteId      string `json:"satelliteId"`
-	SatelliteName    string `json:"satelliteName"`
-	OrbitId          string `json:"orbitId"`
-	NetworkSegment   string `json:"networkSegment"`
-	NetworkState     string `json:"networkState"`
-	NetworkBandwidth string `json:"networkBandwidth"`
+	SatelliteId      string   `json:"satelliteId"`
+	SatelliteName    string   `json:"satelliteName"`
+	OrbitId          string   `json:"orbitId"`
+	NetworkSegment   string   `json:"networkSegment"`
+	NetworkState     string   `json:"networkState"`
+	NetworkBandwidth Quantity `json:"networkBandwidth"`
 }
 
 type AddCommStateReq struct {
-	SatelliteId   string `json:"satelliteId"`
-	SatelliteName string `json:"satelliteName"`
-	OrbitId       string `json:"orbitId"`
-	CommState     string `json:"commState"`
-	CommPort      string `json:"commPort"`
-	CommBandwidth string `json:"commBandwidth"`
-	CommDelay     string `json:"commDelay"`
-	LinkLoad      string `json:"linkLoad"`
+	SatelliteId   string   `json:"satelliteId"`
+	SatelliteName string   `json:"satelliteName"`
+	OrbitId       string   `json:"orbitId"`
+	CommState     string   `json:"commState"`
+	CommPort      string   `json:"commPort"`
+	CommBandwidth Quantity `json:"commBandwidth"`
+	CommDelay     Quantity `json:"commDelay"`
+	LinkLoad      Quantity `json:"linkLoad"`
+}
+
+type Login2FAReq struct {
+	ChallengeToken string `json:"challengeToken"`
+	// Code is the TOTP code or a recovery code
+	Code string `json:"code"`
+}
+
+type TotpCodeReq struct {
+	Code string `json:"code"`
+}
+
+type DisableTotpReq struct {
+	UserPwd string `json:"userPwd"`
+	Code    string `json:"code"`
+}
+
+type UpdateUserInfoReq struct {
+	UserNickName string `json:"userNickName"`
+	UserPhoneNum string `json:"userPhoneNum"`
+	UserEmail    string `json:"userEmail"`
+}
+
+type ChangePwdReq struct {
+	OldPwd string `json:"oldPwd"`
+	NewPwd string `json:"newPwd"`
+}
+
+type ForgetPwdReq struct {
+	UserName string `json:"userName"`
+}
+
+type ResetPwdReq struct {
+	ResetToken string `json:"resetToken"`
+	NewPwd     string `json:"newPwd"`
+}
+
+type UpdateUserStateReq struct {
+	UserName  string `json:"userName"`
+	UserState string `json:"userState"`
+}
+
+type UpdateUserRoleReq struct {
+	UserName string `json:"userName"`
+	UserRole string `json:"userRole"`
+}
+
+type ResetUserPwdReq struct {
+	UserName string `json:"userName"`
+	NewPwd   string `json:"newPwd"`
+}
+
+type DeleteUserReq struct {
+	UserName string `json:"userName"`
+}
+
+type ResetUserTotpReq struct {
+	UserName string `json:"userName"`
+}
+
+type AddInviteCodeReq struct {
+	UserRole string `json:"userRole"`
+}
+
+type RoleReq struct {
+	RoleName    string   `json:"roleName"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type DeleteRoleReq struct {
+	RoleName string `json:"roleName"`
+}
+
+type SetUserRolesReq struct {
+	UserName  string   `json:"userName"`
+	RoleNames []string `json:"roleNames"`
+}
+
+type AddServiceAccountReq struct {
+	UserName     string `json:"userName"`
+	UserNickName string `json:"userNickName"`
+	UserRole     string `json:"userRole"`
+}
+
+type AddApiKeyReq struct {
+	UserName string   `json:"userName"`
+	KeyName  string   `json:"keyName"`
+	Scopes   []string `json:"scopes"`
+	// ExpireDays 0 means never expire
+	ExpireDays int32 `json:"expireDays"`
+}
+
+type RevokeApiKeyReq struct {
+	KeyPrefix string `json:"keyPrefix"`
+}
+
+type TerminateSessionReq struct {
+	SessionId string `json:"sessionId"`
+}
+
+type TerminateUserSessionReq struct {
+	UserName string `json:"userName"`
+	// SessionId empty means all the sessions of the user
+	SessionId string `json:"sessionId"`
+}
+
+type DeleteDebrisReq struct {
+	DebrisId string `json:"debrisId"`
+}
+
+type DeleteOrbitReq struct {
+	OrbitId string `json:"orbitId"`
+}
+
+type DeleteConstellationReq struct {
+	ConstellationId string `json:"constellationId"`
+}
+
+type DeleteSatelliteReq struct {
+	SatelliteId string `json:"satelliteId"`
 }
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 type StandardResp struct {
 	Code int32       `json:"code"`
 	Msg  string      `json:"msg"`
@@ -22,6 +24,12 @@ const (
 
 	RESP_CODE_PWD_ERROR = 503
 
+	RESP_CODE_LOGIN_LOCKED = 504
+
+	RESP_CODE_USER_NOT_ENABLED = 505
+
+	RESP_CODE_TOTP_ERROR = 506
+
 	RESP_CODE_PARAMS_TYPE_ERROR = 101
 
 	RESP_CODE_PARAMS_MISSING = 102
@@ -58,6 +66,12 @@ const (
 
 	RESP_MSG_PWD_ERROR = "密码错误"
 
+	RESP_MSG_LOGIN_LOCKED = "登录失败次数过多，已被锁定"
+
+	RESP_MSG_USER_NOT_ENABLED = "用户已禁用或等待审批"
+
+	RESP_MSG_TOTP_ERROR = "动态码错误"
+
 	RESP_MSG_NOT_IN_CHAIN = "不属于链上用户，无法操作链"
 
 	RESP_MSG_TOKEN_ERROR = "请重新登录"
@@ -66,20 +80,112 @@ const (
 )
 
 type UserInfo struct {
-	Id           int32  `json:"id"`
+	Id           int32    `json:"id"`
+	UserName     string   `json:"userName"`
+	UserNickName string   `json:"userNickName"`
+	UserRole     string   `json:"userRole"`
+	UserPhoneNum string   `json:"userPhoneNum"`
+	UserEmail    string   `json:"userEmail"`
+	UserState    string   `json:"userState"`
+	UserRoles    []string `json:"userRoles"`
+	TotpEnabled  bool     `json:"totpEnabled"`
+	Permissions  []string `json:"permissions"`
+	Expires      int64    `json:"expires"`
+}
+
+type UserDetails struct {
+	BaseRespInfo
 	UserName     string `json:"userName"`
 	UserNickName string `json:"userNickName"`
 	UserRole     string `json:"userRole"`
 	UserPhoneNum string `json:"userPhoneNum"`
 	UserEmail    string `json:"userEmail"`
-	Expires      int64  `json:"expires"`
+	UserState    string `json:"userState"`
+	// ServiceAccount can only use api keys
+	ServiceAccount bool `json:"serviceAccount"`
+}
+
+type InviteCodeInfo struct {
+	BaseRespInfo
+	CodePrefix string `json:"codePrefix"`
+	UserRole   string `json:"userRole"`
+	CreatedBy  string `json:"createdBy"`
+	ExpiresAt  int64  `json:"expiresAt"`
+	UsedBy     string `json:"usedBy"`
+	UsedTime   int64  `json:"usedTime"`
+}
+
+type RoleInfo struct {
+	BaseRespInfo
+	RoleName    string   `json:"roleName"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	BuiltIn     bool     `json:"builtIn"`
+}
+
+type PermissionInfo struct {
+	Permission  string `json:"permission"`
+	Description string `json:"description"`
+}
+
+type TotpSetupInfo struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type ApiKeyInfo struct {
+	BaseRespInfo
+	UserName   string   `json:"userName"`
+	KeyName    string   `json:"keyName"`
+	KeyPrefix  string   `json:"keyPrefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedBy  string   `json:"createdBy"`
+	ExpiresAt  int64    `json:"expiresAt"`
+	LastUsedAt int64    `json:"lastUsedAt"`
+	LastUsedIp string   `json:"lastUsedIp"`
+	RevokedAt  int64    `json:"revokedAt"`
+}
+
+type NewApiKey struct {
+	ApiKey    string `json:"apiKey"`
+	KeyPrefix string `json:"keyPrefix"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type NewInviteCode struct {
+	InviteCode string `json:"inviteCode"`
+	UserRole   string `json:"userRole"`
+	ExpiresAt  int64  `json:"expiresAt"`
+}
+
+type SessionInfo struct {
+	BaseRespInfo
+	SessionId    string `json:"sessionId"`
+	LoginIp      string `json:"loginIp"`
+	UserAgent    string `json:"userAgent"`
+	LoginTime    int64  `json:"loginTime"`
+	LastActiveAt int64  `json:"lastActiveAt"`
+	ExpiresAt    int64  `json:"expiresAt"`
+	// Current the session of the request token
+	Current bool `json:"current"`
 }
 
 type LoginInfo struct {
-	UserNickName string `json:"userNickName"`
-	UserRole     string `json:"userRole"`
-	Expires      int64  `json:"expires"`
-	Token        string `json:"token"`
+	UserNickName   string `json:"userNickName"`
+	UserRole       string `json:"userRole"`
+	Expires        int64  `json:"expires"`
+	Token          string `json:"token"`
+	RefreshToken   string `json:"refreshToken"`
+	RefreshExpires int64  `json:"refreshExpires"`
+	// TwoFactorRequired the ChallengeToken must be sent with the code to finish login
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+	// TotpEnrollRequired the Token can only be used to enable 2FA
+	TotpEnrollRequired bool `json:"totpEnrollRequired"`
 }
 
 type BaseRespInfo struct {
@@ -111,6 +217,15 @@ type InstructionInfo struct {
 	SatelliteName       string `json:"satelliteName"`
 	InstructionContent  string `json:"instructionContent"`
 	GenInstructionTime  int64  `json:"genInstructionTime"`
+	ScheduledTime       int64  `json:"scheduledTime"`
+	ScheduleWindowEnd   int64  `json:"scheduleWindowEnd"`
+	Reviewer            string `json:"reviewer"`
+	ReviewTime          int64  `json:"reviewTime"`
+	ConjunctionTca      int64  `json:"conjunctionTca"`
+}
+
+type NewInstruction struct {
+	InstructionId string `json:"instructionId"`
 }
 
 type InstructionDetails struct {
@@ -118,6 +233,70 @@ type InstructionDetails struct {
 	ExecState string `json:"execState"`
 }
 
+// ClaimedInstruction deadline 为上报结果的截止时间（毫秒），超时后记为执行失败
+type ClaimedInstruction struct {
+	InstructionDetails
+	Attempt  int32 `json:"attempt"`
+	Deadline int64 `json:"deadline"`
+}
+
+// TimelineItemInfo nextRunAt 为下一次执行的时间（毫秒）
+type TimelineItemInfo struct {
+	InstructionDetails
+	TaskState string `json:"taskState"`
+	NextRunAt int64  `json:"nextRunAt"`
+	Attempts  int32  `json:"attempts"`
+	Progress  int32  `json:"progress"`
+}
+
+// ConjunctionInfo tca 为最近接近时刻（秒），screenedAt 为筛查时间（毫秒）
+type ConjunctionInfo struct {
+	BaseRespInfo
+	SatelliteId    string  `json:"satelliteId"`
+	SatelliteName  string  `json:"satelliteName"`
+	DebrisId       string  `json:"debrisId"`
+	DebrisName     string  `json:"debrisName"`
+	Tca            int64   `json:"tca"`
+	MissDistanceKm float64 `json:"missDistanceKm"`
+	Treaten        string  `json:"threaten"`
+	ScreenedAt     int64   `json:"screenedAt"`
+}
+
+type AvoidancePolicyInfo struct {
+	BaseRespInfo
+	PolicyName      string `json:"policyName"`
+	SatelliteId     string `json:"satelliteId"`
+	ConstellationId string `json:"constellationId"`
+	Mode            string `json:"mode"`
+	Source          string `json:"source"`
+	ContentTemplate string `json:"contentTemplate"`
+	Enabled         bool   `json:"enabled"`
+}
+
+type InstructionResultInfo struct {
+	BaseRespInfo
+	InstructionId string          `json:"instructionId"`
+	Attempt       int32           `json:"attempt"`
+	ExecState     string          `json:"execState"`
+	ErrorCode     string          `json:"errorCode"`
+	ErrorMessage  string          `json:"errorMessage"`
+	Telemetry     json.RawMessage `json:"telemetry"`
+	StartedAt     int64           `json:"startedAt"`
+	FinishedAt    int64           `json:"finishedAt"`
+	ReportedBy    string          `json:"reportedBy"`
+}
+
+// InstructionEventInfo 创建指令的事件 fromState 为空
+type InstructionEventInfo struct {
+	BaseRespInfo
+	InstructionId string `json:"instructionId"`
+	FromState     string `json:"fromState"`
+	ToState       string `json:"toState"`
+	Operator      string `json:"operator"`
+	Note          string `json:"note"`
+	EventTime     int64  `json:"eventTime"`
+}
+
 type OrbitInfo struct {
 	BaseRespInfo
 	OrbitId                string  `json:"orbitId"`
@@ -127,6 +306,15 @@ type OrbitInfo struct {
 	OrbitAngle             float64 `json:"orbitAngle"`
 	AscendingNodeLongitude float64 `json:"ascendingNodeLongitude"`
 	Perigee                float64 `json:"perigee"`
+
+	// 由轨道根数推导，轨道根数无效时为零值
+	PeriodMinutes     float64 `json:"periodMinutes"`
+	ApogeeAltitudeKm  float64 `json:"apogeeAltitudeKm"`
+	PerigeeAltitudeKm float64 `json:"perigeeAltitudeKm"`
+	MeanMotion        float64 `json:"meanMotionRevPerDay"`
+	OrbitClass        string  `json:"orbitClass"`
+	// OrbitTypeMatched 轨道类型与推导的分类是否一致
+	OrbitTypeMatched bool `json:"orbitTypeMatched"`
 }
 
 type ConstellationInfo struct {
@@ -139,6 +327,34 @@ type ConstellationInfo struct {
 	SatelliteLinkState string `json:"satelliteLinkState"`
 }
 
+// ConstellationMemberInfo the member of the constellation and its latest reported states
+type ConstellationMemberInfo struct {
+	SatelliteId   string `json:"satelliteId"`
+	SatelliteName string `json:"satelliteName"`
+	OrbitId       string `json:"orbitId"`
+	Status        string `json:"status"`
+	RunState      string `json:"runState"`
+	RunStateTime  int64  `json:"runStateTime"`
+	NetworkState  string `json:"networkState"`
+	NetStateTime  int64  `json:"netStateTime"`
+}
+
+type SatelliteInfo struct {
+	BaseRespInfo
+	SatelliteId     string `json:"satelliteId"`
+	SatelliteName   string `json:"satelliteName"`
+	OrbitId         string `json:"orbitId"`
+	ConstellationId string `json:"constellationId"`
+	LaunchDate      int64  `json:"launchDate"`
+	Status          string `json:"status"`
+}
+
+// OperationChange the values before and after the change in the operation record
+type OperationChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
 type OperationInfo struct {
 	BaseRespInfo
 	Operator        string `json:"operator"`
@@ -151,9 +367,23 @@ type OperationInfo struct {
 
 type LoginLogInfo struct {
 	BaseRespInfo
-	UserName  string `json:"userName"`
-	LoginTime int64  `json:"loginTime"`
-	LoginIp   string `json:"loginIp"`
+	UserName    string `json:"userName"`
+	LoginTime   int64  `json:"loginTime"`
+	LoginIp     string `json:"loginIp"`
+	UserAgent   string `json:"userAgent"`
+	LoginResult string `json:"loginResult"`
+	FailReason  string `json:"failReason"`
+}
+
+type LoginLockInfo struct {
+	BaseRespInfo
+	LockKey       string `json:"lockKey"`
+	LockType      string `json:"lockType"`
+	LockTarget    string `json:"lockTarget"`
+	FailCount     int32  `json:"failCount"`
+	FirstFailTime int64  `json:"firstFailTime"`
+	LockedUntil   int64  `json:"lockedUntil"`
+	IsLocked      bool   `json:"isLocked"`
 }
 
 type SatelliteStateInfo struct {
@@ -172,7 +402,7 @@ type ControlsInfo struct {
 	SatelliteName        string  `json:"satelliteName"`
 	SatelliteAttitude    string  `json:"satelliteAttitude"`
 	SatelliteTemperature float64 `json:"satelliteTemperature"`
-	SatellitePower       string  `json:"satellitePower"`
+	SatellitePowerW      float64 `json:"satellitePowerW"`
 }
 
 type FaultInfo struct {
@@ -184,28 +414,59 @@ type FaultInfo struct {
 	FaultDescription string `json:"faultDescription"`
 	FaultTime        int64  `json:"faultTime"`
 	RepairState      string `json:"repairState"`
+	FaultState       string `json:"faultState"`
+	Assignee         string `json:"assignee"`
+	AcknowledgedAt   int64  `json:"acknowledgedAt"`
+	ResolvedAt       int64  `json:"resolvedAt"`
+	ClosedAt         int64  `json:"closedAt"`
+}
+
+type FaultTransitionInfo struct {
+	BaseRespInfo
+	FaultId        int32  `json:"faultId"`
+	FromState      string `json:"fromState"`
+	ToState        string `json:"toState"`
+	Operator       string `json:"operator"`
+	Assignee       string `json:"assignee"`
+	Note           string `json:"note"`
+	TransitionTime int64  `json:"transitionTime"`
+}
+
+// FaultStatsInfo 未修复的故障数量，平均确认时间和平均修复时间（秒），没有样本时为 null
+type FaultStatsInfo struct {
+	SatelliteId   string   `json:"satelliteId,omitempty"`
+	SatelliteName string   `json:"satelliteName,omitempty"`
+	FaultType     string   `json:"faultType,omitempty"`
+	OpenNum       int64    `json:"openNum"`
+	MttaSeconds   *float64 `json:"mttaSeconds"`
+	MttrSeconds   *float64 `json:"mttrSeconds"`
+}
+
+type FaultStats struct {
+	BySatellite []*FaultStatsInfo `json:"bySatellite"`
+	ByFaultType []*FaultStatsInfo `json:"byFaultType"`
 }
 
 type NetStateInfo struct {
 	BaseRespInfo
-	SatelliteId      string `json:"satelliteId"`
-	SatelliteName    string `json:"satelliteName"`
-	OrbitId          string `json:"orbitId"`
-	NetworkSegment   string `json:"networkSegment"`
-	NetworkState     string `json:"networkState"`
-	NetworkBandwidth string `json:"networkBandwidth"`
+	SatelliteId          string  `json:"satelliteId"`
+	SatelliteName        string  `json:"satelliteName"`
+	OrbitId              string  `json:"orbitId"`
+	NetworkSegment       string  `json:"networkSegment"`
+	NetworkState         string  `json:"networkState"`
+	NetworkBandwidthMbps float64 `json:"networkBandwidthMbps"`
 }
 
 type CommStateInfo struct {
 	BaseRespInfo
-	SatelliteId   string `json:"satelliteId"`
-	SatelliteName string `json:"satelliteName"`
-	OrbitId       string `json:"orbitId"`
-	CommState     string `json:"commState"`
-	CommPort      string `json:"commPort"`
-	CommBandwidth string `json:"commBandwidth"`
-	CommDelay     string `json:"commDelay"`
-	LinkLoad      string `json:"linkLoad"`
+	SatelliteId       string  `json:"satelliteId"`
+	SatelliteName     string  `json:"satelliteName"`
+	OrbitId           string  `json:"orbitId"`
+	CommState         string  `json:"commState"`
+	CommPort          string  `json:"commPort"`
+	CommBandwidthMbps float64 `json:"commBandwidthMbps"`
+	CommDelayMs       float64 `json:"commDelayMs"`
+	LinkLoadPercent   float64 `json:"linkLoadPercent"`
 }
 
 type AllState struct {
@@ -214,6 +475,8 @@ type AllState struct {
 	ConstellationState bool `json:"constellationState"`
 	NetState           bool `json:"netState"`
 	CommState          bool `json:"commState"`
+
+	Constellations []*ConstellationInfo `json:"constellations"`
 }
 
 type ChainDataNum struct {
@@ -260,3 +523,19 @@ type LatestInfo struct {
 type LastTime struct {
 	LastTime int64 `json:"serverLastTime"`
 }
+
+// JWK 公钥信息，格式遵循 RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
@@ -9,6 +9,8 @@ const ROUTERS_HEADER = "/satellitebc"
 
 const ROUTERS_USER = ROUTERS_HEADER + "/user"
 
+const ROUTERS_TOTP = ROUTERS_USER + "/totp"
+
 const ROUTERS_CONTROL = ROUTERS_HEADER + "/control"
 
 const ROUTERS_EXEC = ROUTERS_HEADER + "/exec"
@@ -17,40 +19,189 @@ const ROUTERS_TRACE = ROUTERS_HEADER + "/trace"
 
 const ROUTERS_MONITOR = ROUTERS_HEADER + "/monitor"
 
+const ROUTERS_ADMIN = ROUTERS_HEADER + "/admin"
+
+const ROUTERS_WELL_KNOWN = ROUTERS_HEADER + "/.well-known"
+
 func LoadNoTokenRouter(s *services.Server) {
 	routerGroup := s.GetGinEngine().Group(ROUTERS_USER)
 	{
 		routerGroup.POST("/register", handlers.Register(s))
 		routerGroup.POST("/login", handlers.Login(s))
+		routerGroup.POST("/login/2fa", handlers.Login2FA(s))
+		routerGroup.POST("/refreshtoken", handlers.RefreshToken(s))
+		routerGroup.POST("/forgetpwd", handlers.ForgetPwd(s))
+		routerGroup.POST("/resetpwd", handlers.ResetPwd(s))
+	}
+}
+
+func LoadWellKnownRouter(s *services.Server) {
+	routerGroup := s.GetGinEngine().Group(ROUTERS_WELL_KNOWN)
+	{
+		routerGroup.GET("/jwks.json", handlers.GetJWKS(s))
 	}
 }
 
+// LoadTotpRouter the routes also accept the enrollment token of the users who
+// must enable 2FA, so they are loaded with their own auth middleware
+func LoadTotpRouter(s *services.Server) {
+	routerGroup := s.GetGinEngine().Group(ROUTERS_TOTP,
+		handlers.JWTAuthMiddleware(s, services.TOKEN_PURPOSE_TOTP_ENROLL))
+	{
+		routerGroup.POST("/setup", handlers.TotpSetup(s))
+		routerGroup.POST("/enable", handlers.TotpEnable(s))
+	}
+}
+
+// LoadUserRouter the routes of the user itself are not for the api keys
 func LoadUserRouter(s *services.Server) {
-	routerGroup := s.GetGinEngine().Group(ROUTERS_USER)
+	routerGroup := s.GetGinEngine().Group(ROUTERS_USER, handlers.DenyApiKey())
 	{
 		routerGroup.GET("/getuserinfo", handlers.GetUserInfo(s))
+		routerGroup.POST("/logout", handlers.Logout(s))
+		routerGroup.POST("/updateuserinfo", handlers.UpdateUserInfo(s))
+		routerGroup.POST("/changepwd", handlers.ChangePwd(s))
+		routerGroup.POST("/totp/disable", handlers.TotpDisable(s))
+		routerGroup.POST("/totp/recoverycodes", handlers.TotpGenRecoveryCodes(s))
+		routerGroup.GET("/sessions", handlers.GetUserSessions(s))
+		routerGroup.POST("/terminatesession", handlers.TerminateSession(s))
+		routerGroup.POST("/terminateothersessions", handlers.TerminateOtherSessions(s))
 	}
 }
 
 func LoadControlRouter(s *services.Server) {
 	routerGroup := s.GetGinEngine().Group(ROUTERS_CONTROL)
 	{
-		routerGroup.POST("/adddebris", handlers.ControlAddDebris(s))
-		routerGroup.GET("/getdebrislist", handlers.ControlGetDebrisList(s))
-
-		routerGroup.POST("/addinstruction", handlers.ControlAddInstruction(s))
-		routerGroup.GET("/getinstructionlist", handlers.ControlGetInstructionList(s))
-
-		routerGroup.POST("/addorbit", handlers.ControlAddOrbit(s))
-		routerGroup.GET("/getorbitlist", handlers.ControlGetOrbitList(s))
-
-		routerGroup.POST("/addconstellation", handlers.ControlAddConstellation(s))
-		routerGroup.GET("/getconstellationlist", handlers.ControlGetConstellationList(s))
+		routerGroup.POST("/adddebris",
+			handlers.RequirePermission(s, services.PERM_DEBRIS_WRITE), handlers.ControlAddDebris(s))
+		routerGroup.GET("/getdebrislist",
+			handlers.RequirePermission(s, services.PERM_DEBRIS_READ), handlers.ControlGetDebrisList(s))
+		routerGroup.GET("/getdebris",
+			handlers.RequirePermission(s, services.PERM_DEBRIS_READ), handlers.ControlGetDebris(s))
+		routerGroup.POST("/updatedebris",
+			handlers.RequirePermission(s, services.PERM_DEBRIS_WRITE), handlers.ControlUpdateDebris(s))
+		routerGroup.POST("/deletedebris",
+			handlers.RequirePermission(s, services.PERM_DEBRIS_WRITE), handlers.ControlDeleteDebris(s))
+
+		routerGroup.POST("/addinstruction",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_WRITE), handlers.ControlAddInstruction(s))
+		routerGroup.GET("/getinstructionlist",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_READ), handlers.ControlGetInstructionList(s))
+		routerGroup.POST("/reviewinstruction",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_WRITE), handlers.ControlReviewInstruction(s))
+		routerGroup.POST("/cancelinstruction",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_WRITE), handlers.ControlCancelInstruction(s))
+		routerGroup.GET("/getinstructionevents",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_READ), handlers.GetInstructionEvents(s))
+		routerGroup.GET("/getsatellitetimeline",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_READ), handlers.ControlGetSatelliteTimeline(s))
+		routerGroup.GET("/getconjunctionlist",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_READ), handlers.ControlGetConjunctionList(s))
+
+		routerGroup.POST("/addavoidancepolicy",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_WRITE), handlers.ControlAddAvoidancePolicy(s))
+		routerGroup.POST("/updateavoidancepolicy",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_WRITE), handlers.ControlUpdateAvoidancePolicy(s))
+		routerGroup.POST("/deleteavoidancepolicy",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_WRITE), handlers.ControlDeleteAvoidancePolicy(s))
+		routerGroup.GET("/getavoidancepolicylist",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_READ), handlers.ControlGetAvoidancePolicyList(s))
+
+		routerGroup.POST("/addorbit",
+			handlers.RequirePermission(s, services.PERM_ORBIT_WRITE), handlers.ControlAddOrbit(s))
+		routerGroup.GET("/getorbitlist",
+			handlers.RequirePermission(s, services.PERM_ORBIT_READ), handlers.ControlGetOrbitList(s))
+		routerGroup.GET("/getorbit",
+			handlers.RequirePermission(s, services.PERM_ORBIT_READ), handlers.ControlGetOrbit(s))
+		routerGroup.POST("/updateorbit",
+			handlers.RequirePermission(s, services.PERM_ORBIT_WRITE), handlers.ControlUpdateOrbit(s))
+		routerGroup.POST("/deleteorbit",
+			handlers.RequirePermission(s, services.PERM_ORBIT_WRITE), handlers.ControlDeleteOrbit(s))
+
+		routerGroup.POST("/addconstellation",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_WRITE), handlers.ControlAddConstellation(s))
+		routerGroup.GET("/getconstellationlist",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_READ), handlers.ControlGetConstellationList(s))
+		routerGroup.GET("/getconstellation",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_READ), handlers.ControlGetConstellation(s))
+		routerGroup.POST("/updateconstellation",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_WRITE), handlers.ControlUpdateConstellation(s))
+		routerGroup.POST("/deleteconstellation",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_WRITE), handlers.ControlDeleteConstellation(s))
+		routerGroup.GET("/getconstellationmembers",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_READ), handlers.GetConstellationMembers(s))
+
+		routerGroup.POST("/addsatellite",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_WRITE), handlers.ControlAddSatellite(s))
+		routerGroup.GET("/getsatellitelist",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_READ), handlers.ControlGetSatelliteList(s))
+		routerGroup.GET("/getsatellite",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_READ), handlers.ControlGetSatellite(s))
+		routerGroup.POST("/updatesatellite",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_WRITE), handlers.ControlUpdateSatellite(s))
+		routerGroup.POST("/deletesatellite",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_WRITE), handlers.ControlDeleteSatellite(s))
+
+		routerGroup.GET("/getoperationlist",
+			handlers.RequirePermission(s, services.PERM_OPERATION_READ), handlers.ControlGetOperationList(s))
+
+		routerGroup.GET("/getloginloglist",
+			handlers.RequirePermission(s, services.PERM_LOGIN_LOG_READ), handlers.ControlGetLoginLogList(s))
 
-		routerGroup.GET("/getoperationlist", handlers.ControlGetOperationList(s))
-
-		routerGroup.GET("/getloginloglist", handlers.ControlGetLoginLogList(s))
+	}
+}
 
+func LoadAdminRouter(s *services.Server) {
+	routerGroup := s.GetGinEngine().Group(ROUTERS_ADMIN)
+	{
+		routerGroup.GET("/getuserlist",
+			handlers.RequirePermission(s, services.PERM_USER_READ), handlers.AdminGetUserList(s))
+		routerGroup.POST("/updateuserstate",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminUpdateUserState(s))
+		routerGroup.POST("/updateuserrole",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminUpdateUserRole(s))
+		routerGroup.POST("/resetuserpwd",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminResetUserPwd(s))
+		routerGroup.POST("/deleteuser",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminDeleteUser(s))
+
+		routerGroup.POST("/addinvitecode",
+			handlers.RequirePermission(s, services.PERM_INVITE_CODE_WRITE), handlers.AdminAddInviteCode(s))
+		routerGroup.GET("/getinvitecodelist",
+			handlers.RequirePermission(s, services.PERM_INVITE_CODE_READ), handlers.AdminGetInviteCodeList(s))
+
+		routerGroup.GET("/getpermissionlist",
+			handlers.RequirePermission(s, services.PERM_ROLE_READ), handlers.AdminGetPermissionList(s))
+		routerGroup.GET("/getrolelist",
+			handlers.RequirePermission(s, services.PERM_ROLE_READ), handlers.AdminGetRoleList(s))
+		routerGroup.POST("/addrole",
+			handlers.RequirePermission(s, services.PERM_ROLE_WRITE), handlers.AdminAddRole(s))
+		routerGroup.POST("/updaterole",
+			handlers.RequirePermission(s, services.PERM_ROLE_WRITE), handlers.AdminUpdateRole(s))
+		routerGroup.POST("/deleterole",
+			handlers.RequirePermission(s, services.PERM_ROLE_WRITE), handlers.AdminDeleteRole(s))
+		routerGroup.POST("/resetusertotp",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminResetUserTotp(s))
+		routerGroup.POST("/setuserroles",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminSetUserRoles(s))
+		routerGroup.GET("/getusersessions",
+			handlers.RequirePermission(s, services.PERM_USER_READ), handlers.AdminGetUserSessions(s))
+		routerGroup.POST("/terminateusersession",
+			handlers.RequirePermission(s, services.PERM_USER_WRITE), handlers.AdminTerminateUserSession(s))
+
+		routerGroup.POST("/addserviceaccount",
+			handlers.RequirePermission(s, services.PERM_API_KEY_WRITE), handlers.AdminAddServiceAccount(s))
+		routerGroup.POST("/addapikey",
+			handlers.RequirePermission(s, services.PERM_API_KEY_WRITE), handlers.AdminAddApiKey(s))
+		routerGroup.GET("/getapikeylist",
+			handlers.RequirePermission(s, services.PERM_API_KEY_READ), handlers.AdminGetApiKeyList(s))
+		routerGroup.POST("/revokeapikey",
+			handlers.RequirePermission(s, services.PERM_API_KEY_WRITE), handlers.AdminRevokeApiKey(s))
+
+		routerGroup.GET("/getloginlocklist",
+			handlers.RequirePermission(s, services.PERM_LOGIN_LOCK_READ), handlers.AdminGetLoginLockList(s))
+		routerGroup.POST("/clearloginlock",
+			handlers.RequirePermission(s, services.PERM_LOGIN_LOCK_WRITE), handlers.AdminClearLoginLock(s))
 	}
 }
 
@@ -58,22 +209,45 @@ func LoadExecRouter(s *services.Server) {
 	routerGroup := s.GetGinEngine().Group(ROUTERS_EXEC)
 	{
 
-		routerGroup.GET("/getexecresultlist", handlers.ExecGetExecResultList(s))
-
-		routerGroup.POST("/addsatellitestate", handlers.ExecAddSatelliteState(s))
-		routerGroup.GET("/getsatellitestatelist", handlers.ExecGetSatelliteStateList(s))
-
-		routerGroup.POST("/addcontrols", handlers.ExecAddControl(s))
-		routerGroup.GET("/getcontrolslist", handlers.ExecGetControlList(s))
-
-		routerGroup.POST("/addfault", handlers.ExecAddFault(s))
-		routerGroup.GET("/getfaultlist", handlers.ExecGetFaultList(s))
-
-		routerGroup.POST("/addnetstate", handlers.ExecAddNetState(s))
-		routerGroup.GET("/getnetstatelist", handlers.ExecGetNetStateList(s))
-
-		routerGroup.POST("/addcommstate", handlers.ExecAddCommState(s))
-		routerGroup.GET("/getcommstatelist", handlers.ExecGetCommStateList(s))
+		routerGroup.GET("/getexecresultlist",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_EXECUTE), handlers.ExecGetExecResultList(s))
+		routerGroup.POST("/claiminstruction",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_EXECUTE), handlers.ExecClaimInstruction(s))
+		routerGroup.POST("/reportinstructionprogress",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_EXECUTE), handlers.ExecReportInstructionProgress(s))
+		routerGroup.POST("/reportinstructionresult",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_EXECUTE), handlers.ExecReportInstructionResult(s))
+		routerGroup.GET("/getinstructionresults",
+			handlers.RequirePermission(s, services.PERM_INSTRUCTION_EXECUTE), handlers.ExecGetInstructionResults(s))
+
+		routerGroup.POST("/addsatellitestate",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_STATE_WRITE), handlers.ExecAddSatelliteState(s))
+		routerGroup.GET("/getsatellitestatelist",
+			handlers.RequirePermission(s, services.PERM_SATELLITE_STATE_READ), handlers.ExecGetSatelliteStateList(s))
+
+		routerGroup.POST("/addcontrols",
+			handlers.RequirePermission(s, services.PERM_CONTROLS_WRITE), handlers.ExecAddControl(s))
+		routerGroup.GET("/getcontrolslist",
+			handlers.RequirePermission(s, services.PERM_CONTROLS_READ), handlers.ExecGetControlList(s))
+
+		routerGroup.POST("/addfault",
+			handlers.RequirePermission(s, services.PERM_FAULT_WRITE), handlers.ExecAddFault(s))
+		routerGroup.GET("/getfaultlist",
+			handlers.RequirePermission(s, services.PERM_FAULT_READ), handlers.ExecGetFaultList(s))
+		routerGroup.POST("/transitfault",
+			handlers.RequirePermission(s, services.PERM_FAULT_WRITE), handlers.ExecTransitFault(s))
+		routerGroup.GET("/getfaulttransitions",
+			handlers.RequirePermission(s, services.PERM_FAULT_READ), handlers.ExecGetFaultTransitions(s))
+
+		routerGroup.POST("/addnetstate",
+			handlers.RequirePermission(s, services.PERM_NET_STATE_WRITE), handlers.ExecAddNetState(s))
+		routerGroup.GET("/getnetstatelist",
+			handlers.RequirePermission(s, services.PERM_NET_STATE_READ), handlers.ExecGetNetStateList(s))
+
+		routerGroup.POST("/addcommstate",
+			handlers.RequirePermission(s, services.PERM_COMM_STATE_WRITE), handlers.ExecAddCommState(s))
+		routerGroup.GET("/getcommstatelist",
+			handlers.RequirePermission(s, services.PERM_COMM_STATE_READ), handlers.ExecGetCommStateList(s))
 
 	}
 }
@@ -82,23 +256,34 @@ func LoadTraceRouter(s *services.Server) {
 	routerGroup := s.GetGinEngine().Group(ROUTERS_TRACE)
 	{
 
-		routerGroup.GET("/tracedebrislist", handlers.TraceGetDebrisList(s))
+		routerGroup.GET("/tracedebrislist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetDebrisList(s))
 
-		routerGroup.GET("/traceinstructionlist", handlers.TraceGetInstructionList(s))
+		routerGroup.GET("/traceinstructionlist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetInstructionList(s))
+		routerGroup.GET("/traceinstructionevents",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.GetInstructionEvents(s))
 
-		routerGroup.GET("/traceconstellationlist", handlers.TraceGetConstellationList(s))
+		routerGroup.GET("/traceconstellationlist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetConstellationList(s))
 
-		routerGroup.GET("/traceoperationlist", handlers.TraceGetOperationList(s))
+		routerGroup.GET("/traceoperationlist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetOperationList(s))
 
-		routerGroup.GET("/tracesatellitestatelist", handlers.TraceGetSatelliteStateList(s))
+		routerGroup.GET("/tracesatellitestatelist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetSatelliteStateList(s))
 
-		routerGroup.GET("/tracecontrolslist", handlers.TraceGetControlList(s))
+		routerGroup.GET("/tracecontrolslist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetControlList(s))
 
-		routerGroup.GET("/tracefaultlist", handlers.TraceGetFaultList(s))
+		routerGroup.GET("/tracefaultlist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetFaultList(s))
 
-		routerGroup.GET("/tracenetstatelist", handlers.TraceGetNetStateList(s))
+		routerGroup.GET("/tracenetstatelist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetNetStateList(s))
 
-		routerGroup.GET("/tracecommstatelist", handlers.TraceGetCommStateList(s))
+		routerGroup.GET("/tracecommstatelist",
+			handlers.RequirePermission(s, services.PERM_TRACE_READ), handlers.TraceGetCommStateList(s))
 	}
 }
 
@@ -114,3 +299,15 @@ func LoadMonitorRouter(s *services.Server) {
 		routerGroup.GET("/getserverlasttime", handlers.MonitorGetLastTime(s))
 	}
 }
+
+// LoadMonitorAuthRouter the monitor routes that expose the catalog or the fault details
+// are loaded after the auth middleware and check the permissions
+func LoadMonitorAuthRouter(s *services.Server) {
+	routerGroup := s.GetGinEngine().Group(ROUTERS_MONITOR)
+	{
+		routerGroup.GET("/getconstellationmembers",
+			handlers.RequirePermission(s, services.PERM_CONSTELLATION_READ), handlers.GetConstellationMembers(s))
+		routerGroup.GET("/getfaultstats",
+			handlers.RequirePermission(s, services.PERM_FAULT_READ), handlers.MonitorGetFaultStats(s))
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+	"strings"
+	"time"
+)
+
+const (
+	// API_KEY_HEADER 请求头中携带 API Key
+	API_KEY_HEADER = "X-Api-Key"
+
+	// API Key 格式为 sbk_前缀_密钥，前缀用于展示和吊销
+	API_KEY_PREFIX = "sbk_"
+
+	// 最近使用时间的更新间隔，避免每个请求都写库
+	API_KEY_LAST_USED_INTERVAL = time.Minute
+)
+
+// CreateApiKey generate a key for the service account, the plaintext is only returned here
+func (s *Server) CreateApiKey(user *db.User, keyName string, scopes []string,
+	expiresAt int64, createdBy string) (string, *db.ApiKey, error) {
+
+	if !user.ServiceAccount {
+		return "", nil, errors.New("api key can only be created for service accounts")
+	}
+
+	err := CheckPermissions(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	prefix, err := genRandomString(6)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := genRandomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 前缀中不能包含分隔符
+	prefix = API_KEY_PREFIX + strings.ReplaceAll(prefix, "_", "-")
+	key := prefix + "_" + secret
+
+	apiKey := &db.ApiKey{
+		UserId:    user.Id,
+		UserName:  user.UserName,
+		KeyName:   keyName,
+		KeyPrefix: prefix,
+		KeyHash:   hashToken(key),
+		Scopes:    JoinPermissions(scopes),
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+	}
+
+	err = s.InsertOneObjertToDB(apiKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, apiKey, nil
+}
+
+// AuthenticateApiKey check the key and return the claims of its service account
+func (s *Server) AuthenticateApiKey(key, ip string) (*MyClaims, error) {
+	if !strings.HasPrefix(key, API_KEY_PREFIX) {
+		return nil, errors.New("invalid api key")
+	}
+
+	apiKey := new(db.ApiKey)
+	err := s.gormDb.Where("key_hash = ?", hashToken(key)).First(apiKey).Error
+	if err != nil {
+		return nil, errors.New("invalid api key")
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != 0 {
+		return nil, errors.New("the api key has been revoked")
+	}
+	if apiKey.ExpiresAt != 0 && apiKey.ExpiresAt < now.Unix() {
+		return nil, errors.New("the api key has expired")
+	}
+
+	user := new(db.User)
+	err = s.QueryObjectById(user, apiKey.UserId)
+	if err != nil {
+		return nil, errors.New("the api key user not found")
+	}
+	if user.UserState != db.USER_ENABLED {
+		return nil, errors.New("the api key user is not enabled")
+	}
+
+	if apiKey.LastUsedAt < now.Add(-API_KEY_LAST_USED_INTERVAL).Unix() {
+		err = s.gormDb.Model(&db.ApiKey{}).
+			Where("id = ? AND last_used_at < ?", apiKey.Id,
+				now.Add(-API_KEY_LAST_USED_INTERVAL).Unix()).
+			Updates(map[string]interface{}{
+				"last_used_at": now.Unix(),
+				"last_used_ip": ip,
+			}).Error
+		if err != nil {
+			s.sulog.Infof("update api key last used failed, err: [%s], key: [%s]\n",
+				err.Error(), apiKey.KeyPrefix)
+		}
+	}
+
+	return &MyClaims{
+		Id:       user.Id,
+		Name:     user.UserName,
+		Role:     db.UserRoleTypeName[user.UserRole],
+		Purpose:  TOKEN_PURPOSE_API_KEY,
+		ApiKeyId: apiKey.Id,
+		Scopes:   SplitPermissions(apiKey.Scopes),
+	}, nil
+}
+
+// RevokeApiKey the key can not be used any more
+func (s *Server) RevokeApiKey(keyPrefix string) error {
+	result := s.gormDb.Model(&db.ApiKey{}).
+		Where("key_prefix = ? AND revoked_at = 0", keyPrefix).
+		Update("revoked_at", time.Now().Unix())
+	if result.Error != nil {
+		s.sulog.Infof("revoke api key failed, err: [%s], key: [%s]\n",
+			result.Error.Error(), keyPrefix)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("the api key not found or has been revoked")
+	}
+	return nil
+}
+
+// CheckClaimsPermission whether the claims hold any of the permissions,
+// the api key must also have the permission in its scopes
+func (s *Server) CheckClaimsPermission(claims *MyClaims, perms ...string) (bool, error) {
+	if claims.Purpose != TOKEN_PURPOSE_API_KEY {
+		return s.HasPermission(claims.Id, perms...)
+	}
+
+	scopes := make(map[string]bool)
+	for _, scope := range claims.Scopes {
+		scopes[scope] = true
+	}
+
+	for _, perm := range perms {
+		if !scopes[PERM_ALL] && !scopes[perm] {
+			continue
+		}
+
+		ok, err := s.HasPermission(claims.Id, perm)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
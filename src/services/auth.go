@@ -0,0 +1,288 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-web-demo/src/db"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// 过期吊销记录的清理间隔
+const TOKEN_CLEAN_INTERVAL = time.Hour
+
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  int64
+	RefreshToken     string
+	RefreshExpiresAt int64
+}
+
+// IssueTokens generate the access token and refresh token of the user session,
+// the session is created by StartSession and extended here
+func (s *Server) IssueTokens(user *db.User, sessionId string) (*TokenPair, error) {
+	var err error
+	now := time.Now()
+	pair := &TokenPair{
+		AccessExpiresAt:  now.Add(s.config.TokenConfig.AccessTokenExpire).Unix(),
+		RefreshExpiresAt: now.Add(s.config.TokenConfig.RefreshTokenExpire).Unix(),
+	}
+
+	pair.AccessToken, err = s.GenToken(&MyClaims{
+		Id:   user.Id,
+		Name: user.UserName,
+		Role: db.UserRoleTypeName[user.UserRole],
+		Ver:  user.TokenVersion,
+		Sid:  sessionId,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: pair.AccessExpiresAt,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pair.RefreshToken, err = genRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&db.RefreshToken{
+			UserId:    user.Id,
+			SessionId: sessionId,
+			TokenHash: hashToken(pair.RefreshToken),
+			ExpiresAt: pair.RefreshExpiresAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&db.UserSession{}).Where("session_id = ?", sessionId).
+			Updates(map[string]interface{}{
+				"last_active_at": now.Unix(),
+				"expires_at":     pair.RefreshExpiresAt,
+			}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("issue tokens failed, err: [%s], session: [%s]\n",
+			err.Error(), sessionId)
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// RefreshTokens rotate the refresh token, the old one can not be used again.
+// If a rotated refresh token is presented, the whole session is revoked
+func (s *Server) RefreshTokens(refreshToken string) (*db.User, *TokenPair, error) {
+	rt := new(db.RefreshToken)
+	err := s.QueryObjectByCondition(rt, "token_hash", hashToken(refreshToken))
+	if err != nil {
+		return nil, nil, errors.New("the refresh token not found")
+	}
+
+	now := time.Now().Unix()
+	if rt.RevokedAt != 0 {
+		s.sulog.Warnf("revoked refresh token reused, revoke the session [%s] of user [%d]\n",
+			rt.SessionId, rt.UserId)
+		if err := s.RevokeSession(rt.SessionId); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("the refresh token has been revoked")
+	}
+
+	if rt.ExpiresAt < now {
+		return nil, nil, errors.New("the refresh token has expired")
+	}
+
+	err = s.checkSession(rt.SessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 条件更新，保证并发刷新时只有一个请求能换到新 token
+	result := s.gormDb.Model(&db.RefreshToken{}).
+		Where("id = ? AND revoked_at = 0", rt.Id).Update("revoked_at", now)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, errors.New("the refresh token has been revoked")
+	}
+
+	user := new(db.User)
+	err = s.QueryObjectById(user, rt.UserId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user.UserState != db.USER_ENABLED {
+		return nil, nil, errors.New("the user is not enabled")
+	}
+
+	pair, err := s.IssueTokens(user, rt.SessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, pair, nil
+}
+
+// RevokeToken revoke the access token and the session it belongs to
+func (s *Server) RevokeToken(claims *MyClaims) error {
+	err := s.InsertOneObjertToDB(&db.RevokedToken{
+		Jti:       claims.StandardClaims.Id,
+		UserId:    claims.Id,
+		ExpiresAt: claims.StandardClaims.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(claims.Sid) == 0 {
+		return nil
+	}
+
+	return s.RevokeSession(claims.Sid)
+}
+
+// RevokeSession terminate the session and revoke all its refresh tokens,
+// the access tokens of the session are rejected by CheckTokenRevoked
+func (s *Server) RevokeSession(sessionId string) error {
+	now := time.Now().Unix()
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&db.UserSession{}).
+			Where("session_id = ? AND revoked_at = 0", sessionId).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&db.RefreshToken{}).
+			Where("session_id = ? AND revoked_at = 0", sessionId).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		s.sulog.Infof("revoke session failed, err: [%s], session: [%s]\n",
+			err.Error(), sessionId)
+		return err
+	}
+	return nil
+}
+
+// RevokeAllUserTokens invalidate all the access tokens and refresh tokens of the user,
+// used when the user is disabled or the password is changed
+func (s *Server) RevokeAllUserTokens(userId int32) error {
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		return revokeAllUserTokens(tx, userId, time.Now().Unix())
+	})
+	if err != nil {
+		s.sulog.Infof("revoke all user tokens failed, err: [%s], user: [%d]\n",
+			err.Error(), userId)
+		return err
+	}
+	return nil
+}
+
+// revokeAllUserTokens bump the token version and revoke the sessions and the refresh tokens
+// in the transaction
+func revokeAllUserTokens(tx *gorm.DB, userId int32, now int64) error {
+	err := tx.Model(&db.User{}).Where("id = ?", userId).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&db.UserSession{}).
+		Where("user_id = ? AND revoked_at = 0", userId).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&db.RefreshToken{}).
+		Where("user_id = ? AND revoked_at = 0", userId).
+		Update("revoked_at", now).Error
+}
+
+// CheckTokenRevoked check the token against the revocation list, the user's token
+// version and the state of its session
+func (s *Server) CheckTokenRevoked(claims *MyClaims) error {
+	var count int64
+	err := s.gormDb.Model(&db.RevokedToken{}).
+		Where("jti = ?", claims.StandardClaims.Id).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return errors.New("the token has been revoked")
+	}
+
+	user := new(db.User)
+	err = s.gormDb.Model(user).Select("id", "token_version").
+		Where("id = ?", claims.Id).First(user).Error
+	if err != nil {
+		return errors.New("the token user not found")
+	}
+
+	if user.TokenVersion != claims.Ver {
+		return errors.New("the token has been revoked")
+	}
+
+	// 登录两步验证等中间 token 不属于任何会话
+	if len(claims.Sid) == 0 {
+		return nil
+	}
+
+	return s.checkSession(claims.Sid)
+}
+
+// StartTokenCleaner delete the expired refresh tokens, revocation records, sessions
+// and password reset tokens periodically
+func (s *Server) StartTokenCleaner() {
+	go func() {
+		ticker := time.NewTicker(TOKEN_CLEAN_INTERVAL)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now().Unix()
+			err := s.gormDb.Where("expires_at < ?", now).Delete(&db.RevokedToken{}).Error
+			if err != nil {
+				s.sulog.Infof("clean revoked tokens failed, err: [%s]\n", err.Error())
+			}
+
+			err = s.gormDb.Where("expires_at < ?", now).Delete(&db.RefreshToken{}).Error
+			if err != nil {
+				s.sulog.Infof("clean refresh tokens failed, err: [%s]\n", err.Error())
+			}
+
+			err = s.gormDb.Where("expires_at < ?", now).Delete(&db.UserSession{}).Error
+			if err != nil {
+				s.sulog.Infof("clean sessions failed, err: [%s]\n", err.Error())
+			}
+
+			err = s.gormDb.Where("expires_at < ?", now).Delete(&db.PwdResetToken{}).Error
+			if err != nil {
+				s.sulog.Infof("clean password reset tokens failed, err: [%s]\n", err.Error())
+			}
+		}
+	}()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func genRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
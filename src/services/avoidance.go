@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-web-demo/src/db"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DEFAULT_AVOIDANCE_TEMPLATE 策略没有配置模板时的指令内容，
+// 模板中可以使用 {satelliteId} {satelliteName} {debrisId} {debrisName} {tca} {missDistanceKm}
+const DEFAULT_AVOIDANCE_TEMPLATE = "规避碎片 {debrisName}（{debrisId}），" +
+	"最近接近时刻 {tca}，最近距离 {missDistanceKm} 千米"
+
+// RenderAvoidanceContent fill the template with the conjunction
+func RenderAvoidanceContent(template string, conjunction *db.Conjunction) string {
+	if len(strings.TrimSpace(template)) == 0 {
+		template = DEFAULT_AVOIDANCE_TEMPLATE
+	}
+
+	return strings.NewReplacer(
+		"{satelliteId}", conjunction.SatelliteId,
+		"{satelliteName}", conjunction.SatelliteName,
+		"{debrisId}", conjunction.DebrisId,
+		"{debrisName}", conjunction.DebrisName,
+		"{tca}", time.Unix(conjunction.Tca, 0).Format("2006-01-02 15:04:05"),
+		"{missDistanceKm}", strconv.FormatFloat(conjunction.MissDistanceKm, 'f', 3, 64),
+	).Replace(template)
+}
+
+// CheckAvoidancePolicyScope the policy works on either a satellite or a constellation,
+// and each of them has one policy at most
+func (s *Server) CheckAvoidancePolicyScope(policy *db.AvoidancePolicy) error {
+	if (len(policy.SatelliteId) == 0) == (len(policy.ConstellationId) == 0) {
+		return errors.New("the policy must work on either a satellite or a constellation")
+	}
+
+	column, value := "satellite_id", policy.SatelliteId
+	if len(policy.SatelliteId) == 0 {
+		column, value = "constellation_id", policy.ConstellationId
+		err := s.QueryObjectByCondition(new(db.Constellation), "constellation_id", value)
+		if err != nil {
+			return errors.New("the constellation does not exist: " + value)
+		}
+	} else {
+		_, err := s.GetCatalogSatellite(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	var count int64
+	err := s.gormDb.Model(&db.AvoidancePolicy{}).
+		Where(column+" = ? AND policy_name <> ?", value, policy.PolicyName).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return errors.New("another policy already works on " + value)
+	}
+	return nil
+}
+
+// MatchAvoidancePolicy the enabled policy of the satellite, or of its constellation,
+// nil if there is none
+func (s *Server) MatchAvoidancePolicy(satellite *db.SatelliteCatalog) (*db.AvoidancePolicy, error) {
+	policies := make([]*db.AvoidancePolicy, 0)
+	query := s.gormDb.Where("satellite_id = ?", satellite.SatelliteId)
+	if len(satellite.ConstellationId) != 0 {
+		query = query.Or("constellation_id = ?", satellite.ConstellationId)
+	}
+	err := s.gormDb.Where("enabled = ?", true).Where(query).Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var matched *db.AvoidancePolicy
+	for _, policy := range policies {
+		if policy.SatelliteId == satellite.SatelliteId {
+			return policy, nil
+		}
+		matched = policy
+	}
+	return matched, nil
+}
+
+// generateAvoidanceInstructions create the automatic instruction for each high threat
+// conjunction by the policy of the satellite, once for the same conjunction event
+func (s *Server) generateAvoidanceInstructions(conjunctions []*db.Conjunction) {
+	tolerance := int64(s.config.ScreenConf.EventTolerance.Seconds())
+
+	for _, conjunction := range conjunctions {
+		if conjunction.Treaten != db.HIGH {
+			continue
+		}
+
+		instruction, err := s.generateAvoidanceInstruction(conjunction, tolerance)
+		if err != nil {
+			s.sulog.Infof("generate avoidance instruction failed, err:[%s], satellite:[%s], debris:[%s]\n",
+				err.Error(), conjunction.SatelliteId, conjunction.DebrisId)
+			continue
+		}
+		if instruction != nil {
+			s.sulog.Infof("avoidance instruction generated, instruction:[%s]\n", instruction.InstructionId)
+		}
+	}
+}
+
+func (s *Server) generateAvoidanceInstruction(conjunction *db.Conjunction,
+	tolerance int64) (*db.Instruction, error) {
+
+	var count int64
+	err := s.gormDb.Model(&db.Instruction{}).
+		Where("satellite_id = ? AND debris_id = ? AND type = ? AND conjunction_tca BETWEEN ? AND ?",
+			conjunction.SatelliteId, conjunction.DebrisId, db.AUTOMATIC,
+			conjunction.Tca-tolerance, conjunction.Tca+tolerance).Count(&count).Error
+	if err != nil || count != 0 {
+		return nil, err
+	}
+
+	satellite, err := s.GetCatalogSatellite(conjunction.SatelliteId)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.MatchAvoidancePolicy(satellite)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+
+	instruction := &db.Instruction{
+		InstructionId: fmt.Sprintf("AUTO-%s-%s-%d",
+			conjunction.SatelliteId, conjunction.DebrisId, conjunction.Tca),
+		Type:               db.AUTOMATIC,
+		InstructionContent: RenderAvoidanceContent(policy.ContentTemplate, conjunction),
+		InstructionSource:  policy.Source,
+		GenInstructionTime: time.Now().Unix(),
+		DebrisId:           conjunction.DebrisId,
+		DebrisName:         conjunction.DebrisName,
+		Treaten:            conjunction.Treaten,
+		SatelliteId:        satellite.SatelliteId,
+		SatelliteName:      satellite.SatelliteName,
+		// 最近接近之后规避已没有意义
+		ScheduleWindowEnd: conjunction.Tca,
+		ConjunctionTca:    conjunction.Tca,
+	}
+	if policy.Mode == db.AVOIDANCE_RECOMMEND {
+		instruction.ExecState = db.WAITAPPROVAL
+	}
+
+	err = s.CreateInstruction(instruction, policy.Source, 0)
+	if err != nil {
+		return nil, err
+	}
+	return instruction, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderAvoidanceContent(t *testing.T) {
+	conjunction := &db.Conjunction{
+		SatelliteId:    "s1",
+		DebrisId:       "d1",
+		DebrisName:     "debris1",
+		MissDistanceKm: 0.5,
+	}
+
+	content := RenderAvoidanceContent("{satelliteId} avoid {debrisId} {missDistanceKm}", conjunction)
+	require.Equal(t, "s1 avoid d1 0.500", content)
+	require.Contains(t, RenderAvoidanceContent("", conjunction), "debris1（d1）")
+}
+
+func TestGenerateAvoidanceInstructionsSqlite(t *testing.T) {
+	s := newScreeningTestServer(t)
+	s.config.ScreenConf.EventTolerance = 10 * time.Minute
+	s.config.ExecConfig = &configs.ExecutorConfig{Timeout: time.Second, MaxAttempts: 1}
+
+	require.Nil(t, s.InsertOneObjertToDB(&db.Constellation{ConstellationId: "c1"}))
+	seedConjunction(t, s, "c1")
+
+	recommend := &db.AvoidancePolicy{
+		PolicyName:      "p1",
+		ConstellationId: "c1",
+		Mode:            db.AVOIDANCE_RECOMMEND,
+		Source:          "policy-c1",
+		ContentTemplate: "avoid {debrisId}",
+		Enabled:         true,
+	}
+	require.Nil(t, s.CheckAvoidancePolicyScope(recommend))
+	require.Nil(t, s.InsertOneObjertToDB(recommend))
+
+	conjunctions, err := s.ScreenConjunctions()
+	require.Nil(t, err)
+	require.Len(t, conjunctions, 1)
+	tca := conjunctions[0].Tca
+
+	instructions := make([]*db.Instruction, 0)
+	require.Nil(t, s.gormDb.Where("type = ?", db.AUTOMATIC).Find(&instructions).Error)
+	require.Len(t, instructions, 1)
+	require.Equal(t, db.WAITAPPROVAL, instructions[0].ExecState)
+	require.Equal(t, "policy-c1", instructions[0].InstructionSource)
+	require.Equal(t, "avoid d1", instructions[0].InstructionContent)
+	require.Equal(t, "debris1", instructions[0].DebrisName)
+	require.Equal(t, db.HIGH, instructions[0].Treaten)
+	require.Equal(t, tca, instructions[0].ScheduleWindowEnd)
+
+	task := new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(task, "instruction_id", instructions[0].InstructionId))
+	require.Equal(t, db.TASK_HELD, task.TaskState)
+
+	// 同一接近事件的最近接近时刻略有变化时不会重复生成
+	conjunctions[0].Tca = tca + 60
+	s.generateAvoidanceInstructions(conjunctions)
+	var count int64
+	require.Nil(t, s.gormDb.Model(&db.Instruction{}).Where("type = ?", db.AUTOMATIC).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+
+	// 卫星的策略优先于星座的策略，每颗卫星只能有一个策略
+	auto := &db.AvoidancePolicy{
+		PolicyName:  "p2",
+		SatelliteId: "s1",
+		Mode:        db.AVOIDANCE_AUTO,
+		Source:      "policy-s1",
+		Enabled:     true,
+	}
+	require.Nil(t, s.CheckAvoidancePolicyScope(auto))
+	require.Nil(t, s.InsertOneObjertToDB(auto))
+	require.NotNil(t, s.CheckAvoidancePolicyScope(&db.AvoidancePolicy{PolicyName: "p3", SatelliteId: "s1"}))
+	require.NotNil(t, s.CheckAvoidancePolicyScope(&db.AvoidancePolicy{PolicyName: "p3"}))
+
+	conjunctions[0].Tca = tca + 3600
+	s.generateAvoidanceInstructions(conjunctions)
+
+	instructions = make([]*db.Instruction, 0)
+	require.Nil(t, s.gormDb.Where("type = ? AND conjunction_tca = ?", db.AUTOMATIC, tca+3600).
+		Find(&instructions).Error)
+	require.Len(t, instructions, 1)
+	require.Equal(t, db.NOTEXEC, instructions[0].ExecState)
+	require.Equal(t, "policy-s1", instructions[0].InstructionSource)
+
+	// 停用的策略不再生成指令
+	require.Nil(t, s.gormDb.Model(&db.AvoidancePolicy{}).Where("1 = 1").Update("enabled", false).Error)
+	conjunctions[0].Tca = tca + 7200
+	s.generateAvoidanceInstructions(conjunctions)
+	require.Nil(t, s.gormDb.Model(&db.Instruction{}).Where("type = ?", db.AUTOMATIC).Count(&count).Error)
+	require.Equal(t, int64(2), count)
+}
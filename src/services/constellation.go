@@ -0,0 +1,127 @@
+package services
+
+import (
+	"go-web-demo/src/db"
+
+	"gorm.io/gorm"
+)
+
+// ConstellationHealth 由成员卫星最新上报的在轨状态和网络状态计算，
+// 没有上报过在轨状态的成员既不计入在轨也不计入故障
+type ConstellationHealth struct {
+	SatelliteTotalNum  int32
+	SatelliteUpNum     int32
+	SatelliteDownNum   int32
+	SatelliteLinkState db.State
+}
+
+// ConstellationMember the satellite in the catalog and its latest reported states,
+// the states are nil if never reported
+type ConstellationMember struct {
+	Satellite *db.SatelliteCatalog
+	RunState  *db.Satellite
+	NetState  *db.NetState
+}
+
+// GetConstellationMembers the members of the constellations in the catalog, keyed by the constellation id
+func (s *Server) GetConstellationMembers(constellationIds []string) (map[string][]*ConstellationMember, error) {
+	members := make(map[string][]*ConstellationMember)
+	if len(constellationIds) == 0 {
+		return members, nil
+	}
+
+	satellites := make([]*db.SatelliteCatalog, 0)
+	err := s.gormDb.Model(&db.SatelliteCatalog{}).Where("constellation_id IN ?", constellationIds).
+		Order("satellite_id").Find(&satellites).Error
+	if err != nil {
+		s.sulog.Infof("query constellation members failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+
+	if len(satellites) == 0 {
+		return members, nil
+	}
+
+	satelliteIds := make([]string, 0, len(satellites))
+	for _, satellite := range satellites {
+		satelliteIds = append(satelliteIds, satellite.SatelliteId)
+	}
+
+	runStates := make([]*db.Satellite, 0)
+	err = s.latestBySatellite(&db.Satellite{}, satelliteIds).Find(&runStates).Error
+	if err != nil {
+		s.sulog.Infof("query latest run states failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+
+	netStates := make([]*db.NetState, 0)
+	err = s.latestBySatellite(&db.NetState{}, satelliteIds).Find(&netStates).Error
+	if err != nil {
+		s.sulog.Infof("query latest net states failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+
+	runStateMap := make(map[string]*db.Satellite)
+	for _, state := range runStates {
+		runStateMap[state.SatelliteId] = state
+	}
+
+	netStateMap := make(map[string]*db.NetState)
+	for _, state := range netStates {
+		netStateMap[state.SatelliteId] = state
+	}
+
+	for _, satellite := range satellites {
+		members[satellite.ConstellationId] = append(members[satellite.ConstellationId],
+			&ConstellationMember{
+				Satellite: satellite,
+				RunState:  runStateMap[satellite.SatelliteId],
+				NetState:  netStateMap[satellite.SatelliteId],
+			})
+	}
+
+	return members, nil
+}
+
+// GetConstellationHealth the health of every given constellation, a constellation without
+// members is normal
+func (s *Server) GetConstellationHealth(constellationIds []string) (map[string]*ConstellationHealth, error) {
+	members, err := s.GetConstellationMembers(constellationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	healths := make(map[string]*ConstellationHealth)
+	for _, id := range constellationIds {
+		health := &ConstellationHealth{SatelliteLinkState: db.NORMAL}
+		for _, member := range members[id] {
+			health.SatelliteTotalNum++
+
+			if member.RunState != nil {
+				switch member.RunState.RunState {
+				case db.NORMAL:
+					health.SatelliteUpNum++
+				case db.WRONG:
+					health.SatelliteDownNum++
+				}
+			}
+
+			if member.NetState != nil && member.NetState.NetworkState == db.WRONG {
+				health.SatelliteLinkState = db.WRONG
+			}
+		}
+		healths[id] = health
+	}
+
+	return healths, nil
+}
+
+// latestBySatellite the latest reported records of the satellites
+func (s *Server) latestBySatellite(model db.ModelStruct, satelliteIds []string) *gorm.DB {
+	table := model.TableName()
+	groupSub := s.gormDb.Model(model).Select("satellite_id, MAX(last_time) as latest_time").
+		Where("satellite_id IN ?", satelliteIds).Group("satellite_id")
+
+	return s.gormDb.Model(model).Joins("inner join (?) as t2 on t2.satellite_id = "+table+
+		".satellite_id AND t2.latest_time = "+table+".last_time", groupSub)
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"go-web-demo/src/db"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConstellationHealthSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	for _, id := range []string{"s1", "s2", "s3"} {
+		require.Nil(t, s.InsertOneObjertToDB(&db.SatelliteCatalog{
+			SatelliteId:     id,
+			OrbitId:         "o1",
+			ConstellationId: "c1",
+		}))
+	}
+
+	// s1 最新状态恢复正常，s2 最新状态异常，s3 从未上报
+	states := []*db.Satellite{
+		{GeneralField: db.GeneralField{LastTime: 1}, SatelliteId: "s1", RunState: db.WRONG},
+		{GeneralField: db.GeneralField{LastTime: 2}, SatelliteId: "s1", RunState: db.NORMAL},
+		{GeneralField: db.GeneralField{LastTime: 3}, SatelliteId: "s2", RunState: db.WRONG},
+	}
+	for _, state := range states {
+		require.Nil(t, s.InsertOneObjertToDB(state))
+	}
+
+	require.Nil(t, s.InsertOneObjertToDB(&db.NetState{
+		GeneralField: db.GeneralField{LastTime: 1},
+		SatelliteId:  "s2",
+		NetworkState: db.NORMAL,
+	}))
+
+	healths, err := s.GetConstellationHealth([]string{"c1", "c2"})
+	require.Nil(t, err)
+	require.Equal(t, &ConstellationHealth{
+		SatelliteTotalNum:  3,
+		SatelliteUpNum:     1,
+		SatelliteDownNum:   1,
+		SatelliteLinkState: db.NORMAL,
+	}, healths["c1"])
+	require.Equal(t, &ConstellationHealth{SatelliteLinkState: db.NORMAL}, healths["c2"])
+
+	require.Nil(t, s.InsertOneObjertToDB(&db.NetState{
+		GeneralField: db.GeneralField{LastTime: 2},
+		SatelliteId:  "s2",
+		NetworkState: db.WRONG,
+	}))
+
+	members, err := s.GetConstellationMembers([]string{"c1"})
+	require.Nil(t, err)
+	require.Len(t, members["c1"], 3)
+	require.Nil(t, members["c1"][2].RunState)
+	require.Equal(t, db.WRONG, members["c1"][1].NetState.NetworkState)
+
+	healths, err = s.GetConstellationHealth([]string{"c1"})
+	require.Nil(t, err)
+	require.Equal(t, db.WRONG, healths["c1"].SatelliteLinkState)
+}
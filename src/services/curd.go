@@ -46,6 +46,10 @@ type QueryLatestObjectsParams struct {
 	SearchIndex []string
 	SearchInput string
 	GroupIndex  string
+	// QueryMap 精确查询条件，在取每组最新的一条之前过滤
+	QueryMap map[string]string
+	// Unscoped 包含已删除的记录，追溯历史时使用
+	Unscoped bool
 }
 
 func (s *Server) GetGormObject() *gorm.DB {
@@ -81,15 +85,17 @@ func (s *Server) QueryObjectsWithPage(params *QueryObjectsParams) (sqlRow *sql.R
 		totalSub := s.gormDb.Model(params.ModelStruct).Select("id")
 
 		if len(params.SearchIndex) != 0 && len(params.SearchInput) != 0 {
+			// 搜索条件整体加括号，避免 OR 吞掉后面的精确查询条件
+			searchCond := s.gormDb
 			for i, v := range params.SearchIndex {
 				if i == 0 {
-					querySub = querySub.Where(v+" LIKE ?", "%"+params.SearchInput+"%")
-					totalSub = totalSub.Where(v+" LIKE ?", "%"+params.SearchInput+"%")
+					searchCond = searchCond.Where(v+" LIKE ?", "%"+params.SearchInput+"%")
 					continue
 				}
-				querySub = querySub.Or(v+" LIKE ?", "%"+params.SearchInput+"%")
-				totalSub = totalSub.Or(v+" LIKE ?", "%"+params.SearchInput+"%")
+				searchCond = searchCond.Or(v+" LIKE ?", "%"+params.SearchInput+"%")
 			}
+			querySub = querySub.Where(searchCond)
+			totalSub = totalSub.Where(searchCond)
 		}
 
 		if len(params.QueryMap) != 0 {
@@ -173,41 +179,58 @@ func (s *Server) QueryObjectsByCondition(modelStruct db.ModelStruct,
 
 func (s *Server) QueryLatestObjectsWithPage(params *QueryLatestObjectsParams) (
 	sqlRow *sql.Rows, total int64, err error) {
+	gormDb := s.gormDb
+	if params.Unscoped {
+		// 新的会话，之后的每个查询各自复制条件
+		gormDb = gormDb.Unscoped().Session(&gorm.Session{})
+	}
+
 	switch params.SortType {
 	case SORTTYPE_TIME:
 		offset := (params.Page - 1) * params.PageSize
 
-		groupSub := s.gormDb.Model(params.ModelStruct).Select(params.GroupIndex + ",MAX(last_time) as latest_time").
+		groupSub := gormDb.Model(params.ModelStruct).Select(params.GroupIndex + ",MAX(last_time) as latest_time").
 			Group(params.GroupIndex)
+		for k, v := range params.QueryMap {
+			groupSub = groupSub.Where(k+" = ?", v)
+		}
 
-		querySub := s.gormDb.Model(params.ModelStruct).Select("id").Order("last_time desc").
+		querySub := gormDb.Model(params.ModelStruct).Select("id").Order("last_time desc").
 			Joins("inner join (?) as t2 on t2."+params.GroupIndex+
 				" = "+params.ModelStruct.TableName()+"."+params.GroupIndex+
 				" AND t2.latest_time = "+params.ModelStruct.TableName()+".last_time",
 				groupSub).Limit(int(params.PageSize)).Offset(int(offset))
 
-		totalSub := s.gormDb.Model(params.ModelStruct).Select("id").
+		totalSub := gormDb.Model(params.ModelStruct).Select("id").
 			Joins("inner join (?) as t2 on t2."+params.GroupIndex+
 				" = "+params.ModelStruct.TableName()+"."+params.GroupIndex+
 				" AND t2.latest_time = "+params.ModelStruct.TableName()+".last_time",
 				groupSub)
 
-		querySub = s.gormDb.Model(params.ModelStruct).Order("last_time desc").
+		querySub = gormDb.Model(params.ModelStruct).Order("last_time desc").
 			Joins("inner join (?) as t2 using(id)", querySub)
 
-		totalSub = s.gormDb.Model(params.ModelStruct).Select("id").
+		totalSub = gormDb.Model(params.ModelStruct).Select("id").
 			Joins("inner join (?) as t2 using(id)", totalSub)
 
+		// 同一时间的其他记录不能混入
+		for k, v := range params.QueryMap {
+			querySub = querySub.Where(k+" = ?", v)
+			totalSub = totalSub.Where(k+" = ?", v)
+		}
+
 		if len(params.SearchIndex) != 0 && len(params.SearchInput) != 0 {
+			// 搜索条件整体加括号，避免 OR 吞掉后面的精确查询条件
+			searchCond := gormDb
 			for i, v := range params.SearchIndex {
 				if i == 0 {
-					querySub = querySub.Where(v+" LIKE ?", "%"+params.SearchInput+"%")
-					totalSub = totalSub.Where(v+" LIKE ?", "%"+params.SearchInput+"%")
+					searchCond = searchCond.Where(v+" LIKE ?", "%"+params.SearchInput+"%")
 					continue
 				}
-				querySub = querySub.Or(v+" LIKE ?", "%"+params.SearchInput+"%")
-				totalSub = totalSub.Or(v+" LIKE ?", "%"+params.SearchInput+"%")
+				searchCond = searchCond.Or(v+" LIKE ?", "%"+params.SearchInput+"%")
 			}
+			querySub = querySub.Where(searchCond)
+			totalSub = totalSub.Where(searchCond)
 		}
 
 		err = totalSub.Count(&total).Error
@@ -230,6 +253,7 @@ func (s *Server) QueryLatestObjectsWithPage(params *QueryLatestObjectsParams) (
 	}
 }
 
+// UpdateObject update the non-zero fields of the object by its id
 func (s *Server) UpdateObject(modelStruc db.ModelStruct) error {
 	if err := s.gormDb.Model(modelStruc).Updates(modelStruc).Error; err != nil {
 		s.sulog.Infof("updates object failed, err:[%s]\n",
@@ -239,6 +263,27 @@ func (s *Server) UpdateObject(modelStruc db.ModelStruct) error {
 	return nil
 }
 
+// ReplaceObject save all the fields of the object by its id, including the zero values,
+// the object must be queried from the database first
+func (s *Server) ReplaceObject(modelStruc db.ModelStruct) error {
+	if err := s.gormDb.Model(modelStruc).Select("*").Updates(modelStruc).Error; err != nil {
+		s.sulog.Infof("replace object failed, err:[%s]\n",
+			err.Error())
+		return err
+	}
+	return nil
+}
+
+// DeleteObject delete the object by its id, it is a soft delete if the model has DeletedAt
+func (s *Server) DeleteObject(modelStruct db.ModelStruct) error {
+	if err := s.gormDb.Delete(modelStruct).Error; err != nil {
+		s.sulog.Infof("delete object failed, err:[%s], object:[%+v]\n",
+			err.Error(), modelStruct)
+		return err
+	}
+	return nil
+}
+
 func (s *Server) QueryOneObjectWithLatest(modelStruct db.ModelStruct,
 	groupIndex string) (*sql.Rows, error) {
 
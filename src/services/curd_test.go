@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"go-web-demo/src/db"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newSqliteTestServer(t *testing.T) *Server {
+	logger := zap.NewNop().Sugar()
+	gormDb, err := db.GormInit(&db.DBConfig{
+		Driver: db.DB_DRIVER_SQLITE,
+		DbName: db.SQLITE_MEMORY,
+	}, logger)
+	require.Nil(t, err)
+	require.Nil(t, db.MigrateUp(gormDb, 0))
+
+	s, err := NewServer(WithGormDb(gormDb), WithSuLog(logger))
+	require.Nil(t, err)
+	return s
+}
+
+func TestQueryObjectsWithPageSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	for i, name := range []string{"a1", "a2", "b1", "a3"} {
+		err := s.InsertOneObjertToDB(&db.Debris{
+			GeneralField: db.GeneralField{LastTime: int64(i + 1)},
+			DebrisId:     name,
+			DebrisName:   name,
+			Type:         db.SMALL,
+		})
+		require.Nil(t, err)
+	}
+
+	sqlRows, total, err := s.QueryObjectsWithPage(&QueryObjectsParams{
+		ModelStruct: new(db.Debris),
+		Page:        1,
+		PageSize:    2,
+		SortType:    SORTTYPE_TIME,
+		SearchIndex: []string{"debris_id", "debris_name"},
+		SearchInput: "a",
+		QueryMap:    map[string]string{"type": "1"},
+	})
+	require.Nil(t, err)
+	defer sqlRows.Close()
+
+	names := make([]string, 0)
+	for sqlRows.Next() {
+		var debris db.Debris
+		require.Nil(t, s.ScanRows(sqlRows, &debris))
+		names = append(names, debris.DebrisName)
+	}
+	require.Equal(t, int64(3), total)
+	require.Equal(t, []string{"a3", "a2"}, names)
+}
+
+func TestQueryLatestObjectsWithPageSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	records := []struct {
+		name     string
+		lastTime int64
+		speed    float64
+	}{
+		{"a", 1, 1}, {"b", 2, 2}, {"a", 3, 3}, {"c", 4, 4}, {"b", 5, 5},
+	}
+	for i, r := range records {
+		err := s.InsertOneObjertToDB(&db.Debris{
+			GeneralField: db.GeneralField{LastTime: r.lastTime},
+			DebrisId:     fmt.Sprint(i),
+			DebrisName:   r.name,
+			Speed:        r.speed,
+		})
+		require.Nil(t, err)
+	}
+
+	sqlRows, total, err := s.QueryLatestObjectsWithPage(&QueryLatestObjectsParams{
+		ModelStruct: new(db.Debris),
+		Page:        1,
+		PageSize:    10,
+		SortType:    SORTTYPE_TIME,
+		GroupIndex:  "debris_name",
+	})
+	require.Nil(t, err)
+	defer sqlRows.Close()
+
+	speeds := make(map[string]float64)
+	order := make([]string, 0)
+	for sqlRows.Next() {
+		var debris db.Debris
+		require.Nil(t, s.ScanRows(sqlRows, &debris))
+		speeds[debris.DebrisName] = debris.Speed
+		order = append(order, debris.DebrisName)
+	}
+	require.Equal(t, int64(3), total)
+	require.Equal(t, []string{"b", "c", "a"}, order)
+	require.Equal(t, map[string]float64{"a": 3, "b": 5, "c": 4}, speeds)
+}
+
+func TestQueryLatestObjectsWithQueryMapSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	records := []struct {
+		satelliteId string
+		lastTime    int64
+		state       db.FaultState
+	}{
+		{"s1", 1, db.FAULT_OPEN}, {"s1", 2, db.FAULT_RESOLVED}, {"s2", 3, db.FAULT_OPEN}, {"s3", 4, db.FAULT_CLOSED},
+	}
+	for _, r := range records {
+		err := s.InsertOneObjertToDB(&db.Fault{
+			GeneralField: db.GeneralField{LastTime: r.lastTime},
+			SatelliteId:  r.satelliteId,
+			FaultState:   r.state,
+		})
+		require.Nil(t, err)
+	}
+
+	sqlRows, total, err := s.QueryLatestObjectsWithPage(&QueryLatestObjectsParams{
+		ModelStruct: new(db.Fault),
+		Page:        1,
+		PageSize:    10,
+		SortType:    SORTTYPE_TIME,
+		GroupIndex:  "satellite_id",
+		QueryMap:    map[string]string{"fault_state": strconv.Itoa(int(db.FAULT_OPEN))},
+	})
+	require.Nil(t, err)
+	defer sqlRows.Close()
+
+	times := make(map[string]int64)
+	for sqlRows.Next() {
+		var fault db.Fault
+		require.Nil(t, s.ScanRows(sqlRows, &fault))
+		require.Equal(t, db.FAULT_OPEN, fault.FaultState)
+		times[fault.SatelliteId] = fault.LastTime
+	}
+	require.Equal(t, int64(2), total)
+	require.Equal(t, map[string]int64{"s1": 1, "s2": 3}, times)
+}
+
+func TestQueryLatestObjectsUnscopedSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	for _, id := range []string{"d1", "d2"} {
+		require.Nil(t, s.InsertOneObjertToDB(&db.Debris{DebrisId: id, DebrisName: id}))
+	}
+	deleted := new(db.Debris)
+	require.Nil(t, s.QueryObjectByCondition(deleted, "debris_id", "d1"))
+	require.Nil(t, s.DeleteObject(deleted))
+
+	queryIds := func(unscoped bool) ([]string, int64) {
+		sqlRows, total, err := s.QueryLatestObjectsWithPage(&QueryLatestObjectsParams{
+			ModelStruct: new(db.Debris),
+			Page:        1,
+			PageSize:    10,
+			SortType:    SORTTYPE_TIME,
+			GroupIndex:  "debris_id",
+			Unscoped:    unscoped,
+		})
+		require.Nil(t, err)
+		defer sqlRows.Close()
+
+		ids := make([]string, 0)
+		for sqlRows.Next() {
+			var debris db.Debris
+			require.Nil(t, s.ScanRows(sqlRows, &debris))
+			ids = append(ids, debris.DebrisId)
+		}
+		return ids, total
+	}
+
+	ids, total := queryIds(false)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, []string{"d2"}, ids)
+
+	// 追溯时已删除的碎片仍然可见
+	ids, total = queryIds(true)
+	require.Equal(t, int64(2), total)
+	require.ElementsMatch(t, []string{"d1", "d2"}, ids)
+}
+
+func TestUpdateAndDeleteObjectSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	debris := &db.Debris{DebrisId: "d1", DebrisName: "d1", Speed: 10}
+	require.Nil(t, s.InsertOneObjertToDB(debris))
+
+	// 业务 id 唯一
+	require.NotNil(t, s.InsertOneObjertToDB(&db.Debris{DebrisId: "d1"}))
+
+	// 零值也会被更新
+	debris.Speed = 0
+	require.Nil(t, s.ReplaceObject(debris))
+	updated := new(db.Debris)
+	require.Nil(t, s.QueryObjectByCondition(updated, "debris_id", "d1"))
+	require.Equal(t, float64(0), updated.Speed)
+
+	require.Nil(t, s.DeleteObject(updated))
+	require.NotNil(t, s.QueryObjectByCondition(new(db.Debris), "debris_id", "d1"))
+
+	var count int64
+	require.Nil(t, s.GetTableDataCount(&db.Debris{}, &count))
+	require.Equal(t, int64(0), count)
+
+	// 删除后可以重新添加
+	require.Nil(t, s.InsertOneObjertToDB(&db.Debris{DebrisId: "d1"}))
+}
+
+// 只更新部分字段时其余字段保持不变
+func TestUpdateObjectPartialSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	user := &db.User{
+		UserName:  "u1",
+		UserRole:  db.CONTROL,
+		UserPwd:   "plain",
+		UserEmail: "u1@example.com",
+		UserState: db.USER_ENABLED,
+	}
+	require.Nil(t, s.InsertOneObjertToDB(user))
+
+	require.Nil(t, s.UpdateObject(&db.User{
+		GeneralField: db.GeneralField{Id: user.Id},
+		UserPwd:      "hashed",
+	}))
+
+	got := new(db.User)
+	require.Nil(t, s.QueryObjectById(got, user.Id))
+	require.Equal(t, "hashed", got.UserPwd)
+	require.Equal(t, "u1", got.UserName)
+	require.Equal(t, db.CONTROL, got.UserRole)
+	require.Equal(t, "u1@example.com", got.UserEmail)
+	require.Equal(t, db.USER_ENABLED, got.UserState)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// faultTransitions 允许的故障状态变化，已修复的故障可以重新进入维修
+var faultTransitions = map[db.FaultState][]db.FaultState{
+	db.FAULT_OPEN:         {db.FAULT_ACKNOWLEDGED, db.FAULT_CLOSED},
+	db.FAULT_ACKNOWLEDGED: {db.FAULT_IN_REPAIR, db.FAULT_RESOLVED, db.FAULT_CLOSED},
+	db.FAULT_IN_REPAIR:    {db.FAULT_RESOLVED},
+	db.FAULT_RESOLVED:     {db.FAULT_IN_REPAIR, db.FAULT_CLOSED},
+	db.FAULT_CLOSED:       {},
+}
+
+// FaultStats 故障统计，平均确认时间和平均修复时间从故障发生开始计算（秒），没有样本时为 nil
+type FaultStats struct {
+	SatelliteId   string
+	SatelliteName string
+	FaultType     db.FaultType
+	OpenNum       int64
+	Mtta          *float64
+	Mttr          *float64
+}
+
+func CanTransitFault(from, to db.FaultState) bool {
+	for _, state := range faultTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitFault move the fault to the state, the assignee is kept if empty
+func (s *Server) TransitFault(faultId int32, to db.FaultState, operator,
+	assignee, note string) (*db.Fault, error) {
+
+	fault := new(db.Fault)
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", faultId).First(fault).Error
+		if err != nil {
+			return errors.New("the fault does not exist")
+		}
+
+		from := fault.FaultState
+		if !CanTransitFault(from, to) {
+			return errors.New("the fault can not change from " + db.FaultStateName[from] +
+				" to " + db.FaultStateName[to])
+		}
+
+		now := time.Now().Unix()
+		fault.FaultState = to
+		if len(assignee) != 0 {
+			fault.Assignee = assignee
+		}
+
+		switch to {
+		case db.FAULT_ACKNOWLEDGED:
+			fault.AcknowledgedAt = now
+		case db.FAULT_RESOLVED:
+			fault.ResolvedAt = now
+		case db.FAULT_CLOSED:
+			fault.ClosedAt = now
+		}
+
+		fault.RepairState = db.WRONG
+		if to == db.FAULT_RESOLVED || to == db.FAULT_CLOSED {
+			fault.RepairState = db.NORMAL
+		}
+
+		// 以原状态为条件更新，并发的状态变化只有一个能成功
+		result := tx.Model(&db.Fault{}).Where("id = ? AND fault_state = ?", fault.Id, from).
+			Updates(map[string]interface{}{
+				"fault_state":     fault.FaultState,
+				"assignee":        fault.Assignee,
+				"acknowledged_at": fault.AcknowledgedAt,
+				"resolved_at":     fault.ResolvedAt,
+				"closed_at":       fault.ClosedAt,
+				"repair_state":    fault.RepairState,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("the fault has been changed by others")
+		}
+
+		return tx.Create(&db.FaultTransition{
+			FaultId:        fault.Id,
+			FromState:      from,
+			ToState:        to,
+			Operator:       operator,
+			Assignee:       assignee,
+			Note:           note,
+			TransitionTime: now,
+		}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("transit fault failed, err:[%s], fault:[%d]\n", err.Error(), faultId)
+		return nil, err
+	}
+
+	return fault, nil
+}
+
+// ListFaultTransitions the history of the fault in time order
+func (s *Server) ListFaultTransitions(faultId int32) ([]*db.FaultTransition, error) {
+	transitions := make([]*db.FaultTransition, 0)
+	err := s.gormDb.Where("fault_id = ?", faultId).Order("id").Find(&transitions).Error
+	if err != nil {
+		s.sulog.Infof("list fault transitions failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// GetFaultStats the statistics grouped by the satellite or the fault type
+func (s *Server) GetFaultStats(groupBySatellite bool) ([]*FaultStats, error) {
+	columns := "fault_type"
+	if groupBySatellite {
+		columns = "satellite_id, MAX(satellite_name) AS satellite_name"
+	}
+
+	stats := make([]*FaultStats, 0)
+	query := s.gormDb.Model(&db.Fault{}).Select(columns+", "+
+		"SUM(CASE WHEN fault_state IN ? THEN 1 ELSE 0 END) AS open_num, "+
+		"AVG(CASE WHEN acknowledged_at > 0 THEN acknowledged_at - fault_time END) AS mtta, "+
+		"AVG(CASE WHEN resolved_at > 0 THEN resolved_at - fault_time END) AS mttr",
+		db.UnresolvedFaultStates)
+
+	if groupBySatellite {
+		query = query.Group("satellite_id").Order("satellite_id")
+	} else {
+		query = query.Group("fault_type").Order("fault_type")
+	}
+
+	err := query.Scan(&stats).Error
+	if err != nil {
+		s.sulog.Infof("get fault stats failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+	return stats, nil
+}
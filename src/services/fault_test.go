@@ -0,0 +1,65 @@
+package services
+
+import (
+	"go-web-demo/src/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitFaultSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	faultTime := time.Now().Unix() - 100
+	fault := &db.Fault{
+		SatelliteId: "s1",
+		FaultType:   db.AType,
+		FaultTime:   faultTime,
+		RepairState: db.WRONG,
+		FaultState:  db.FAULT_OPEN,
+	}
+	require.Nil(t, s.InsertOneObjertToDB(fault))
+	require.Nil(t, s.InsertOneObjertToDB(&db.Fault{
+		SatelliteId: "s2",
+		FaultType:   db.AType,
+		FaultTime:   faultTime,
+		RepairState: db.WRONG,
+		FaultState:  db.FAULT_OPEN,
+	}))
+
+	_, err := s.TransitFault(fault.Id, db.FAULT_RESOLVED, "op", "", "")
+	require.NotNil(t, err)
+
+	got, err := s.TransitFault(fault.Id, db.FAULT_ACKNOWLEDGED, "op", "alice", "")
+	require.Nil(t, err)
+	require.Equal(t, "alice", got.Assignee)
+	require.NotZero(t, got.AcknowledgedAt)
+
+	_, err = s.TransitFault(fault.Id, db.FAULT_IN_REPAIR, "alice", "", "replace the battery")
+	require.Nil(t, err)
+	got, err = s.TransitFault(fault.Id, db.FAULT_RESOLVED, "alice", "", "")
+	require.Nil(t, err)
+	require.Equal(t, db.NORMAL, got.RepairState)
+	require.Equal(t, "alice", got.Assignee)
+
+	transitions, err := s.ListFaultTransitions(fault.Id)
+	require.Nil(t, err)
+	require.Len(t, transitions, 3)
+	require.Equal(t, db.FAULT_OPEN, transitions[0].FromState)
+	require.Equal(t, "replace the battery", transitions[1].Note)
+
+	stats, err := s.GetFaultStats(true)
+	require.Nil(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, int64(0), stats[0].OpenNum)
+	require.InDelta(t, 100, *stats[0].Mttr, 5)
+	require.Equal(t, int64(1), stats[1].OpenNum)
+	require.Nil(t, stats[1].Mtta)
+
+	stats, err = s.GetFaultStats(false)
+	require.Nil(t, err)
+	require.Len(t, stats, 1)
+	require.Equal(t, db.AType, stats[0].FaultType)
+	require.Equal(t, int64(1), stats[0].OpenNum)
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// instructionTransitions 允许的指令状态变化，执行失败的指令可以重新执行，
+// 错过执行窗口的指令直接记为执行失败，执行前的指令可以取消
+var instructionTransitions = map[db.InstructionExecState][]db.InstructionExecState{
+	db.WAITAPPROVAL: {db.NOTEXEC, db.REJECTED, db.CANCELLED},
+	db.NOTEXEC:      {db.INEXEC, db.EXECFAIL, db.CANCELLED},
+	db.INEXEC:       {db.EXECSUCCESS, db.EXECFAIL},
+	db.EXECFAIL:     {db.INEXEC, db.CANCELLED},
+	db.EXECSUCCESS:  {},
+	db.REJECTED:     {},
+	db.CANCELLED:    {},
+}
+
+func CanTransitInstruction(from, to db.InstructionExecState) bool {
+	for _, state := range instructionTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateInstruction insert the instruction as not executed, record the creation event
+// and queue it for the executor, the default timeout is used if timeout is 0.
+// The instruction is released at its scheduled time and expires after the window end.
+// The operation instruction waits for the approval first if it is required, so does the
+// instruction set as waiting for approval by the caller, such as the recommended avoidance
+func (s *Server) CreateInstruction(instruction *db.Instruction, operator string,
+	timeout time.Duration) error {
+
+	execConf := s.config.ExecConfig
+	if timeout <= 0 {
+		timeout = execConf.Timeout
+	}
+
+	waitApproval := instruction.ExecState == db.WAITAPPROVAL ||
+		(execConf.RequireApproval && instruction.Type == db.OPERATION)
+	instruction.ExecState = db.NOTEXEC
+	taskState := db.TASK_QUEUED
+	if waitApproval {
+		instruction.ExecState = db.WAITAPPROVAL
+		taskState = db.TASK_HELD
+	}
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(instruction).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(&db.InstructionEvent{
+			InstructionId: instruction.InstructionId,
+			ToState:       instruction.ExecState,
+			Operator:      operator,
+			EventTime:     instruction.GenInstructionTime,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		nextRunAt := time.Now().UnixMilli()
+		if instruction.ScheduledTime*1000 > nextRunAt {
+			nextRunAt = instruction.ScheduledTime * 1000
+		}
+
+		return tx.Create(&db.InstructionTask{
+			InstructionId: instruction.InstructionId,
+			TaskState:     taskState,
+			MaxAttempts:   int32(execConf.MaxAttempts),
+			TimeoutMs:     timeout.Milliseconds(),
+			NextRunAt:     nextRunAt,
+			WindowEnd:     instruction.ScheduleWindowEnd * 1000,
+		}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("create instruction failed, err:[%s], instruction:[%s]\n",
+			err.Error(), instruction.InstructionId)
+		return err
+	}
+
+	s.wakeInstructionWorkers()
+	return nil
+}
+
+// TransitInstruction move the instruction to the state, the execution time is set when it starts
+func (s *Server) TransitInstruction(instructionId string, to db.InstructionExecState,
+	operator, note string) (*db.Instruction, error) {
+
+	var instruction *db.Instruction
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		var err error
+		instruction, err = transitInstruction(tx, instructionId, to, operator, note)
+		return err
+	})
+	if err != nil {
+		s.sulog.Infof("transit instruction failed, err:[%s], instruction:[%s]\n",
+			err.Error(), instructionId)
+		return nil, err
+	}
+
+	return instruction, nil
+}
+
+func transitInstruction(tx *gorm.DB, instructionId string, to db.InstructionExecState,
+	operator, note string) (*db.Instruction, error) {
+
+	instruction := new(db.Instruction)
+	err := tx.Where("instruction_id = ?", instructionId).First(instruction).Error
+	if err != nil {
+		return nil, errors.New("the instruction does not exist")
+	}
+
+	from := instruction.ExecState
+	if !CanTransitInstruction(from, to) {
+		return nil, errors.New("the instruction can not change from " + db.ExecStateName[from] +
+			" to " + db.ExecStateName[to])
+	}
+
+	now := time.Now().Unix()
+	instruction.ExecState = to
+	if to == db.INEXEC {
+		instruction.ExecInstructionTime = now
+	}
+
+	// 以原状态为条件更新，并发的状态变化只有一个能成功
+	result := tx.Model(&db.Instruction{}).Where("id = ? AND exec_state = ?", instruction.Id, from).
+		Updates(map[string]interface{}{
+			"exec_state":            instruction.ExecState,
+			"exec_instruction_time": instruction.ExecInstructionTime,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("the instruction has been changed by others")
+	}
+
+	err = tx.Create(&db.InstructionEvent{
+		InstructionId: instructionId,
+		FromState:     from,
+		ToState:       to,
+		Operator:      operator,
+		Note:          note,
+		EventTime:     now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return instruction, nil
+}
+
+// ReviewInstruction approve or reject the instruction waiting for approval,
+// the submitter can not approve their own instruction
+func (s *Server) ReviewInstruction(instructionId, reviewer string, approve bool,
+	reason string) (*db.Instruction, error) {
+
+	var instruction *db.Instruction
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		current := new(db.Instruction)
+		err := tx.Where("instruction_id = ?", instructionId).First(current).Error
+		if err != nil {
+			return errors.New("the instruction does not exist")
+		}
+
+		if approve && current.InstructionSource == reviewer {
+			return errors.New("the submitter can not approve the instruction")
+		}
+
+		to, taskState := db.REJECTED, db.TASK_CANCELLED
+		if approve {
+			to, taskState = db.NOTEXEC, db.TASK_QUEUED
+		}
+
+		instruction, err = transitInstruction(tx, instructionId, to, reviewer, reason)
+		if err != nil {
+			return err
+		}
+
+		instruction.Reviewer = reviewer
+		instruction.ReviewTime = time.Now().Unix()
+		err = tx.Model(&db.Instruction{}).Where("id = ?", instruction.Id).
+			Updates(map[string]interface{}{
+				"reviewer":    instruction.Reviewer,
+				"review_time": instruction.ReviewTime,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&db.InstructionTask{}).
+			Where("instruction_id = ? AND task_state = ?", instructionId, db.TASK_HELD).
+			Updates(map[string]interface{}{
+				"task_state": taskState,
+				"last_error": reason,
+			}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("review instruction failed, err:[%s], instruction:[%s]\n",
+			err.Error(), instructionId)
+		return nil, err
+	}
+
+	if approve {
+		s.wakeInstructionWorkers()
+	}
+	return instruction, nil
+}
+
+// CancelInstruction cancel the instruction waiting for approval or execution,
+// the running one can not be cancelled
+func (s *Server) CancelInstruction(instructionId, operator, reason string) (*db.Instruction, error) {
+	var instruction *db.Instruction
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&db.InstructionTask{}).
+			Where("instruction_id = ? AND task_state IN ?", instructionId,
+				[]db.InstructionTaskState{db.TASK_HELD, db.TASK_QUEUED}).
+			Updates(map[string]interface{}{
+				"task_state": db.TASK_CANCELLED,
+				"last_error": reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("the instruction is not waiting for approval or execution")
+		}
+
+		var err error
+		instruction, err = transitInstruction(tx, instructionId, db.CANCELLED, operator, reason)
+		return err
+	})
+	if err != nil {
+		s.sulog.Infof("cancel instruction failed, err:[%s], instruction:[%s]\n",
+			err.Error(), instructionId)
+		return nil, err
+	}
+	return instruction, nil
+}
+
+// TimelineEntry 待审批、排队或执行中的指令及其队列信息
+type TimelineEntry struct {
+	Instruction *db.Instruction
+	Task        *db.InstructionTask
+}
+
+// GetSatelliteTimeline the instructions of the satellite waiting for approval, queued
+// and running, in the order of the next execution time
+func (s *Server) GetSatelliteTimeline(satelliteId string) ([]*TimelineEntry, error) {
+	tasks := make([]*db.InstructionTask, 0)
+	err := s.gormDb.Model(&db.InstructionTask{}).
+		Joins("JOIN "+db.INSTRUCTION_TABLE_NAME+" ON "+db.INSTRUCTION_TABLE_NAME+
+			".instruction_id = "+db.INSTRUCTION_TASK_TABLE_NAME+".instruction_id").
+		Where(db.INSTRUCTION_TABLE_NAME+".satellite_id = ? AND "+
+			db.INSTRUCTION_TASK_TABLE_NAME+".task_state IN ?",
+			satelliteId, []db.InstructionTaskState{db.TASK_HELD, db.TASK_QUEUED, db.TASK_RUNNING}).
+		Order(db.INSTRUCTION_TASK_TABLE_NAME + ".next_run_at").Find(&tasks).Error
+	if err != nil {
+		s.sulog.Infof("get satellite timeline failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.InstructionId)
+	}
+
+	instructions := make([]*db.Instruction, 0, len(ids))
+	err = s.gormDb.Where("instruction_id IN ?", ids).Find(&instructions).Error
+	if err != nil {
+		s.sulog.Infof("get satellite timeline failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+
+	byId := make(map[string]*db.Instruction, len(instructions))
+	for _, instruction := range instructions {
+		byId[instruction.InstructionId] = instruction
+	}
+
+	timeline := make([]*TimelineEntry, 0, len(tasks))
+	for _, task := range tasks {
+		if instruction, ok := byId[task.InstructionId]; ok {
+			timeline = append(timeline, &TimelineEntry{Instruction: instruction, Task: task})
+		}
+	}
+	return timeline, nil
+}
+
+// ListInstructionEvents the history of the instruction in time order
+func (s *Server) ListInstructionEvents(instructionId string) ([]*db.InstructionEvent, error) {
+	events := make([]*db.InstructionEvent, 0)
+	err := s.gormDb.Where("instruction_id = ?", instructionId).Order("id").Find(&events).Error
+	if err != nil {
+		s.sulog.Infof("list instruction events failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+	return events, nil
+}
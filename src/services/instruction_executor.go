@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// INSTRUCTION_EXECUTOR_OPERATOR 执行器产生的指令事件的操作人
+	INSTRUCTION_EXECUTOR_OPERATOR = "executor"
+
+	// 每次从队列中取出的候选任务数，被其他执行器抢先的任务直接跳过
+	INSTRUCTION_CLAIM_BATCH = 10
+)
+
+// 服务记录的执行失败的错误码，执行系统上报的错误码由其自行定义
+const (
+	INSTRUCTION_ERROR_TIMEOUT = "TIMEOUT"
+
+	INSTRUCTION_ERROR_EXECUTOR = "EXECUTOR_ERROR"
+)
+
+var errTaskTaken = errors.New("the task has been taken by others")
+
+// InstructionExecutor execute one instruction, returning an error means the attempt failed.
+// It should return soon after the ctx is done
+type InstructionExecutor interface {
+	Execute(ctx context.Context, instruction *db.Instruction) error
+}
+
+// SimulatedExecutor 接入真实的执行系统前模拟执行耗时，超时前总是执行成功
+type SimulatedExecutor struct {
+	Delay time.Duration
+}
+
+func (e *SimulatedExecutor) Execute(ctx context.Context, instruction *db.Instruction) error {
+	timer := time.NewTimer(e.Delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StartInstructionExecutor start the workers and the recovery of the expired tasks,
+// the queue is kept in the database so the tasks continue after restart.
+// In the remote mode only the expired tasks are recovered
+func (s *Server) StartInstructionExecutor() {
+	execConf := s.config.ExecConfig
+	if !s.RemoteExecution() {
+		if s.executor == nil {
+			s.executor = &SimulatedExecutor{Delay: execConf.SimulateDelay}
+		}
+
+		hostname, _ := os.Hostname()
+		for i := 0; i < execConf.Workers; i++ {
+			go s.runInstructionWorker(fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), i))
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(execConf.PollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			_, err := s.RecoverInstructionTasks()
+			if err != nil {
+				s.sulog.Infof("recover instruction tasks failed, err: [%s]\n", err.Error())
+			}
+
+			_, err = s.ExpireInstructionTasks()
+			if err != nil {
+				s.sulog.Infof("expire instruction tasks failed, err: [%s]\n", err.Error())
+			}
+		}
+	}()
+}
+
+func (s *Server) runInstructionWorker(worker string) {
+	ticker := time.NewTicker(s.config.ExecConfig.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		task, instruction, err := s.ClaimInstructionTask(worker)
+		if err != nil {
+			s.sulog.Infof("claim instruction task failed, err: [%s], worker: [%s]\n",
+				err.Error(), worker)
+		}
+
+		if task == nil {
+			select {
+			case <-s.execWake:
+			case <-ticker.C:
+			}
+			continue
+		}
+
+		// 队列中可能还有任务，唤醒其他空闲的执行器
+		s.wakeInstructionWorkers()
+		s.ExecuteInstructionTask(task, instruction)
+	}
+}
+
+// RemoteExecution whether the instructions are claimed by the exec system
+func (s *Server) RemoteExecution() bool {
+	return s.config.ExecConfig.Mode == configs.EXECUTOR_MODE_REMOTE
+}
+
+func (s *Server) wakeInstructionWorkers() {
+	select {
+	case s.execWake <- struct{}{}:
+	default:
+	}
+}
+
+// ClaimInstructionTask take the earliest due task within its window and start the instruction,
+// nil is returned when there is no due task
+func (s *Server) ClaimInstructionTask(worker string) (*db.InstructionTask, *db.Instruction, error) {
+	now := time.Now().UnixMilli()
+	ids := make([]int32, 0)
+	err := s.gormDb.Model(&db.InstructionTask{}).
+		Where("task_state = ? AND next_run_at <= ? AND (window_end = 0 OR window_end >= ?)",
+			db.TASK_QUEUED, now, now).
+		Order("next_run_at").Limit(INSTRUCTION_CLAIM_BATCH).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, id := range ids {
+		task := new(db.InstructionTask)
+		var instruction *db.Instruction
+		err = s.gormDb.Transaction(func(tx *gorm.DB) error {
+			// 以排队状态为条件更新，多个执行器同时领取时只有一个能成功
+			result := tx.Model(&db.InstructionTask{}).
+				Where("id = ? AND task_state = ?", id, db.TASK_QUEUED).
+				Updates(map[string]interface{}{
+					"task_state":       db.TASK_RUNNING,
+					"attempts":         gorm.Expr("attempts + 1"),
+					"worker":           worker,
+					"lease_expires_at": gorm.Expr("timeout_ms + ?", now),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errTaskTaken
+			}
+
+			err := tx.Where("id = ?", id).First(task).Error
+			if err != nil {
+				return err
+			}
+
+			instruction, err = transitInstruction(tx, task.InstructionId, db.INEXEC,
+				INSTRUCTION_EXECUTOR_OPERATOR, fmt.Sprintf("第 %d 次执行", task.Attempts))
+			return err
+		})
+		if err == errTaskTaken {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return task, instruction, nil
+	}
+
+	return nil, nil, nil
+}
+
+// ExecuteInstructionTask run one attempt of the claimed task within its timeout
+func (s *Server) ExecuteInstructionTask(task *db.InstructionTask, instruction *db.Instruction) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Duration(task.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	result := &db.InstructionResult{
+		ExecState:  db.EXECSUCCESS,
+		ReportedBy: INSTRUCTION_EXECUTOR_OPERATOR,
+	}
+
+	execErr := s.executeInstruction(ctx, instruction)
+	if ctx.Err() == context.DeadlineExceeded {
+		result.ExecState = db.EXECFAIL
+		result.ErrorCode = INSTRUCTION_ERROR_TIMEOUT
+		result.ErrorMessage = "execution timed out"
+	} else if execErr != nil {
+		result.ExecState = db.EXECFAIL
+		result.ErrorCode = INSTRUCTION_ERROR_EXECUTOR
+		result.ErrorMessage = execErr.Error()
+	}
+
+	err := s.FinishInstructionTask(task, result)
+	if err != nil {
+		s.sulog.Infof("finish instruction task failed, err: [%s], instruction: [%s]\n",
+			err.Error(), task.InstructionId)
+	}
+}
+
+func (s *Server) executeInstruction(ctx context.Context, instruction *db.Instruction) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("executor panic: %v", r)
+		}
+	}()
+	return s.executor.Execute(ctx, instruction)
+}
+
+// FinishInstructionTask record the result of the attempt, the failed task is queued again
+// with backoff until the max attempts
+func (s *Server) FinishInstructionTask(task *db.InstructionTask, result *db.InstructionResult) error {
+	return s.gormDb.Transaction(func(tx *gorm.DB) error {
+		note := ""
+		updates := map[string]interface{}{
+			"worker":           "",
+			"lease_expires_at": 0,
+			"task_state":       db.TASK_DONE,
+		}
+
+		if result.ExecState == db.EXECFAIL {
+			note = result.ErrorMessage
+			if len(note) == 0 {
+				note = result.ErrorCode
+			}
+			updates["last_error"] = note
+			updates["task_state"] = db.TASK_GAVE_UP
+			if task.Attempts < task.MaxAttempts {
+				updates["task_state"] = db.TASK_QUEUED
+				updates["next_run_at"] = time.Now().Add(s.retryBackoff(task.Attempts)).UnixMilli()
+			}
+		}
+
+		// 超时后任务可能已被回收，只有本次执行的领取者能提交结果
+		dbResult := tx.Model(&db.InstructionTask{}).
+			Where("id = ? AND task_state = ? AND worker = ? AND attempts = ?",
+				task.Id, db.TASK_RUNNING, task.Worker, task.Attempts).
+			Updates(updates)
+		if dbResult.Error != nil {
+			return dbResult.Error
+		}
+		if dbResult.RowsAffected == 0 {
+			return errTaskTaken
+		}
+
+		instruction, err := transitInstruction(tx, task.InstructionId, result.ExecState,
+			result.ReportedBy, note)
+		if err != nil {
+			return err
+		}
+
+		result.InstructionId = task.InstructionId
+		result.Attempt = task.Attempts
+		if result.StartedAt == 0 {
+			result.StartedAt = instruction.ExecInstructionTime
+		}
+		if result.FinishedAt == 0 {
+			result.FinishedAt = time.Now().Unix()
+		}
+		return tx.Create(result).Error
+	})
+}
+
+// RecoverInstructionTasks fail the running tasks whose lease has expired, such as the
+// server restarted during the execution or the exec system did not report the result
+func (s *Server) RecoverInstructionTasks() (int, error) {
+	tasks := make([]*db.InstructionTask, 0)
+	err := s.gormDb.Where("task_state = ? AND lease_expires_at < ?",
+		db.TASK_RUNNING, time.Now().UnixMilli()).Find(&tasks).Error
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, task := range tasks {
+		err = s.FinishInstructionTask(task, &db.InstructionResult{
+			ExecState:    db.EXECFAIL,
+			ErrorCode:    INSTRUCTION_ERROR_TIMEOUT,
+			ErrorMessage: "no result before the deadline",
+			ReportedBy:   INSTRUCTION_EXECUTOR_OPERATOR,
+		})
+		if err != nil {
+			if err != errTaskTaken {
+				s.sulog.Infof("recover instruction task failed, err: [%s], instruction: [%s]\n",
+					err.Error(), task.InstructionId)
+			}
+			continue
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+// ExpireInstructionTasks give up the queued tasks whose execution window has passed,
+// the instruction not executed yet is marked as failed
+func (s *Server) ExpireInstructionTasks() (int, error) {
+	now := time.Now().UnixMilli()
+	tasks := make([]*db.InstructionTask, 0)
+	err := s.gormDb.Where("task_state = ? AND window_end > 0 AND window_end < ?",
+		db.TASK_QUEUED, now).Find(&tasks).Error
+	if err != nil {
+		return 0, err
+	}
+
+	const reason = "missed the execution window"
+	expired := 0
+	for _, task := range tasks {
+		err = s.gormDb.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&db.InstructionTask{}).
+				Where("id = ? AND task_state = ?", task.Id, db.TASK_QUEUED).
+				Updates(map[string]interface{}{
+					"task_state": db.TASK_GAVE_UP,
+					"last_error": reason,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errTaskTaken
+			}
+
+			instruction := new(db.Instruction)
+			err := tx.Where("instruction_id = ?", task.InstructionId).First(instruction).Error
+			if err != nil {
+				return err
+			}
+
+			// 重试中的指令已经是执行失败
+			if instruction.ExecState != db.NOTEXEC {
+				return nil
+			}
+			_, err = transitInstruction(tx, task.InstructionId, db.EXECFAIL,
+				INSTRUCTION_EXECUTOR_OPERATOR, reason)
+			return err
+		})
+		if err != nil {
+			if err != errTaskTaken {
+				s.sulog.Infof("expire instruction task failed, err: [%s], instruction: [%s]\n",
+					err.Error(), task.InstructionId)
+			}
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// retryBackoff 第 n 次执行失败后的等待时间，每次翻倍，不超过最大值
+func (s *Server) retryBackoff(attempts int32) time.Duration {
+	execConf := s.config.ExecConfig
+	backoff := execConf.RetryBackoff
+	for i := int32(1); i < attempts && backoff < execConf.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > execConf.MaxBackoff {
+		backoff = execConf.MaxBackoff
+	}
+	return backoff
+}
+
+// ReportInstructionProgress the progress of the instruction claimed by the worker
+func (s *Server) ReportInstructionProgress(instructionId, worker string, progress int32,
+	message string) error {
+
+	result := s.gormDb.Model(&db.InstructionTask{}).
+		Where("instruction_id = ? AND task_state = ? AND worker = ?",
+			instructionId, db.TASK_RUNNING, worker).
+		Updates(map[string]interface{}{
+			"progress":         progress,
+			"progress_message": message,
+			"progress_at":      time.Now().Unix(),
+		})
+	if result.Error != nil {
+		s.sulog.Infof("report instruction progress failed, err:[%s], instruction:[%s]\n",
+			result.Error.Error(), instructionId)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("the instruction is not being executed by " + worker)
+	}
+	return nil
+}
+
+// ReportInstructionResult the final result of the instruction claimed by the worker
+func (s *Server) ReportInstructionResult(instructionId, worker string,
+	result *db.InstructionResult) error {
+
+	task := new(db.InstructionTask)
+	err := s.gormDb.Where("instruction_id = ?", instructionId).First(task).Error
+	if err != nil {
+		return errors.New("the instruction is not in the queue")
+	}
+
+	if task.TaskState != db.TASK_RUNNING || task.Worker != worker {
+		return errors.New("the instruction is not being executed by " + worker)
+	}
+
+	result.ReportedBy = worker
+	err = s.FinishInstructionTask(task, result)
+	if err == errTaskTaken {
+		return errors.New("the deadline of the instruction has passed")
+	}
+	if err != nil {
+		s.sulog.Infof("report instruction result failed, err:[%s], instruction:[%s]\n",
+			err.Error(), instructionId)
+		return err
+	}
+	return nil
+}
+
+// ListInstructionResults the results of all the attempts in time order
+func (s *Server) ListInstructionResults(instructionId string) ([]*db.InstructionResult, error) {
+	results := make([]*db.InstructionResult, 0)
+	err := s.gormDb.Where("instruction_id = ?", instructionId).Order("id").Find(&results).Error
+	if err != nil {
+		s.sulog.Infof("list instruction results failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+	return results, nil
+}
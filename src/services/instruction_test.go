@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type executorFunc func(ctx context.Context, instruction *db.Instruction) error
+
+func (f executorFunc) Execute(ctx context.Context, instruction *db.Instruction) error {
+	return f(ctx, instruction)
+}
+
+func newExecutorTestServer(t *testing.T, executor InstructionExecutor) *Server {
+	s := newSqliteTestServer(t)
+	s.config = &configs.Config{ExecConfig: &configs.ExecutorConfig{
+		Timeout:      time.Second,
+		MaxAttempts:  2,
+		RetryBackoff: time.Minute,
+		MaxBackoff:   time.Hour,
+	}}
+	s.executor = executor
+	return s
+}
+
+func TestTransitInstructionSqlite(t *testing.T) {
+	s := newExecutorTestServer(t, nil)
+
+	instruction := &db.Instruction{
+		InstructionId:      "i1",
+		Type:               db.OPERATION,
+		SatelliteId:        "s1",
+		GenInstructionTime: time.Now().Unix(),
+	}
+	require.Nil(t, s.CreateInstruction(instruction, "op", 0))
+	require.Equal(t, db.NOTEXEC, instruction.ExecState)
+
+	_, err := s.TransitInstruction("i1", db.EXECSUCCESS, "op", "")
+	require.NotNil(t, err)
+	_, err = s.TransitInstruction("i2", db.INEXEC, "op", "")
+	require.NotNil(t, err)
+
+	got, err := s.TransitInstruction("i1", db.INEXEC, "op", "")
+	require.Nil(t, err)
+	require.NotZero(t, got.ExecInstructionTime)
+
+	_, err = s.TransitInstruction("i1", db.EXECFAIL, "op", "timeout")
+	require.Nil(t, err)
+	_, err = s.TransitInstruction("i1", db.INEXEC, "op", "retry")
+	require.Nil(t, err)
+	got, err = s.TransitInstruction("i1", db.EXECSUCCESS, "op", "")
+	require.Nil(t, err)
+	require.Equal(t, db.EXECSUCCESS, got.ExecState)
+
+	// 执行成功后不能再变化
+	_, err = s.TransitInstruction("i1", db.INEXEC, "op", "")
+	require.NotNil(t, err)
+
+	var count int64
+	require.Nil(t, s.GetTableDataCount(&db.Instruction{}, &count))
+	require.Equal(t, int64(1), count)
+
+	events, err := s.ListInstructionEvents("i1")
+	require.Nil(t, err)
+	require.Len(t, events, 5)
+	require.Equal(t, db.InstructionExecState(0), events[0].FromState)
+	require.Equal(t, db.NOTEXEC, events[0].ToState)
+	require.Equal(t, "timeout", events[2].Note)
+	require.Equal(t, db.EXECSUCCESS, events[4].ToState)
+}
+
+func TestInstructionExecutorSqlite(t *testing.T) {
+	calls := 0
+	s := newExecutorTestServer(t, executorFunc(func(ctx context.Context,
+		instruction *db.Instruction) error {
+		calls++
+		if instruction.InstructionId == "slow" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		if calls == 1 {
+			return errors.New("no ack")
+		}
+		return nil
+	}))
+
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "i1"}, "op", 0))
+
+	task, instruction, err := s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Equal(t, db.INEXEC, instruction.ExecState)
+	require.Equal(t, int32(1), task.Attempts)
+
+	// 没有到期的任务
+	task2, _, err := s.ClaimInstructionTask("w2")
+	require.Nil(t, err)
+	require.Nil(t, task2)
+
+	// 第一次失败后退避重试
+	s.ExecuteInstructionTask(task, instruction)
+	got := new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i1"))
+	require.Equal(t, db.TASK_QUEUED, got.TaskState)
+	require.Equal(t, "no ack", got.LastError)
+	require.Greater(t, got.NextRunAt, time.Now().Add(50*time.Second).UnixMilli())
+
+	require.Nil(t, s.GetGormObject().Model(got).Update("next_run_at", 0).Error)
+	task, instruction, err = s.ClaimInstructionTask("w2")
+	require.Nil(t, err)
+	require.Equal(t, int32(2), task.Attempts)
+	s.ExecuteInstructionTask(task, instruction)
+
+	got = new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i1"))
+	require.Equal(t, db.TASK_DONE, got.TaskState)
+	events, err := s.ListInstructionEvents("i1")
+	require.Nil(t, err)
+	require.Len(t, events, 5)
+	require.Equal(t, db.EXECFAIL, events[2].ToState)
+	require.Equal(t, db.EXECSUCCESS, events[4].ToState)
+
+	// 单次执行超时，达到最大次数后放弃
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "slow"}, "op",
+		50*time.Millisecond))
+	for i := 0; i < 2; i++ {
+		task, instruction, err = s.ClaimInstructionTask("w1")
+		require.Nil(t, err)
+		s.ExecuteInstructionTask(task, instruction)
+		require.Nil(t, s.GetGormObject().Model(&db.InstructionTask{}).
+			Where("instruction_id = ?", "slow").Update("next_run_at", 0).Error)
+	}
+	got = new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "slow"))
+	require.Equal(t, db.TASK_GAVE_UP, got.TaskState)
+	require.Equal(t, "execution timed out", got.LastError)
+
+	// 执行中重启，租约过期后回收
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "i2"}, "op", 0))
+	task, _, err = s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	recovered, err := s.RecoverInstructionTasks()
+	require.Nil(t, err)
+	require.Equal(t, 0, recovered)
+
+	require.Nil(t, s.GetGormObject().Model(task).Update("lease_expires_at", 1).Error)
+	recovered, err = s.RecoverInstructionTasks()
+	require.Nil(t, err)
+	require.Equal(t, 1, recovered)
+	got = new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i2"))
+	require.Equal(t, db.TASK_QUEUED, got.TaskState)
+
+	// 被回收后原执行者不能再提交结果
+	require.NotNil(t, s.FinishInstructionTask(task, &db.InstructionResult{ExecState: db.EXECSUCCESS}))
+
+	results, err := s.ListInstructionResults("slow")
+	require.Nil(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, INSTRUCTION_ERROR_TIMEOUT, results[1].ErrorCode)
+	require.Equal(t, int32(2), results[1].Attempt)
+}
+
+func TestReportInstructionResultSqlite(t *testing.T) {
+	s := newExecutorTestServer(t, nil)
+	s.config.ExecConfig.Mode = configs.EXECUTOR_MODE_REMOTE
+	require.True(t, s.RemoteExecution())
+
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "i1"}, "op", 0))
+	require.NotNil(t, s.ReportInstructionProgress("i1", "exec", 10, ""))
+
+	task, _, err := s.ClaimInstructionTask("exec")
+	require.Nil(t, err)
+	require.Nil(t, s.ReportInstructionProgress("i1", "exec", 40, "uploading"))
+	require.NotNil(t, s.ReportInstructionProgress("i1", "other", 40, ""))
+
+	got := new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i1"))
+	require.Equal(t, int32(40), got.Progress)
+
+	// 只有领取者能上报结果
+	require.NotNil(t, s.ReportInstructionResult("i1", "other",
+		&db.InstructionResult{ExecState: db.EXECSUCCESS}))
+	require.Nil(t, s.ReportInstructionResult("i1", "exec", &db.InstructionResult{
+		ExecState:    db.EXECFAIL,
+		ErrorCode:    "E42",
+		ErrorMessage: "thruster not ready",
+		Telemetry:    `{"fuel":12.5}`,
+		StartedAt:    100,
+		FinishedAt:   120,
+	}))
+	require.NotNil(t, s.ReportInstructionResult("i1", "exec",
+		&db.InstructionResult{ExecState: db.EXECSUCCESS}))
+
+	instruction := new(db.Instruction)
+	require.Nil(t, s.QueryObjectByCondition(instruction, "instruction_id", "i1"))
+	require.Equal(t, db.EXECFAIL, instruction.ExecState)
+
+	// 重试时截止时间内没有上报结果
+	require.Nil(t, s.GetGormObject().Model(task).Update("next_run_at", 0).Error)
+	task, _, err = s.ClaimInstructionTask("exec")
+	require.Nil(t, err)
+	require.Nil(t, s.GetGormObject().Model(task).Update("lease_expires_at", 1).Error)
+	recovered, err := s.RecoverInstructionTasks()
+	require.Nil(t, err)
+	require.Equal(t, 1, recovered)
+	require.NotNil(t, s.ReportInstructionResult("i1", "exec",
+		&db.InstructionResult{ExecState: db.EXECSUCCESS}))
+
+	got = new(db.InstructionTask)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i1"))
+	require.Equal(t, db.TASK_GAVE_UP, got.TaskState)
+
+	results, err := s.ListInstructionResults("i1")
+	require.Nil(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "E42", results[0].ErrorCode)
+	require.Equal(t, `{"fuel":12.5}`, results[0].Telemetry)
+	require.Equal(t, int64(100), results[0].StartedAt)
+	require.Equal(t, "exec", results[0].ReportedBy)
+	require.Equal(t, INSTRUCTION_ERROR_TIMEOUT, results[1].ErrorCode)
+}
+
+func TestScheduledInstructionSqlite(t *testing.T) {
+	s := newExecutorTestServer(t, nil)
+
+	now := time.Now().Unix()
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "later", SatelliteId: "s1",
+		ScheduledTime: now + 3600}, "op", 0))
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "soon", SatelliteId: "s1",
+		ScheduledTime: now + 60, ScheduleWindowEnd: now + 120}, "op", 0))
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "other", SatelliteId: "s2",
+		ScheduledTime: now + 60}, "op", 0))
+
+	// 未到执行时间的指令不会被领取
+	task, _, err := s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Nil(t, task)
+
+	timeline, err := s.GetSatelliteTimeline("s1")
+	require.Nil(t, err)
+	require.Len(t, timeline, 2)
+	require.Equal(t, "soon", timeline[0].Instruction.InstructionId)
+	require.Equal(t, (now+60)*1000, timeline[0].Task.NextRunAt)
+	require.Equal(t, "later", timeline[1].Instruction.InstructionId)
+
+	// 到期后释放
+	require.Nil(t, s.GetGormObject().Model(&db.InstructionTask{}).
+		Where("instruction_id = ?", "later").Update("next_run_at", 0).Error)
+	task, _, err = s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Equal(t, "later", task.InstructionId)
+
+	// 错过执行窗口
+	require.Nil(t, s.GetGormObject().Model(&db.InstructionTask{}).
+		Where("instruction_id = ?", "soon").
+		Updates(map[string]interface{}{"next_run_at": 0, "window_end": 1}).Error)
+	task, _, err = s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Nil(t, task)
+
+	expired, err := s.ExpireInstructionTasks()
+	require.Nil(t, err)
+	require.Equal(t, 1, expired)
+
+	instruction := new(db.Instruction)
+	require.Nil(t, s.QueryObjectByCondition(instruction, "instruction_id", "soon"))
+	require.Equal(t, db.EXECFAIL, instruction.ExecState)
+
+	timeline, err = s.GetSatelliteTimeline("s1")
+	require.Nil(t, err)
+	require.Len(t, timeline, 1)
+	require.Equal(t, db.TASK_RUNNING, timeline[0].Task.TaskState)
+}
+
+func TestReviewInstructionSqlite(t *testing.T) {
+	s := newExecutorTestServer(t, nil)
+	s.config.ExecConfig.RequireApproval = true
+
+	for _, id := range []string{"i1", "i2", "i3"} {
+		require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: id, Type: db.OPERATION,
+			InstructionSource: "alice"}, "alice", 0))
+	}
+	require.Nil(t, s.CreateInstruction(&db.Instruction{InstructionId: "auto", Type: db.AUTOMATIC},
+		"system", 0))
+
+	// 待审批的指令不会被执行，自主规避指令不需要审批
+	task, _, err := s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Equal(t, "auto", task.InstructionId)
+	task, _, err = s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Nil(t, task)
+
+	_, err = s.ReviewInstruction("i1", "alice", true, "")
+	require.NotNil(t, err)
+	got, err := s.ReviewInstruction("i1", "bob", true, "")
+	require.Nil(t, err)
+	require.Equal(t, db.NOTEXEC, got.ExecState)
+	require.Equal(t, "bob", got.Reviewer)
+	_, err = s.ReviewInstruction("i1", "bob", false, "twice")
+	require.NotNil(t, err)
+
+	got, err = s.ReviewInstruction("i2", "bob", false, "wrong epoch")
+	require.Nil(t, err)
+	require.Equal(t, db.REJECTED, got.ExecState)
+
+	got, err = s.CancelInstruction("i3", "alice", "debris moved")
+	require.Nil(t, err)
+	require.Equal(t, db.CANCELLED, got.ExecState)
+	_, err = s.CancelInstruction("i2", "alice", "rejected already")
+	require.NotNil(t, err)
+
+	task, _, err = s.ClaimInstructionTask("w1")
+	require.Nil(t, err)
+	require.Equal(t, "i1", task.InstructionId)
+	_, err = s.CancelInstruction("i1", "alice", "too late")
+	require.NotNil(t, err)
+
+	events, err := s.ListInstructionEvents("i3")
+	require.Nil(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, db.WAITAPPROVAL, events[0].ToState)
+	require.Equal(t, "debris moved", events[1].Note)
+}
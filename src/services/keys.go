@@ -0,0 +1,194 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go-web-demo/src/configs"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// HS512 密钥的最小长度
+const MIN_HMAC_SECRET_LEN = 32
+
+// SigningKey token 签名密钥，PrivateKey 为空时只用于校验
+type SigningKey struct {
+	Kid        string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+}
+
+// KeyStore 保存所有可用于校验的密钥以及当前用于签名的密钥
+type KeyStore struct {
+	activeKid string
+	keys      map[string]*SigningKey
+	// keep the order in config, used by jwks
+	kids []string
+}
+
+// NewKeyStore load the signing keys from the config, an ephemeral ed25519 key
+// is generated when no key is configured
+func NewKeyStore(tokenConf *configs.TokenConfig, log *zap.SugaredLogger) (*KeyStore, error) {
+	ks := &KeyStore{
+		keys: make(map[string]*SigningKey),
+		kids: make([]string, 0),
+	}
+
+	if len(tokenConf.SigningKeys) == 0 {
+		key, err := genEphemeralKey()
+		if err != nil {
+			return nil, err
+		}
+		log.Warnf("no token signing key configured, use the ephemeral key [%s], "+
+			"the issued tokens will be invalid after restart\n", key.Kid)
+		ks.addKey(key)
+		ks.activeKid = key.Kid
+		return ks, nil
+	}
+
+	for _, keyConf := range tokenConf.SigningKeys {
+		key, err := loadSigningKey(keyConf)
+		if err != nil {
+			return nil, errors.New("load signing key [" + keyConf.Kid + "] failed, " + err.Error())
+		}
+		ks.addKey(key)
+	}
+
+	active := ks.keys[tokenConf.ActiveKid]
+	if active == nil || active.PrivateKey == nil {
+		return nil, errors.New("the active signing key has no private key")
+	}
+	ks.activeKid = tokenConf.ActiveKid
+
+	return ks, nil
+}
+
+// ActiveKey the key used to sign new tokens
+func (ks *KeyStore) ActiveKey() *SigningKey {
+	return ks.keys[ks.activeKid]
+}
+
+// GetKey get the key by kid
+func (ks *KeyStore) GetKey(kid string) (*SigningKey, bool) {
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// Keys all the keys in config order
+func (ks *KeyStore) Keys() []*SigningKey {
+	keys := make([]*SigningKey, 0, len(ks.kids))
+	for _, kid := range ks.kids {
+		keys = append(keys, ks.keys[kid])
+	}
+	return keys
+}
+
+func (ks *KeyStore) addKey(key *SigningKey) {
+	ks.keys[key.Kid] = key
+	ks.kids = append(ks.kids, key.Kid)
+}
+
+func loadSigningKey(keyConf *configs.SigningKeyConfig) (*SigningKey, error) {
+	key := &SigningKey{Kid: keyConf.Kid}
+
+	switch keyConf.Algorithm {
+	case configs.TOKEN_ALG_HS512:
+		if len(keyConf.Secret) < MIN_HMAC_SECRET_LEN {
+			return nil, errors.New("the secret of HS512 is too short")
+		}
+		key.Method = jwt.SigningMethodHS512
+		key.PrivateKey = []byte(keyConf.Secret)
+		key.PublicKey = []byte(keyConf.Secret)
+
+	case configs.TOKEN_ALG_RS256:
+		key.Method = jwt.SigningMethodRS256
+		if len(keyConf.PrivateKeyFile) != 0 {
+			pemBytes, err := os.ReadFile(keyConf.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			key.PrivateKey = privateKey
+			key.PublicKey = &privateKey.PublicKey
+		}
+		if len(keyConf.PublicKeyFile) != 0 {
+			pemBytes, err := os.ReadFile(keyConf.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			if key.PrivateKey != nil && !publicKey.Equal(key.PublicKey) {
+				return nil, errors.New("the public key does not match the private key")
+			}
+			key.PublicKey = publicKey
+		}
+
+	case configs.TOKEN_ALG_EDDSA:
+		key.Method = jwt.SigningMethodEdDSA
+		if len(keyConf.PrivateKeyFile) != 0 {
+			pemBytes, err := os.ReadFile(keyConf.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			key.PrivateKey = privateKey
+			key.PublicKey = privateKey.(ed25519.PrivateKey).Public()
+		}
+		if len(keyConf.PublicKeyFile) != 0 {
+			pemBytes, err := os.ReadFile(keyConf.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			if key.PrivateKey != nil && !publicKey.(ed25519.PublicKey).Equal(key.PublicKey) {
+				return nil, errors.New("the public key does not match the private key")
+			}
+			key.PublicKey = publicKey
+		}
+
+	default:
+		return nil, errors.New("the signing algorithm does not exist")
+	}
+
+	if key.PublicKey == nil {
+		return nil, errors.New("neither private key nor public key is configured")
+	}
+
+	return key, nil
+}
+
+func genEphemeralKey() (*SigningKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	kidBytes := make([]byte, 8)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		Kid:        "ephemeral-" + hex.EncodeToString(kidBytes),
+		Method:     jwt.SigningMethodEdDSA,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	}, nil
+}
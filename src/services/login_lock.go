@@ -0,0 +1,145 @@
+package services
+
+import (
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	LOCK_KEY_USER_PREFIX = "user:"
+
+	LOCK_KEY_IP_PREFIX = "ip:"
+)
+
+// CheckLoginLocked return the time until which the user or ip is locked, 0 means not locked
+func (s *Server) CheckLoginLocked(userName, ip string) (int64, error) {
+	var locks []*db.LoginLock
+	err := s.gormDb.Model(&db.LoginLock{}).
+		Where("lock_key IN ? AND locked_until > ?", s.getLockKeys(userName, ip),
+			time.Now().Unix()).
+		Find(&locks).Error
+	if err != nil {
+		s.sulog.Infof("query login lock failed, err: [%s]\n", err.Error())
+		return 0, err
+	}
+
+	var lockedUntil int64
+	for _, l := range locks {
+		if l.LockedUntil > lockedUntil {
+			lockedUntil = l.LockedUntil
+		}
+	}
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure count the failure of the user and ip, lock them
+// when the failures within the window reach the limit. The count is changed by
+// conditional updates in the database, concurrent failures are all counted
+func (s *Server) RecordLoginFailure(userName, ip string) error {
+	for lockType, target := range s.getLockTargets(userName, ip) {
+		lockKey := getLockKey(lockType, target)
+		err := s.recordLockFailure(lockType, target, lockKey)
+		if err != nil {
+			s.sulog.Infof("record login failure failed, err: [%s], key: [%s]\n",
+				err.Error(), lockKey)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) recordLockFailure(lockType db.LockType, target, lockKey string) error {
+	limitConf := s.config.LoginConfig
+	now := time.Now()
+
+	// 第一次失败时插入计数行，并发插入时只有一个成功
+	err := s.gormDb.Clauses(clause.OnConflict{DoNothing: true}).Create(&db.LoginLock{
+		LockKey:       lockKey,
+		LockType:      lockType,
+		LockTarget:    target,
+		FirstFailTime: now.Unix(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	// 超出统计窗口且未处于锁定中，重新计数
+	err = s.gormDb.Model(&db.LoginLock{}).
+		Where("lock_key = ? AND first_fail_time < ? AND locked_until <= ?",
+			lockKey, now.Add(-limitConf.FailWindow).Unix(), now.Unix()).
+		Updates(map[string]interface{}{
+			"fail_count":      0,
+			"first_fail_time": now.Unix(),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = s.gormDb.Model(&db.LoginLock{}).Where("lock_key = ?", lockKey).
+		Update("fail_count", gorm.Expr("fail_count + 1")).Error
+	if err != nil {
+		return err
+	}
+
+	lock := new(db.LoginLock)
+	err = s.gormDb.Where("lock_key = ?", lockKey).First(lock).Error
+	if err != nil {
+		return err
+	}
+
+	if lock.FailCount < limitConf.MaxFailures {
+		return nil
+	}
+
+	return s.gormDb.Model(&db.LoginLock{}).Where("lock_key = ?", lockKey).
+		Update("locked_until", now.Add(limitConf.LockDuration).Unix()).Error
+}
+
+// ClearLoginFailures reset the failure count of the user after a successful login,
+// the ip count is kept so that one valid account can not unlock a guessing ip
+func (s *Server) ClearLoginFailures(userName string) error {
+	return s.ClearLoginLock(getLockKey(db.LOCK_USER, userName))
+}
+
+// ClearLoginLock delete the lock and the failure count
+func (s *Server) ClearLoginLock(lockKey string) error {
+	err := s.gormDb.Where("lock_key = ?", lockKey).Delete(&db.LoginLock{}).Error
+	if err != nil {
+		s.sulog.Infof("clear login lock failed, err: [%s], key: [%s]\n",
+			err.Error(), lockKey)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) getLockTargets(userName, ip string) map[db.LockType]string {
+	targets := make(map[db.LockType]string)
+	mode := s.config.LoginConfig.LockMode
+	if mode == configs.LOCK_MODE_USER || mode == configs.LOCK_MODE_BOTH {
+		targets[db.LOCK_USER] = userName
+	}
+	if mode == configs.LOCK_MODE_IP || mode == configs.LOCK_MODE_BOTH {
+		targets[db.LOCK_IP] = ip
+	}
+	return targets
+}
+
+func (s *Server) getLockKeys(userName, ip string) []string {
+	keys := make([]string, 0)
+	for lockType, target := range s.getLockTargets(userName, ip) {
+		keys = append(keys, getLockKey(lockType, target))
+	}
+	return keys
+}
+
+func getLockKey(lockType db.LockType, target string) string {
+	if lockType == db.LOCK_IP {
+		return LOCK_KEY_IP_PREFIX + target
+	}
+	return LOCK_KEY_USER_PREFIX + target
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailureConcurrentSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+	s.config = &configs.Config{LoginConfig: &configs.LoginLimitConfig{
+		MaxFailures:  5,
+		FailWindow:   time.Minute,
+		LockDuration: time.Hour,
+		LockMode:     configs.LOCK_MODE_BOTH,
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Nil(t, s.RecordLoginFailure("u1", "10.0.0.1"))
+		}()
+	}
+	wg.Wait()
+
+	for _, lockKey := range []string{"user:u1", "ip:10.0.0.1"} {
+		lock := new(db.LoginLock)
+		require.Nil(t, s.QueryObjectByCondition(lock, "lock_key", lockKey))
+		require.Equal(t, int32(20), lock.FailCount)
+		require.Greater(t, lock.LockedUntil, time.Now().Unix())
+	}
+
+	lockedUntil, err := s.CheckLoginLocked("u1", "10.0.0.2")
+	require.Nil(t, err)
+	require.Greater(t, lockedUntil, time.Now().Unix())
+}
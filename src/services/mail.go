@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"go-web-demo/src/configs"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MailSender send a plain text mail
+type MailSender interface {
+	SendMail(to []string, subject, body string) error
+}
+
+type SMTPSender struct {
+	conf *configs.MailConfig
+}
+
+// NewSMTPSender return nil when the smtp host is not configured
+func NewSMTPSender(mailConf *configs.MailConfig) MailSender {
+	if len(mailConf.Host) == 0 {
+		return nil
+	}
+	return &SMTPSender{conf: mailConf}
+}
+
+func (m *SMTPSender) SendMail(to []string, subject, body string) error {
+	if len(to) == 0 {
+		return errors.New("the mail recipient is empty")
+	}
+
+	addr := net.JoinHostPort(m.conf.Host, strconv.Itoa(m.conf.Port))
+
+	var conn net.Conn
+	var err error
+	if m.conf.UseTLS {
+		conn, err = tls.Dial("tcp", addr, &tls.Config{ServerName: m.conf.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 10*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !m.conf.UseTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			err = client.StartTLS(&tls.Config{ServerName: m.conf.Host})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(m.conf.UserName) != 0 {
+		err = client.Auth(smtp.PlainAuth("", m.conf.UserName, m.conf.Password, m.conf.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(m.conf.From)
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range to {
+		err = client.Rcpt(addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buildMail(m.conf.From, to, subject, body))
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func buildMail(from string, to []string, subject, body string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bufio"
+	"go-web-demo/src/configs"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub accept one connection and record the envelope and the message
+type smtpStub struct {
+	listener net.Listener
+	from     string
+	rcpt     []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	stub := &smtpStub{listener: l, done: make(chan struct{})}
+	go stub.serve()
+	return stub
+}
+
+func (m *smtpStub) serve() {
+	defer close(m.done)
+
+	conn, err := m.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost stub")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			m.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			m.rcpt = append(m.rcpt, strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>"))
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			m.data = data.String()
+			reply("250 ok")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	stub := newSMTPStub(t)
+	defer stub.listener.Close()
+
+	host, port, err := net.SplitHostPort(stub.listener.Addr().String())
+	require.Nil(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.Nil(t, err)
+
+	sender := NewSMTPSender(&configs.MailConfig{
+		Host: host,
+		Port: portNum,
+		From: "noreply@example.com",
+	})
+	require.NotNil(t, sender)
+
+	err = sender.SendMail([]string{"user@example.com"}, "找回密码", "token: abc\n")
+	require.Nil(t, err)
+	<-stub.done
+
+	require.Equal(t, "noreply@example.com", stub.from)
+	require.Equal(t, []string{"user@example.com"}, stub.rcpt)
+	require.Contains(t, stub.data, "To: user@example.com\r\n")
+	require.Contains(t, stub.data, "Subject: =?UTF-8?b?")
+	require.Contains(t, stub.data, "\r\n\r\ntoken: abc\r\n")
+
+	require.Nil(t, NewSMTPSender(&configs.MailConfig{}))
+}
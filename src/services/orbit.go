@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 轨道根数的单位：半长轴为千米，倾角、升交点赤经和近地点幅角为度
+const (
+	// EARTH_RADIUS_KM 地球赤道半径
+	EARTH_RADIUS_KM = 6378.137
+
+	// EARTH_MU 地球引力常数，km³/s²
+	EARTH_MU = 398600.4418
+
+	// GEO_SEMI_MAJOR_AXIS_KM 地球静止轨道半长轴，允许的偏差为 GEO_TOLERANCE_KM
+	GEO_SEMI_MAJOR_AXIS_KM = 42164.0
+	GEO_TOLERANCE_KM       = 500.0
+
+	// LEO 的远地点高度上限
+	LEO_MAX_ALTITUDE_KM = 2000.0
+
+	// 偏心率不小于该值时视为大椭圆轨道
+	HEO_MIN_ECCENTRICITY = 0.25
+
+	// GEO 的偏心率上限
+	GEO_MAX_ECCENTRICITY = 0.01
+)
+
+// 轨道分类
+const (
+	ORBIT_CLASS_LEO = "LEO"
+	ORBIT_CLASS_MEO = "MEO"
+	ORBIT_CLASS_GEO = "GEO"
+	ORBIT_CLASS_HEO = "HEO"
+)
+
+var orbitClasses = []string{ORBIT_CLASS_LEO, ORBIT_CLASS_MEO, ORBIT_CLASS_GEO, ORBIT_CLASS_HEO}
+
+// OrbitParams 由半长轴和偏心率推导的轨道参数
+type OrbitParams struct {
+	// PeriodMinutes 轨道周期，分钟
+	PeriodMinutes float64
+	// ApogeeAltitudeKm 远地点高度，千米
+	ApogeeAltitudeKm float64
+	// PerigeeAltitudeKm 近地点高度，千米
+	PerigeeAltitudeKm float64
+	// MeanMotion 平均运动，圈/天
+	MeanMotion float64
+	Class      string
+}
+
+// CheckOrbitElements the keplerian elements must describe a closed orbit above the earth surface
+func CheckOrbitElements(semiMajorAxis, eccentricity, inclination,
+	ascendingNodeLongitude, argumentOfPerigee float64) error {
+
+	for _, v := range []float64{semiMajorAxis, eccentricity, inclination,
+		ascendingNodeLongitude, argumentOfPerigee} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("the orbit elements must be finite numbers")
+		}
+	}
+
+	if eccentricity < 0 || eccentricity >= 1 {
+		return errors.New("the orbit eccentricity must be in [0, 1)")
+	}
+
+	if semiMajorAxis*(1-eccentricity) <= EARTH_RADIUS_KM {
+		return fmt.Errorf("the perigee radius must be above the earth radius %.3f km", EARTH_RADIUS_KM)
+	}
+
+	if inclination < 0 || inclination > 180 {
+		return errors.New("the orbit angle (inclination) must be in [0, 180] degrees")
+	}
+
+	if ascendingNodeLongitude < 0 || ascendingNodeLongitude >= 360 {
+		return errors.New("the ascending node longitude must be in [0, 360) degrees")
+	}
+
+	if argumentOfPerigee < 0 || argumentOfPerigee >= 360 {
+		return errors.New("the perigee (argument of perigee) must be in [0, 360) degrees")
+	}
+
+	return nil
+}
+
+// DeriveOrbitParams nil is returned if the orbit is not closed or under the earth surface,
+// the orbits saved before the validation may be invalid
+func DeriveOrbitParams(semiMajorAxis, eccentricity float64) *OrbitParams {
+	if eccentricity < 0 || eccentricity >= 1 || semiMajorAxis*(1-eccentricity) <= EARTH_RADIUS_KM {
+		return nil
+	}
+
+	periodSeconds := 2 * math.Pi * math.Sqrt(math.Pow(semiMajorAxis, 3)/EARTH_MU)
+	params := &OrbitParams{
+		PeriodMinutes:     periodSeconds / 60,
+		ApogeeAltitudeKm:  semiMajorAxis*(1+eccentricity) - EARTH_RADIUS_KM,
+		PerigeeAltitudeKm: semiMajorAxis*(1-eccentricity) - EARTH_RADIUS_KM,
+		MeanMotion:        86400 / periodSeconds,
+	}
+	params.Class = classifyOrbit(semiMajorAxis, eccentricity, params.ApogeeAltitudeKm)
+	return params
+}
+
+func classifyOrbit(semiMajorAxis, eccentricity, apogeeAltitude float64) string {
+	switch {
+	case eccentricity >= HEO_MIN_ECCENTRICITY:
+		return ORBIT_CLASS_HEO
+	case apogeeAltitude <= LEO_MAX_ALTITUDE_KM:
+		return ORBIT_CLASS_LEO
+	case math.Abs(semiMajorAxis-GEO_SEMI_MAJOR_AXIS_KM) <= GEO_TOLERANCE_KM &&
+		eccentricity < GEO_MAX_ECCENTRICITY:
+		return ORBIT_CLASS_GEO
+	case semiMajorAxis < GEO_SEMI_MAJOR_AXIS_KM:
+		return ORBIT_CLASS_MEO
+	default:
+		// 高于地球静止轨道
+		return ORBIT_CLASS_HEO
+	}
+}
+
+// CheckOrbitType the free-text orbit type naming one of the classes must be the derived class,
+// other texts are not checked
+func CheckOrbitType(orbitType, class string) error {
+	upper := strings.ToUpper(orbitType)
+	for _, c := range orbitClasses {
+		if strings.Contains(upper, c) && c != class {
+			return fmt.Errorf("the orbit type %s is not the same as the elements: %s", orbitType, class)
+		}
+	}
+	return nil
+}
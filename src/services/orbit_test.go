@@ -0,0 +1,50 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOrbitElements(t *testing.T) {
+	require.Nil(t, CheckOrbitElements(6778, 0.001, 51.6, 120, 90))
+	require.Nil(t, CheckOrbitElements(42164, 0, 0, 0, 0))
+
+	// 偏心率、半长轴和角度越界
+	require.NotNil(t, CheckOrbitElements(6778, 5, 51.6, 120, 90))
+	require.NotNil(t, CheckOrbitElements(6778, -0.1, 51.6, 120, 90))
+	require.NotNil(t, CheckOrbitElements(-6778, 0, 51.6, 120, 90))
+	require.NotNil(t, CheckOrbitElements(7000, 0.2, 51.6, 120, 90))
+	require.NotNil(t, CheckOrbitElements(6778, 0, 181, 120, 90))
+	require.NotNil(t, CheckOrbitElements(6778, 0, 51.6, 360, 90))
+	require.NotNil(t, CheckOrbitElements(6778, 0, 51.6, 120, -1))
+	require.NotNil(t, CheckOrbitElements(math.NaN(), 0, 51.6, 120, 90))
+}
+
+func TestDeriveOrbitParams(t *testing.T) {
+	// 国际空间站附近的轨道，周期约 92.6 分钟
+	params := DeriveOrbitParams(6778, 0.001)
+	require.InDelta(t, 92.56, params.PeriodMinutes, 0.05)
+	require.InDelta(t, 15.56, params.MeanMotion, 0.01)
+	require.InDelta(t, 406.64, params.ApogeeAltitudeKm, 0.01)
+	require.InDelta(t, 393.09, params.PerigeeAltitudeKm, 0.01)
+	require.Equal(t, ORBIT_CLASS_LEO, params.Class)
+
+	// 地球静止轨道的周期为一个恒星日
+	params = DeriveOrbitParams(42164, 0)
+	require.InDelta(t, 1436.07, params.PeriodMinutes, 0.1)
+	require.Equal(t, ORBIT_CLASS_GEO, params.Class)
+
+	require.Equal(t, ORBIT_CLASS_MEO, DeriveOrbitParams(26560, 0.01).Class)
+	require.Equal(t, ORBIT_CLASS_HEO, DeriveOrbitParams(26600, 0.74).Class)
+	require.Equal(t, ORBIT_CLASS_HEO, DeriveOrbitParams(60000, 0).Class)
+	require.Nil(t, DeriveOrbitParams(6000, 0))
+	require.Nil(t, DeriveOrbitParams(6778, 1))
+}
+
+func TestCheckOrbitType(t *testing.T) {
+	require.Nil(t, CheckOrbitType("leo", ORBIT_CLASS_LEO))
+	require.Nil(t, CheckOrbitType("太阳同步轨道", ORBIT_CLASS_LEO))
+	require.NotNil(t, CheckOrbitType("GEO", ORBIT_CLASS_LEO))
+}
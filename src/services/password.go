@@ -0,0 +1,284 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	BCRYPT_HASH_PREFIX = "$2"
+
+	ARGON2ID_HASH_PREFIX = "$argon2id$"
+)
+
+// HashPassword hash the password with the algorithm set in the config
+func (s *Server) HashPassword(pwd string) (string, error) {
+	hashed, err := hashPassword(s.config.PwdConfig, pwd)
+	if err != nil {
+		s.sulog.Infof("hash password failed, err: [%s]\n", err.Error())
+		return "", err
+	}
+	return hashed, nil
+}
+
+// VerifyPassword check the password against the stored one, needRehash is true
+// when the stored value is plaintext or was hashed with outdated parameters
+func (s *Server) VerifyPassword(storedPwd, pwd string) (match, needRehash bool) {
+	return verifyPassword(s.config.PwdConfig, storedPwd, pwd)
+}
+
+func hashPassword(pwdConf *configs.PasswordConfig, pwd string) (string, error) {
+	switch pwdConf.Algorithm {
+	case configs.PWD_ALGORITHM_BCRYPT:
+		hashed, err := bcrypt.GenerateFromPassword([]byte(pwd), pwdConf.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+
+	case configs.PWD_ALGORITHM_ARGON2ID:
+		salt := make([]byte, pwdConf.Argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(pwd), salt, pwdConf.Argon2Time,
+			pwdConf.Argon2Memory, pwdConf.Argon2Threads, pwdConf.Argon2KeyLen)
+
+		return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", ARGON2ID_HASH_PREFIX,
+			argon2.Version, pwdConf.Argon2Memory, pwdConf.Argon2Time, pwdConf.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key)), nil
+
+	default:
+		return "", errors.New("the password algorithm does not exist")
+	}
+}
+
+func verifyPassword(pwdConf *configs.PasswordConfig, storedPwd, pwd string) (match, needRehash bool) {
+	switch {
+	case strings.HasPrefix(storedPwd, BCRYPT_HASH_PREFIX):
+		if bcrypt.CompareHashAndPassword([]byte(storedPwd), []byte(pwd)) != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost([]byte(storedPwd))
+		return true, err != nil || pwdConf.Algorithm != configs.PWD_ALGORITHM_BCRYPT ||
+			cost != pwdConf.BcryptCost
+
+	case strings.HasPrefix(storedPwd, ARGON2ID_HASH_PREFIX):
+		params, salt, key, err := decodeArgon2idHash(storedPwd)
+		if err != nil {
+			return false, false
+		}
+		otherKey := argon2.IDKey([]byte(pwd), salt, params.Argon2Time,
+			params.Argon2Memory, params.Argon2Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+			return false, false
+		}
+		return true, pwdConf.Algorithm != configs.PWD_ALGORITHM_ARGON2ID ||
+			params.Argon2Time != pwdConf.Argon2Time ||
+			params.Argon2Memory != pwdConf.Argon2Memory ||
+			params.Argon2Threads != pwdConf.Argon2Threads ||
+			uint32(len(key)) != pwdConf.Argon2KeyLen ||
+			uint32(len(salt)) != pwdConf.Argon2SaltLen
+
+	default:
+		// 历史数据中的明文密码，校验通过后需要升级为哈希
+		if subtle.ConstantTimeCompare([]byte(storedPwd), []byte(pwd)) != 1 {
+			return false, false
+		}
+		return true, true
+	}
+}
+
+// decodeArgon2idHash parse the hash like $argon2id$v=19$m=65536,t=3,p=2$salt$key
+func decodeArgon2idHash(hashed string) (*configs.PasswordConfig, []byte, []byte, error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, errors.New("incompatible argon2 version")
+	}
+
+	params := new(configs.PasswordConfig)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d",
+		&params.Argon2Memory, &params.Argon2Time, &params.Argon2Threads); err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return params, salt, key, nil
+}
+
+// 同一用户发送找回密码邮件的最小间隔
+const PWD_RESET_MAIL_INTERVAL = time.Minute
+
+// UpdateUserPwd hash and save the new password, all the tokens of the user are revoked
+func (s *Server) UpdateUserPwd(userId int32, pwd string) error {
+	hashedPwd, err := s.HashPassword(pwd)
+	if err != nil {
+		return err
+	}
+
+	err = s.UpdateObject(&db.User{
+		GeneralField: db.GeneralField{Id: userId},
+		UserPwd:      hashedPwd,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.RevokeAllUserTokens(userId)
+}
+
+// MailEnabled whether the mail sender is configured
+func (s *Server) MailEnabled() bool {
+	return s.mail != nil
+}
+
+// SendPwdResetMail mail a one-time reset token to the user,
+// the unused tokens sent before are invalidated
+func (s *Server) SendPwdResetMail(user *db.User) error {
+	if s.mail == nil {
+		return errors.New("the mail sender is not configured")
+	}
+
+	if len(user.UserEmail) == 0 {
+		return errors.New("the user email is empty")
+	}
+
+	now := time.Now()
+	var count int64
+	err := s.gormDb.Model(&db.PwdResetToken{}).
+		Where("user_id = ? AND last_time > ?", user.Id,
+			now.Add(-PWD_RESET_MAIL_INTERVAL).UnixMilli()).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return errors.New("the password reset mail is sent too frequently")
+	}
+
+	err = s.gormDb.Model(&db.PwdResetToken{}).
+		Where("user_id = ? AND used_at = 0", user.Id).
+		Update("used_at", now.Unix()).Error
+	if err != nil {
+		return err
+	}
+
+	token, err := genRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	userConf := s.config.UserConfig
+	err = s.InsertOneObjertToDB(&db.PwdResetToken{
+		UserId:    user.Id,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(userConf.PwdResetExpire).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := token
+	if len(userConf.PwdResetUrl) != 0 {
+		sep := "?"
+		if strings.Contains(userConf.PwdResetUrl, "?") {
+			sep = "&"
+		}
+		link = userConf.PwdResetUrl + sep + "token=" + url.QueryEscape(token)
+	}
+
+	body := fmt.Sprintf("%s，您好：\n\n您正在找回密码，请在 %d 分钟内使用以下链接或令牌重置密码：\n\n%s\n\n"+
+		"如果这不是您本人的操作，请忽略本邮件。\n", user.UserNickName,
+		int(userConf.PwdResetExpire.Minutes()), link)
+
+	err = s.mail.SendMail([]string{user.UserEmail}, "找回密码", body)
+	if err != nil {
+		s.sulog.Infof("send password reset mail failed, err: [%s], user: [%s]\n",
+			err.Error(), user.UserName)
+		return err
+	}
+	return nil
+}
+
+// ErrPwdResetTokenInvalid the reset token does not exist, is used or is expired
+var ErrPwdResetTokenInvalid = errors.New("the reset token is invalid or expired")
+
+// ResetPwdWithToken consume the reset token and set the new password in one transaction,
+// the token can still be used if the password is not updated
+func (s *Server) ResetPwdWithToken(token, pwd string) (*db.User, error) {
+	resetToken := new(db.PwdResetToken)
+	now := time.Now().Unix()
+	err := s.gormDb.Where("token_hash = ? AND used_at = 0 AND expires_at > ?",
+		hashToken(token), now).First(resetToken).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrPwdResetTokenInvalid
+	} else if err != nil {
+		return nil, err
+	}
+
+	user := new(db.User)
+	err = s.QueryObjectById(user, resetToken.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPwd, err := s.HashPassword(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.gormDb.Transaction(func(tx *gorm.DB) error {
+		// 条件更新，保证 token 只能使用一次
+		result := tx.Model(&db.PwdResetToken{}).
+			Where("id = ? AND used_at = 0", resetToken.Id).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPwdResetTokenInvalid
+		}
+
+		err := tx.Model(&db.User{}).Where("id = ?", user.Id).Update("user_pwd", hashedPwd).Error
+		if err != nil {
+			return err
+		}
+		return revokeAllUserTokens(tx, user.Id, now)
+	})
+	if err != nil {
+		s.sulog.Infof("reset password failed, err: [%s], user: [%s]\n", err.Error(), user.UserName)
+		return nil, err
+	}
+
+	_ = s.ClearLoginFailures(user.UserName)
+	return user, nil
+}
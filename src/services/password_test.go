@@ -0,0 +1,98 @@
+package services
+
+import (
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	bcryptConf := &configs.PasswordConfig{
+		Algorithm:  configs.PWD_ALGORITHM_BCRYPT,
+		BcryptCost: 4,
+	}
+
+	argon2Conf := &configs.PasswordConfig{
+		Algorithm:     configs.PWD_ALGORITHM_ARGON2ID,
+		Argon2Time:    1,
+		Argon2Memory:  1024,
+		Argon2Threads: 1,
+		Argon2KeyLen:  32,
+		Argon2SaltLen: 16,
+	}
+
+	for _, conf := range []*configs.PasswordConfig{bcryptConf, argon2Conf} {
+		hashed, err := hashPassword(conf, "1234")
+		require.Nil(t, err)
+		require.NotEqual(t, "1234", hashed)
+
+		match, needRehash := verifyPassword(conf, hashed, "1234")
+		require.True(t, match)
+		require.False(t, needRehash)
+
+		match, _ = verifyPassword(conf, hashed, "12345")
+		require.False(t, match)
+	}
+
+	// 切换算法后，旧哈希仍然可以校验，但需要重新哈希
+	hashed, err := hashPassword(bcryptConf, "1234")
+	require.Nil(t, err)
+	match, needRehash := verifyPassword(argon2Conf, hashed, "1234")
+	require.True(t, match)
+	require.True(t, needRehash)
+}
+
+func TestVerifyPlaintextPassword(t *testing.T) {
+	conf := &configs.PasswordConfig{
+		Algorithm:  configs.PWD_ALGORITHM_BCRYPT,
+		BcryptCost: 4,
+	}
+
+	match, needRehash := verifyPassword(conf, "1234", "1234")
+	require.True(t, match)
+	require.True(t, needRehash)
+
+	match, _ = verifyPassword(conf, "1234", "4321")
+	require.False(t, match)
+}
+
+func TestResetPwdWithTokenSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+	s.config = &configs.Config{PwdConfig: &configs.PasswordConfig{Algorithm: "unknown"}}
+
+	user := &db.User{UserName: "u1", UserPwd: "old"}
+	require.Nil(t, s.InsertOneObjertToDB(user))
+	require.Nil(t, s.InsertOneObjertToDB(&db.PwdResetToken{
+		UserId:    user.Id,
+		TokenHash: hashToken("t1"),
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}))
+
+	// 密码没有更新时 token 不能被消耗
+	_, err := s.ResetPwdWithToken("t1", "1234")
+	require.NotNil(t, err)
+	require.NotEqual(t, ErrPwdResetTokenInvalid, err)
+
+	got := new(db.User)
+	require.Nil(t, s.QueryObjectById(got, user.Id))
+	require.Equal(t, "old", got.UserPwd)
+
+	s.config.PwdConfig = &configs.PasswordConfig{
+		Algorithm:  configs.PWD_ALGORITHM_BCRYPT,
+		BcryptCost: 4,
+	}
+	_, err = s.ResetPwdWithToken("t1", "1234")
+	require.Nil(t, err)
+
+	got = new(db.User)
+	require.Nil(t, s.QueryObjectById(got, user.Id))
+	match, _ := s.VerifyPassword(got.UserPwd, "1234")
+	require.True(t, match)
+	require.Equal(t, user.TokenVersion+1, got.TokenVersion)
+
+	_, err = s.ResetPwdWithToken("t1", "5678")
+	require.Equal(t, ErrPwdResetTokenInvalid, err)
+}
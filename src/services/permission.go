@@ -0,0 +1,414 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 权限，格式为 资源:操作
+const (
+	PERM_ALL = "*"
+
+	PERM_DEBRIS_READ  = "debris:read"
+	PERM_DEBRIS_WRITE = "debris:write"
+
+	PERM_INSTRUCTION_READ    = "instruction:read"
+	PERM_INSTRUCTION_WRITE   = "instruction:write"
+	PERM_INSTRUCTION_EXECUTE = "instruction:execute"
+
+	PERM_ORBIT_READ  = "orbit:read"
+	PERM_ORBIT_WRITE = "orbit:write"
+
+	PERM_CONSTELLATION_READ  = "constellation:read"
+	PERM_CONSTELLATION_WRITE = "constellation:write"
+
+	PERM_SATELLITE_READ  = "satellite:read"
+	PERM_SATELLITE_WRITE = "satellite:write"
+
+	PERM_SATELLITE_STATE_READ  = "satellitestate:read"
+	PERM_SATELLITE_STATE_WRITE = "satellitestate:write"
+
+	PERM_CONTROLS_READ  = "controls:read"
+	PERM_CONTROLS_WRITE = "controls:write"
+
+	PERM_FAULT_READ  = "fault:read"
+	PERM_FAULT_WRITE = "fault:write"
+
+	PERM_NET_STATE_READ  = "netstate:read"
+	PERM_NET_STATE_WRITE = "netstate:write"
+
+	PERM_COMM_STATE_READ  = "commstate:read"
+	PERM_COMM_STATE_WRITE = "commstate:write"
+
+	PERM_OPERATION_READ = "operation:read"
+
+	PERM_LOGIN_LOG_READ = "loginlog:read"
+
+	// PERM_TRACE_READ 溯源系统查看链上记录
+	PERM_TRACE_READ = "trace:read"
+
+	PERM_USER_READ  = "user:read"
+	PERM_USER_WRITE = "user:write"
+
+	PERM_INVITE_CODE_READ  = "invitecode:read"
+	PERM_INVITE_CODE_WRITE = "invitecode:write"
+
+	PERM_LOGIN_LOCK_READ  = "loginlock:read"
+	PERM_LOGIN_LOCK_WRITE = "loginlock:write"
+
+	PERM_ROLE_READ  = "role:read"
+	PERM_ROLE_WRITE = "role:write"
+
+	PERM_API_KEY_READ  = "apikey:read"
+	PERM_API_KEY_WRITE = "apikey:write"
+)
+
+// PermissionName 所有可分配的权限及说明
+var PermissionName = map[string]string{
+	PERM_ALL:                   "全部权限",
+	PERM_DEBRIS_READ:           "查看碎片",
+	PERM_DEBRIS_WRITE:          "添加碎片",
+	PERM_INSTRUCTION_READ:      "查看指令",
+	PERM_INSTRUCTION_WRITE:     "生成指令",
+	PERM_INSTRUCTION_EXECUTE:   "执行指令",
+	PERM_ORBIT_READ:            "查看轨道",
+	PERM_ORBIT_WRITE:           "添加轨道",
+	PERM_CONSTELLATION_READ:    "查看星座",
+	PERM_CONSTELLATION_WRITE:   "添加星座",
+	PERM_SATELLITE_READ:        "查看卫星目录",
+	PERM_SATELLITE_WRITE:       "管理卫星目录",
+	PERM_SATELLITE_STATE_READ:  "查看卫星状态",
+	PERM_SATELLITE_STATE_WRITE: "上报卫星状态",
+	PERM_CONTROLS_READ:         "查看卫星控制信息",
+	PERM_CONTROLS_WRITE:        "上报卫星控制信息",
+	PERM_FAULT_READ:            "查看故障",
+	PERM_FAULT_WRITE:           "上报故障",
+	PERM_NET_STATE_READ:        "查看网络状态",
+	PERM_NET_STATE_WRITE:       "上报网络状态",
+	PERM_COMM_STATE_READ:       "查看通信状态",
+	PERM_COMM_STATE_WRITE:      "上报通信状态",
+	PERM_OPERATION_READ:        "查看操作记录",
+	PERM_LOGIN_LOG_READ:        "查看登录日志",
+	PERM_TRACE_READ:            "溯源查询",
+	PERM_USER_READ:             "查看用户",
+	PERM_USER_WRITE:            "管理用户",
+	PERM_INVITE_CODE_READ:      "查看邀请码",
+	PERM_INVITE_CODE_WRITE:     "生成邀请码",
+	PERM_LOGIN_LOCK_READ:       "查看登录锁定",
+	PERM_LOGIN_LOCK_WRITE:      "解除登录锁定",
+	PERM_ROLE_READ:             "查看角色",
+	PERM_ROLE_WRITE:            "管理角色",
+	PERM_API_KEY_READ:          "查看 API Key",
+	PERM_API_KEY_WRITE:         "管理服务账号与 API Key",
+}
+
+// defaultRolePermissions 内置角色的初始权限，与原先按角色硬编码的校验一致
+var defaultRolePermissions = map[db.UserRoleType][]string{
+	db.CONTROL: {
+		PERM_DEBRIS_READ, PERM_DEBRIS_WRITE,
+		PERM_INSTRUCTION_READ, PERM_INSTRUCTION_WRITE,
+		PERM_ORBIT_READ, PERM_ORBIT_WRITE,
+		PERM_CONSTELLATION_READ, PERM_CONSTELLATION_WRITE,
+		PERM_SATELLITE_READ, PERM_SATELLITE_WRITE,
+		PERM_OPERATION_READ, PERM_LOGIN_LOG_READ,
+	},
+	db.EXEC: {
+		PERM_INSTRUCTION_EXECUTE, PERM_SATELLITE_READ,
+		PERM_SATELLITE_STATE_READ, PERM_SATELLITE_STATE_WRITE,
+		PERM_CONTROLS_READ, PERM_CONTROLS_WRITE,
+		PERM_FAULT_READ, PERM_FAULT_WRITE,
+		PERM_NET_STATE_READ, PERM_NET_STATE_WRITE,
+		PERM_COMM_STATE_READ, PERM_COMM_STATE_WRITE,
+	},
+	db.TRACE: {
+		PERM_TRACE_READ, PERM_FAULT_READ, PERM_LOGIN_LOG_READ,
+	},
+	db.MONITOR: {},
+	db.ADMIN: {
+		PERM_ALL,
+	},
+}
+
+// 权限缓存的有效期，角色变更时会主动清除，有效期用于多实例部署时的最终一致
+const PERMISSION_CACHE_TTL = time.Minute
+
+type userPermissions struct {
+	roles       []string
+	permissions map[string]bool
+	expiresAt   time.Time
+}
+
+type permissionCache struct {
+	sync.RWMutex
+	users map[int32]*userPermissions
+}
+
+func newPermissionCache() *permissionCache {
+	return &permissionCache{
+		users: make(map[int32]*userPermissions),
+	}
+}
+
+func (p *permissionCache) get(userId int32) (*userPermissions, bool) {
+	p.RLock()
+	defer p.RUnlock()
+	up, ok := p.users[userId]
+	if !ok || time.Now().After(up.expiresAt) {
+		return nil, false
+	}
+	return up, true
+}
+
+func (p *permissionCache) set(userId int32, up *userPermissions) {
+	p.Lock()
+	defer p.Unlock()
+	p.users[userId] = up
+}
+
+func (p *permissionCache) invalidate(userId int32) {
+	p.Lock()
+	defer p.Unlock()
+	delete(p.users, userId)
+}
+
+func (p *permissionCache) invalidateAll() {
+	p.Lock()
+	defer p.Unlock()
+	p.users = make(map[int32]*userPermissions)
+}
+
+// InitRoles create the built-in roles that do not exist yet, and bind the users
+// without any role to the built-in role of their UserRole
+func (s *Server) InitRoles() error {
+	for roleType, perms := range defaultRolePermissions {
+		roleName := db.UserRoleTypeName[roleType]
+		err := s.QueryObjectByCondition(new(db.Role), "role_name", roleName)
+		if err == nil {
+			continue
+		}
+
+		err = s.InsertOneObjertToDB(&db.Role{
+			RoleName:    roleName,
+			Description: "内置角色",
+			Permissions: strings.Join(perms, ","),
+			BuiltIn:     true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var users []*db.User
+	err := s.gormDb.Model(&db.User{}).
+		Where("id NOT IN (?)", s.gormDb.Model(&db.UserRoleBinding{}).Select("user_id")).
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err = s.gormDb.Transaction(func(tx *gorm.DB) error {
+			return bindUserRoles(tx, user.Id, []string{db.UserRoleTypeName[user.UserRole]})
+		})
+		if err != nil {
+			s.sulog.Infof("bind the role of user failed, err: [%s], user: [%s]\n",
+				err.Error(), user.UserName)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUserPermissions the role names and permissions of the user, cached for a while
+func (s *Server) GetUserPermissions(userId int32) ([]string, map[string]bool, error) {
+	if up, ok := s.permCache.get(userId); ok {
+		return up.roles, up.permissions, nil
+	}
+
+	var roles []*db.Role
+	err := s.gormDb.Model(&db.Role{}).
+		Where("id IN (?)", s.gormDb.Model(&db.UserRoleBinding{}).
+			Select("role_id").Where("user_id = ?", userId)).
+		Find(&roles).Error
+	if err != nil {
+		s.sulog.Infof("query user permissions failed, err: [%s], user: [%d]\n",
+			err.Error(), userId)
+		return nil, nil, err
+	}
+
+	up := &userPermissions{
+		roles:       make([]string, 0, len(roles)),
+		permissions: make(map[string]bool),
+		expiresAt:   time.Now().Add(PERMISSION_CACHE_TTL),
+	}
+	for _, role := range roles {
+		up.roles = append(up.roles, role.RoleName)
+		for _, perm := range SplitPermissions(role.Permissions) {
+			up.permissions[perm] = true
+		}
+	}
+
+	s.permCache.set(userId, up)
+	return up.roles, up.permissions, nil
+}
+
+// HasPermission check whether the user holds any of the permissions
+func (s *Server) HasPermission(userId int32, perms ...string) (bool, error) {
+	_, userPerms, err := s.GetUserPermissions(userId)
+	if err != nil {
+		return false, err
+	}
+
+	if userPerms[PERM_ALL] {
+		return true, nil
+	}
+
+	for _, perm := range perms {
+		if userPerms[perm] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListRoles all the roles ordered by name
+func (s *Server) ListRoles() ([]*db.Role, error) {
+	var roles []*db.Role
+	err := s.gormDb.Model(&db.Role{}).Order("role_name").Find(&roles).Error
+	if err != nil {
+		s.sulog.Infof("list roles failed, err: [%s]\n", err.Error())
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *Server) AddRole(role *db.Role) error {
+	return s.InsertOneObjertToDB(role)
+}
+
+// UpdateRole change the description and permissions of the role,
+// all the cached permissions are dropped
+func (s *Server) UpdateRole(role *db.Role) error {
+	err := s.gormDb.Model(&db.Role{}).Where("id = ?", role.Id).
+		Updates(map[string]interface{}{
+			"description": role.Description,
+			"permissions": role.Permissions,
+		}).Error
+	if err != nil {
+		s.sulog.Infof("update role failed, err: [%s], role: [%s]\n",
+			err.Error(), role.RoleName)
+		return err
+	}
+
+	s.permCache.invalidateAll()
+	return nil
+}
+
+// DeleteRole delete the role and unbind it from all the users
+func (s *Server) DeleteRole(roleId int32) error {
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("role_id = ?", roleId).Delete(&db.UserRoleBinding{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", roleId).Delete(&db.Role{}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("delete role failed, err: [%s], role: [%d]\n",
+			err.Error(), roleId)
+		return err
+	}
+
+	s.permCache.invalidateAll()
+	return nil
+}
+
+// SetUserRoles replace all the roles of the user
+func (s *Server) SetUserRoles(userId int32, roleNames []string) error {
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&db.UserRoleBinding{}).Error
+		if err != nil {
+			return err
+		}
+
+		return bindUserRoles(tx, userId, roleNames)
+	})
+	if err != nil {
+		s.sulog.Infof("set user roles failed, err: [%s], user: [%d]\n",
+			err.Error(), userId)
+		return err
+	}
+
+	s.permCache.invalidate(userId)
+	return nil
+}
+
+// CheckPermissions return an error if any of the permissions is unknown
+func CheckPermissions(perms []string) error {
+	for _, perm := range perms {
+		if _, ok := PermissionName[perm]; !ok {
+			return errors.New("unknown permission: " + perm)
+		}
+	}
+	return nil
+}
+
+// JoinPermissions deduplicate and sort the permissions for storing
+func JoinPermissions(perms []string) string {
+	set := make(map[string]bool)
+	for _, perm := range perms {
+		set[strings.TrimSpace(perm)] = true
+	}
+
+	list := make([]string, 0, len(set))
+	for perm := range set {
+		if len(perm) != 0 {
+			list = append(list, perm)
+		}
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+func SplitPermissions(perms string) []string {
+	list := make([]string, 0)
+	for _, perm := range strings.Split(perms, ",") {
+		perm = strings.TrimSpace(perm)
+		if len(perm) != 0 {
+			list = append(list, perm)
+		}
+	}
+	return list
+}
+
+func bindUserRoles(tx *gorm.DB, userId int32, roleNames []string) error {
+	if len(roleNames) == 0 {
+		return nil
+	}
+
+	var roles []*db.Role
+	err := tx.Model(&db.Role{}).Where("role_name IN ?", roleNames).Find(&roles).Error
+	if err != nil {
+		return err
+	}
+	if len(roles) != len(roleNames) {
+		return errors.New("some of the roles not found")
+	}
+
+	bindings := make([]*db.UserRoleBinding, 0, len(roles))
+	for _, role := range roles {
+		bindings = append(bindings, &db.UserRoleBinding{
+			UserId: userId,
+			RoleId: role.Id,
+		})
+	}
+	return tx.Create(&bindings).Error
+}
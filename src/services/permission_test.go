@@ -0,0 +1,25 @@
+package services
+
+import (
+	"go-web-demo/src/db"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinAndSplitPermissions(t *testing.T) {
+	joined := JoinPermissions([]string{PERM_ORBIT_WRITE, " debris:read", PERM_ORBIT_WRITE, ""})
+	require.Equal(t, "debris:read,orbit:write", joined)
+	require.Equal(t, []string{PERM_DEBRIS_READ, PERM_ORBIT_WRITE}, SplitPermissions(joined))
+	require.Empty(t, SplitPermissions(""))
+}
+
+func TestDefaultRolePermissions(t *testing.T) {
+	for roleType := range db.UserRoleTypeName {
+		perms, ok := defaultRolePermissions[roleType]
+		require.True(t, ok, db.UserRoleTypeName[roleType])
+		require.Nil(t, CheckPermissions(perms))
+	}
+
+	require.NotNil(t, CheckPermissions([]string{"debris:delete"}))
+}
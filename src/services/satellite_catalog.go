@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+)
+
+// GetCatalogSatellite the satellite referenced by the requests must be in the catalog
+func (s *Server) GetCatalogSatellite(satelliteId string) (*db.SatelliteCatalog, error) {
+	satellite := new(db.SatelliteCatalog)
+	err := s.QueryObjectByCondition(satellite, "satellite_id", satelliteId)
+	if err != nil {
+		return nil, errors.New("the satellite is not in the catalog: " + satelliteId)
+	}
+	return satellite, nil
+}
+
+// CheckCatalogReferences the orbit and the constellation of the satellite must exist,
+// the constellation is optional
+func (s *Server) CheckCatalogReferences(orbitId, constellationId string) error {
+	err := s.QueryObjectByCondition(new(db.Orbit), "orbit_id", orbitId)
+	if err != nil {
+		return errors.New("the orbit does not exist: " + orbitId)
+	}
+
+	if len(constellationId) == 0 {
+		return nil
+	}
+
+	err = s.QueryObjectByCondition(new(db.Constellation), "constellation_id", constellationId)
+	if err != nil {
+		return errors.New("the constellation does not exist: " + constellationId)
+	}
+	return nil
+}
+
+// CountCatalogSatellites the number of satellites in the catalog referencing the orbit or
+// the constellation, they can not be deleted while referenced
+func (s *Server) CountCatalogSatellites(column, value string) (int64, error) {
+	var count int64
+	err := s.gormDb.Model(&db.SatelliteCatalog{}).Where(column+" = ?", value).Count(&count).Error
+	if err != nil {
+		s.sulog.Infof("count catalog satellites by [%s] failed, err:[%s]\n",
+			column, err.Error())
+		return 0, err
+	}
+	return count, nil
+}
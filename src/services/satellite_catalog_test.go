@@ -0,0 +1,47 @@
+package services
+
+import (
+	"go-web-demo/src/db"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogReferencesSqlite(t *testing.T) {
+	s := newSqliteTestServer(t)
+
+	require.Nil(t, s.InsertOneObjertToDB(&db.Orbit{OrbitId: "o1"}))
+	require.Nil(t, s.InsertOneObjertToDB(&db.Constellation{ConstellationId: "c1"}))
+
+	require.Nil(t, s.CheckCatalogReferences("o1", ""))
+	require.Nil(t, s.CheckCatalogReferences("o1", "c1"))
+	require.NotNil(t, s.CheckCatalogReferences("o2", ""))
+	require.NotNil(t, s.CheckCatalogReferences("o1", "c2"))
+
+	satellite := &db.SatelliteCatalog{
+		SatelliteId:     "s1",
+		SatelliteName:   "sat-1",
+		OrbitId:         "o1",
+		ConstellationId: "c1",
+		Status:          db.SATELLITE_IN_ORBIT,
+	}
+	require.Nil(t, s.InsertOneObjertToDB(satellite))
+
+	got, err := s.GetCatalogSatellite("s1")
+	require.Nil(t, err)
+	require.Equal(t, "sat-1", got.SatelliteName)
+
+	count, err := s.CountCatalogSatellites("orbit_id", "o1")
+	require.Nil(t, err)
+	require.Equal(t, int64(1), count)
+
+	// 删除后不再被引用，同一个 id 可以重新登记
+	require.Nil(t, s.DeleteObject(got))
+	_, err = s.GetCatalogSatellite("s1")
+	require.NotNil(t, err)
+
+	count, err = s.CountCatalogSatellites("orbit_id", "o1")
+	require.Nil(t, err)
+	require.Equal(t, int64(0), count)
+	require.Nil(t, s.InsertOneObjertToDB(&db.SatelliteCatalog{SatelliteId: "s1", OrbitId: "o1"}))
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"go-web-demo/src/db"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 碰撞筛查使用二体模型，位置为地心惯性系，单位为千米。
+// 碎片记录只有高度（千米）、速度（千米/秒）和倾角（度），按圆轨道处理，
+// 升交点赤经取 0，记录时刻位于升交点；速度为 0 时按高度计算圆轨道速度
+type vec3 [3]float64
+
+func (v vec3) distance(o vec3) float64 {
+	dx, dy, dz := v[0]-o[0], v[1]-o[1], v[2]-o[2]
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func deg2rad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// solveKepler the eccentric anomaly of the mean anomaly (rad)
+func solveKepler(meanAnomaly, eccentricity float64) float64 {
+	e := meanAnomaly
+	if eccentricity > 0.8 {
+		e = math.Pi
+	}
+	for i := 0; i < 50; i++ {
+		delta := (e - eccentricity*math.Sin(e) - meanAnomaly) / (1 - eccentricity*math.Cos(e))
+		e -= delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+	return e
+}
+
+// perifocalToInertial rotate the position in the orbit plane by the argument of perigee,
+// inclination and ascending node longitude (rad)
+func perifocalToInertial(x, y, inclination, ascendingNode, argumentOfPerigee float64) vec3 {
+	cosO, sinO := math.Cos(ascendingNode), math.Sin(ascendingNode)
+	cosW, sinW := math.Cos(argumentOfPerigee), math.Sin(argumentOfPerigee)
+	cosI, sinI := math.Cos(inclination), math.Sin(inclination)
+
+	return vec3{
+		x*(cosO*cosW-sinO*sinW*cosI) - y*(cosO*sinW+sinO*cosW*cosI),
+		x*(sinO*cosW+cosO*sinW*cosI) + y*(cosO*cosW*cosI-sinO*sinW),
+		x*(sinW*sinI) + y*(cosW*sinI),
+	}
+}
+
+// orbitPosition the position of the satellite dt seconds after the epoch of the mean anomaly (deg)
+func orbitPosition(orbit *db.Orbit, meanAnomaly, dt float64) vec3 {
+	a, e := orbit.OrbitSemiMajorAxis, orbit.OrbitEccentricity
+	n := math.Sqrt(EARTH_MU / (a * a * a))
+	ea := solveKepler(math.Mod(deg2rad(meanAnomaly)+n*dt, 2*math.Pi), e)
+
+	x := a * (math.Cos(ea) - e)
+	y := a * math.Sqrt(1-e*e) * math.Sin(ea)
+	return perifocalToInertial(x, y, deg2rad(orbit.OrbitAngle),
+		deg2rad(orbit.AscendingNodeLongitude), deg2rad(orbit.Perigee))
+}
+
+// debrisPosition the position of the debris dt seconds after its record time
+func debrisPosition(debris *db.Debris, dt float64) vec3 {
+	r := EARTH_RADIUS_KM + debris.Height
+	rate := math.Sqrt(EARTH_MU / (r * r * r))
+	if debris.Speed > 0 {
+		rate = debris.Speed / r
+	}
+
+	u := rate * dt
+	return perifocalToInertial(r*math.Cos(u), r*math.Sin(u), deg2rad(debris.Angle), 0, 0)
+}
+
+// closestApproach sample the distance in [start, end] and refine around every local minimum
+// of the samples with the golden section search, return the time and the distance of the
+// closest one. 相对速度可达十几千米每秒，一次采样间隔内会移动上百千米，
+// 距离最近的采样点不一定属于真正最近的那次接近
+func closestApproach(distance func(t float64) float64, start, end, step float64) (float64, float64) {
+	times, dists := []float64{start}, []float64{distance(start)}
+	for t := start + step; t < end+step; t += step {
+		if t > end {
+			t = end
+		}
+		times = append(times, t)
+		dists = append(dists, distance(t))
+	}
+
+	bestT, bestD := start, dists[0]
+	for i := range times {
+		if (i > 0 && dists[i] > dists[i-1]) || (i < len(times)-1 && dists[i] > dists[i+1]) {
+			continue
+		}
+
+		lo, hi := times[i], times[i]
+		if i > 0 {
+			lo = times[i-1]
+		}
+		if i < len(times)-1 {
+			hi = times[i+1]
+		}
+		if t, d := goldenSectionSearch(distance, lo, hi); d < bestD {
+			bestT, bestD = t, d
+		}
+		if dists[i] < bestD {
+			bestT, bestD = times[i], dists[i]
+		}
+	}
+	return bestT, bestD
+}
+
+// goldenSectionSearch the minimum of the distance in [lo, hi], which has only one minimum
+func goldenSectionSearch(distance func(t float64) float64, lo, hi float64) (float64, float64) {
+	ratio := (math.Sqrt(5) - 1) / 2
+	x1, x2 := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	d1, d2 := distance(x1), distance(x2)
+	for i := 0; i < 60 && hi-lo > 1e-3; i++ {
+		if d1 < d2 {
+			hi, x2, d2 = x2, x1, d1
+			x1 = hi - ratio*(hi-lo)
+			d1 = distance(x1)
+		} else {
+			lo, x1, d1 = x1, x2, d2
+			x2 = lo + ratio*(hi-lo)
+			d2 = distance(x2)
+		}
+	}
+
+	if d1 < d2 {
+		return x1, d1
+	}
+	return x2, d2
+}
+
+// ThreatOfMissDistance the threat degree by the configured thresholds
+func (s *Server) ThreatOfMissDistance(missDistanceKm float64) db.ThreatDegree {
+	screenConf := s.config.ScreenConf
+	switch {
+	case missDistanceKm <= screenConf.HighThresholdKm:
+		return db.HIGH
+	case missDistanceKm <= screenConf.LowThresholdKm:
+		return db.LOW
+	}
+	return db.NO
+}
+
+// StartConjunctionScreening screen on the timer and when it is triggered
+func (s *Server) StartConjunctionScreening() {
+	go func() {
+		ticker := time.NewTicker(s.config.ScreenConf.Interval)
+		defer ticker.Stop()
+		for {
+			_, err := s.ScreenConjunctions()
+			if err != nil {
+				s.sulog.Infof("screen conjunctions failed, err: [%s]\n", err.Error())
+			}
+
+			select {
+			case <-ticker.C:
+			case <-s.screenWake:
+			}
+		}
+	}()
+}
+
+// TriggerScreening screen again soon, such as the debris or the orbits changed
+func (s *Server) TriggerScreening() {
+	select {
+	case s.screenWake <- struct{}{}:
+	default:
+	}
+}
+
+// ScreenConjunctions propagate the satellites in the catalog and the debris over the
+// look-ahead window, keep the approaches within the low threshold, update the threat
+// of the unfinished instructions and generate the avoidance instructions by the policies
+func (s *Server) ScreenConjunctions() ([]*db.Conjunction, error) {
+	s.screenMu.Lock()
+	defer s.screenMu.Unlock()
+
+	screenConf := s.config.ScreenConf
+	now := time.Now()
+
+	satellites := make([]*db.SatelliteCatalog, 0)
+	err := s.gormDb.Where("status <> ?", db.SATELLITE_RETIRED).Find(&satellites).Error
+	if err != nil {
+		return nil, err
+	}
+
+	orbits := make([]*db.Orbit, 0)
+	err = s.gormDb.Find(&orbits).Error
+	if err != nil {
+		return nil, err
+	}
+	orbitById := make(map[string]*db.Orbit, len(orbits))
+	for _, orbit := range orbits {
+		orbitById[orbit.OrbitId] = orbit
+	}
+
+	satelliteIds := make([]string, 0, len(satellites))
+	for _, satellite := range satellites {
+		satelliteIds = append(satelliteIds, satellite.SatelliteId)
+	}
+	states := make([]*db.Satellite, 0)
+	err = s.latestBySatellite(&db.Satellite{}, satelliteIds).Find(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	stateById := make(map[string]*db.Satellite, len(states))
+	for _, state := range states {
+		stateById[state.SatelliteId] = state
+	}
+
+	debrisList := make([]*db.Debris, 0)
+	err = s.gormDb.Find(&debrisList).Error
+	if err != nil {
+		return nil, err
+	}
+
+	start := float64(now.UnixMilli()) / 1000
+	end := start + screenConf.LookAhead.Seconds()
+	step := screenConf.Step.Seconds()
+
+	conjunctions := make([]*db.Conjunction, 0)
+	for _, satellite := range satellites {
+		orbit, ok1 := orbitById[satellite.OrbitId]
+		state, ok2 := stateById[satellite.SatelliteId]
+		// 没有轨道或从未上报平近点角的卫星无法推算位置
+		if !ok1 || !ok2 || CheckOrbitElements(orbit.OrbitSemiMajorAxis, orbit.OrbitEccentricity,
+			orbit.OrbitAngle, orbit.AscendingNodeLongitude, orbit.Perigee) != nil {
+			continue
+		}
+
+		perigee := orbit.OrbitSemiMajorAxis * (1 - orbit.OrbitEccentricity)
+		apogee := orbit.OrbitSemiMajorAxis * (1 + orbit.OrbitEccentricity)
+		stateEpoch := float64(state.LastTime) / 1000
+
+		for _, debris := range debrisList {
+			// 地心距范围相差超过低威胁阈值时不可能接近
+			r := EARTH_RADIUS_KM + debris.Height
+			if r < perigee-screenConf.LowThresholdKm || r > apogee+screenConf.LowThresholdKm {
+				continue
+			}
+
+			debrisEpoch := float64(debris.LastTime) / 1000
+			tca, missDistance := closestApproach(func(t float64) float64 {
+				return orbitPosition(orbit, state.MeanAnomaly, t-stateEpoch).
+					distance(debrisPosition(debris, t-debrisEpoch))
+			}, start, end, step)
+
+			treaten := s.ThreatOfMissDistance(missDistance)
+			if treaten == db.NO {
+				continue
+			}
+
+			conjunctions = append(conjunctions, &db.Conjunction{
+				SatelliteId:    satellite.SatelliteId,
+				SatelliteName:  satellite.SatelliteName,
+				DebrisId:       debris.DebrisId,
+				DebrisName:     debris.DebrisName,
+				Tca:            int64(math.Round(tca)),
+				MissDistanceKm: missDistance,
+				Treaten:        treaten,
+				ScreenedAt:     now.UnixMilli(),
+			})
+		}
+	}
+
+	err = s.gormDb.Transaction(func(tx *gorm.DB) error {
+		return saveConjunctions(tx, conjunctions, now.UnixMilli())
+	})
+	if err != nil {
+		s.sulog.Infof("save conjunctions failed, err:[%s]\n", err.Error())
+		return nil, err
+	}
+
+	s.generateAvoidanceInstructions(conjunctions)
+
+	return conjunctions, nil
+}
+
+// unfinishedExecStates 还未结束的指令，其威胁程度随筛查结果更新
+var unfinishedExecStates = []db.InstructionExecState{
+	db.WAITAPPROVAL, db.NOTEXEC, db.INEXEC, db.EXECFAIL,
+}
+
+// saveConjunctions the conjunction of the same satellite and debris is updated in place,
+// the ones not found in this screening are deleted
+func saveConjunctions(tx *gorm.DB, conjunctions []*db.Conjunction, screenedAt int64) error {
+	for _, conjunction := range conjunctions {
+		existing := new(db.Conjunction)
+		err := tx.Where("satellite_id = ? AND debris_id = ?",
+			conjunction.SatelliteId, conjunction.DebrisId).First(existing).Error
+		if err == nil {
+			conjunction.Id = existing.Id
+			conjunction.LastTime = existing.LastTime
+			err = tx.Save(conjunction).Error
+		} else if err == gorm.ErrRecordNotFound {
+			err = tx.Create(conjunction).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Where("screened_at <> ?", screenedAt).Delete(&db.Conjunction{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&db.Instruction{}).Where("exec_state IN ?", unfinishedExecStates).
+		Update("treaten", db.NO).Error
+	if err != nil {
+		return err
+	}
+
+	for _, conjunction := range conjunctions {
+		err = tx.Model(&db.Instruction{}).
+			Where("satellite_id = ? AND debris_id = ? AND exec_state IN ?",
+				conjunction.SatelliteId, conjunction.DebrisId, unfinishedExecStates).
+			Update("treaten", conjunction.Treaten).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConjunctionThreat the threat of the latest screening, NO if they do not approach
+func (s *Server) ConjunctionThreat(satelliteId, debrisId string) db.ThreatDegree {
+	conjunction := new(db.Conjunction)
+	err := s.gormDb.Where("satellite_id = ? AND debris_id = ?", satelliteId, debrisId).
+		First(conjunction).Error
+	if err != nil {
+		return db.NO
+	}
+	return conjunction.Treaten
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"go-web-demo/src/configs"
+	"go-web-demo/src/db"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newScreeningTestServer(t *testing.T) *Server {
+	s := newSqliteTestServer(t)
+	s.config = &configs.Config{ScreenConf: &configs.ScreeningConfig{
+		LookAhead:       3 * time.Hour,
+		Step:            30 * time.Second,
+		HighThresholdKm: 1,
+		LowThresholdKm:  5,
+	}}
+	return s
+}
+
+func TestClosestApproach(t *testing.T) {
+	// 同高度的赤道轨道和极地碎片同时过升交点，每半圈在交点相遇
+	orbit := &db.Orbit{OrbitSemiMajorAxis: EARTH_RADIUS_KM + 500}
+	debris := &db.Debris{Height: 500, Angle: 90}
+
+	distance := func(t float64) float64 {
+		return orbitPosition(orbit, 0, t).distance(debrisPosition(debris, t))
+	}
+
+	tca, missDistance := closestApproach(distance, 100, 3*3600, 30)
+	require.Less(t, missDistance, 0.01)
+	require.Greater(t, tca, 100.0)
+
+	debris.Height = 600
+	_, missDistance = closestApproach(distance, 100, 3*3600, 30)
+	require.Greater(t, missDistance, 99.0)
+}
+
+func TestClosestApproachBetweenSamples(t *testing.T) {
+	// 相对速度 12 km/s 的两次接近：第一次真正的最近点落在两个采样点中间，
+	// 采样点距离约 180 km；第二次最近 50 km，恰好落在采样点上
+	pass := func(t, tca, missDistance float64) float64 {
+		return math.Hypot(missDistance, 12*(t-tca))
+	}
+	distance := func(t float64) float64 {
+		return math.Min(pass(t, 1005, 0.2), pass(t, 2000, 50))
+	}
+
+	tca, missDistance := closestApproach(distance, 0, 3000, 30)
+	require.InDelta(t, 0.2, missDistance, 1e-3)
+	require.InDelta(t, 1005, tca, 0.1)
+}
+
+// seedConjunction satellite s1 and debris d1 meet at the nodes, debris d2 is 100 km higher
+func seedConjunction(t *testing.T, s *Server, constellationId string) {
+	require.Nil(t, s.InsertOneObjertToDB(&db.Orbit{
+		OrbitId:            "o1",
+		OrbitSemiMajorAxis: EARTH_RADIUS_KM + 500,
+	}))
+	require.Nil(t, s.InsertOneObjertToDB(&db.SatelliteCatalog{
+		SatelliteId:     "s1",
+		SatelliteName:   "sat1",
+		OrbitId:         "o1",
+		ConstellationId: constellationId,
+		Status:          db.SATELLITE_IN_ORBIT,
+	}))
+
+	epoch := time.Now().UnixMilli()
+	require.Nil(t, s.InsertOneObjertToDB(&db.Satellite{
+		GeneralField: db.GeneralField{LastTime: epoch},
+		SatelliteId:  "s1",
+		OrbitId:      "o1",
+	}))
+	require.Nil(t, s.InsertOneObjertToDB(&db.Debris{
+		GeneralField: db.GeneralField{LastTime: epoch},
+		DebrisId:     "d1",
+		DebrisName:   "debris1",
+		Height:       500,
+		Angle:        90,
+		Type:         db.SMALL,
+	}))
+	require.Nil(t, s.InsertOneObjertToDB(&db.Debris{
+		GeneralField: db.GeneralField{LastTime: epoch},
+		DebrisId:     "d2",
+		DebrisName:   "debris2",
+		Height:       600,
+		Angle:        90,
+		Type:         db.SMALL,
+	}))
+}
+
+func TestScreenConjunctionsSqlite(t *testing.T) {
+	s := newScreeningTestServer(t)
+
+	seedConjunction(t, s, "")
+
+	instruction := &db.Instruction{
+		InstructionId: "i1",
+		Type:          db.OPERATION,
+		SatelliteId:   "s1",
+		DebrisId:      "d1",
+		Treaten:       db.NO,
+		ExecState:     db.NOTEXEC,
+	}
+	require.Nil(t, s.InsertOneObjertToDB(instruction))
+
+	conjunctions, err := s.ScreenConjunctions()
+	require.Nil(t, err)
+	require.Len(t, conjunctions, 1)
+	require.Equal(t, "d1", conjunctions[0].DebrisId)
+	require.Equal(t, db.HIGH, conjunctions[0].Treaten)
+	require.Equal(t, db.HIGH, s.ConjunctionThreat("s1", "d1"))
+	require.Equal(t, db.NO, s.ConjunctionThreat("s1", "d2"))
+
+	got := new(db.Instruction)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i1"))
+	require.Equal(t, db.HIGH, got.Treaten)
+
+	// 碎片升高后不再接近，上次的结果被删除
+	debris := new(db.Debris)
+	require.Nil(t, s.QueryObjectByCondition(debris, "debris_id", "d1"))
+	debris.Height = 600
+	require.Nil(t, s.UpdateObject(debris))
+
+	conjunctions, err = s.ScreenConjunctions()
+	require.Nil(t, err)
+	require.Len(t, conjunctions, 0)
+	require.Equal(t, db.NO, s.ConjunctionThreat("s1", "d1"))
+
+	got = new(db.Instruction)
+	require.Nil(t, s.QueryObjectByCondition(got, "instruction_id", "i1"))
+	require.Equal(t, db.NO, got.Treaten)
+}
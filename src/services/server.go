@@ -2,6 +2,7 @@ package services
 
 import (
 	"go-web-demo/src/configs"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -14,6 +15,14 @@ type Server struct {
 	log       *zap.Logger
 	sulog     *zap.SugaredLogger
 	gormDb    *gorm.DB
+	keyStore  *KeyStore
+	permCache *permissionCache
+	mail      MailSender
+	executor  InstructionExecutor
+	execWake  chan struct{}
+
+	screenMu   sync.Mutex
+	screenWake chan struct{}
 }
 
 type Option func(s *Server)
@@ -49,8 +58,32 @@ func WithGormDb(db *gorm.DB) Option {
 	}
 }
 
+func WithKeyStore(ks *KeyStore) Option {
+	return func(s *Server) {
+		s.keyStore = ks
+	}
+}
+
+func WithMailSender(mail MailSender) Option {
+	return func(s *Server) {
+		s.mail = mail
+	}
+}
+
+// WithInstructionExecutor the simulated executor is used if not set
+func WithInstructionExecutor(executor InstructionExecutor) Option {
+	return func(s *Server) {
+		s.executor = executor
+	}
+}
+
 func NewServer(opts ...Option) (*Server, error) {
-	server := new(Server)
+	server := &Server{
+		permCache: newPermissionCache(),
+		execWake:  make(chan struct{}, 1),
+
+		screenWake: make(chan struct{}, 1),
+	}
 	for _, opt := range opts {
 		opt(server)
 	}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+	"time"
+)
+
+// 会话最近活动时间的更新间隔，避免每个请求都写库
+const SESSION_ACTIVE_INTERVAL = time.Minute
+
+// StartSession create the login session of the user and issue its first tokens
+func (s *Server) StartSession(user *db.User, ip, userAgent string) (*TokenPair, error) {
+	sessionId, err := genRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.InsertOneObjertToDB(&db.UserSession{
+		UserId:       user.Id,
+		SessionId:    sessionId,
+		LoginIp:      ip,
+		UserAgent:    userAgent,
+		LoginTime:    now.Unix(),
+		LastActiveAt: now.Unix(),
+		ExpiresAt:    now.Add(s.config.TokenConfig.RefreshTokenExpire).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.IssueTokens(user, sessionId)
+}
+
+// ListUserSessions the sessions of the user that are neither terminated nor expired
+func (s *Server) ListUserSessions(userId int32) ([]*db.UserSession, error) {
+	sessions := make([]*db.UserSession, 0)
+	err := s.gormDb.Where("user_id = ? AND revoked_at = 0 AND expires_at > ?",
+		userId, time.Now().Unix()).
+		Order("last_active_at desc").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TerminateSession revoke the session of the user, the session of other users
+// can not be terminated by the id
+func (s *Server) TerminateSession(userId int32, sessionId string) error {
+	session := new(db.UserSession)
+	err := s.gormDb.Where("user_id = ? AND session_id = ? AND revoked_at = 0",
+		userId, sessionId).First(session).Error
+	if err != nil {
+		return errors.New("the session not found or has been terminated")
+	}
+
+	return s.RevokeSession(sessionId)
+}
+
+// TerminateOtherSessions revoke all the sessions of the user except the current one,
+// all the sessions are revoked when the current one is empty
+func (s *Server) TerminateOtherSessions(userId int32, currentSessionId string) error {
+	sessions, err := s.ListUserSessions(userId)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.SessionId == currentSessionId {
+			continue
+		}
+
+		err = s.RevokeSession(session.SessionId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSession the session of the token must be active, the last active time is
+// refreshed at most once per SESSION_ACTIVE_INTERVAL
+func (s *Server) checkSession(sessionId string) error {
+	session := new(db.UserSession)
+	err := s.gormDb.Where("session_id = ?", sessionId).First(session).Error
+	if err != nil {
+		return errors.New("the session not found")
+	}
+
+	if session.RevokedAt != 0 {
+		return errors.New("the session has been terminated")
+	}
+
+	now := time.Now()
+	if session.LastActiveAt < now.Add(-SESSION_ACTIVE_INTERVAL).Unix() {
+		err = s.gormDb.Model(&db.UserSession{}).Where("id = ?", session.Id).
+			Update("last_active_at", now.Unix()).Error
+		if err != nil {
+			s.sulog.Infof("update session last active failed, err: [%s], session: [%s]\n",
+				err.Error(), sessionId)
+		}
+	}
+	return nil
+}
@@ -2,24 +2,60 @@ package services
 
 import (
 	"errors"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v4"
 )
 
-// DefaultTokenSecretKey default token secret key
-const DEFAULT_TOKEN_SECRET_KEY = "1045836262777123654"
+const TOKEN_HEADER_KID = "kid"
+
+// token 用途，普通的访问 token 为空
+const (
+	// TOKEN_PURPOSE_2FA 密码验证通过后等待输入动态码
+	TOKEN_PURPOSE_2FA = "2fa"
+
+	// TOKEN_PURPOSE_TOTP_ENROLL 必须启用两步验证的用户，只能访问绑定接口
+	TOKEN_PURPOSE_TOTP_ENROLL = "totp_enroll"
+
+	// TOKEN_PURPOSE_API_KEY 由 API Key 认证得到，不是签发的 token
+	TOKEN_PURPOSE_API_KEY = "api_key"
+)
 
 type MyClaims struct {
 	Id   int32
 	Role string
 	Name string
+	// Ver must equal the user's token version, otherwise the token is invalid
+	Ver int32
+	// Sid is the login session the token belongs to
+	Sid string
+	// Purpose limits where the token can be used, empty for the access token
+	Purpose string `json:",omitempty"`
+	// ApiKeyId and Scopes are only set when authenticated by the api key
+	ApiKeyId int32    `json:"-"`
+	Scopes   []string `json:"-"`
 	jwt.StandardClaims
 }
 
 func (s *Server) ParseToken(token string) (*MyClaims, error) {
 
 	t, err := jwt.ParseWithClaims(token, &MyClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(DEFAULT_TOKEN_SECRET_KEY), nil
+		kid, ok := t.Header[TOKEN_HEADER_KID].(string)
+		if !ok {
+			return nil, errors.New("the token kid not found")
+		}
+
+		key, ok := s.keyStore.GetKey(kid)
+		if !ok {
+			return nil, errors.New("the token kid is unknown")
+		}
+
+		// 签名算法必须与密钥一致，防止算法混淆攻击
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("the token algorithm does not match the key")
+		}
+
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
@@ -30,27 +66,44 @@ func (s *Server) ParseToken(token string) (*MyClaims, error) {
 		return nil, errors.New("unknown error")
 	}
 
-	if claims, ok := t.Claims.(*MyClaims); ok && t.Valid {
-		return claims, nil
+	claims, ok := t.Claims.(*MyClaims)
+	if !ok || !t.Valid {
+		return nil, errors.New("invalid token")
 	}
-	return nil, errors.New("invalid token")
+
+	if !claims.VerifyIssuer(s.config.TokenConfig.Issuer, true) {
+		return nil, errors.New("invalid token issuer")
+	}
+
+	return claims, nil
 }
 
-func (s *Server) GenToken(id int32, name, role string, expiresAt int64) (string, error) {
+// GenToken sign the claims with the active key, the jti, issuer and issue time
+// are filled in here
+func (s *Server) GenToken(claims *MyClaims) (string, error) {
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, MyClaims{
-		Id:   id,
-		Role: role,
-		Name: name,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expiresAt,
-		},
-	})
+	key := s.keyStore.ActiveKey()
+
+	jti, err := genRandomString(16)
+	if err != nil {
+		return "", err
+	}
+	claims.StandardClaims.Id = jti
+	claims.StandardClaims.IssuedAt = time.Now().Unix()
+	claims.StandardClaims.Issuer = s.config.TokenConfig.Issuer
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header[TOKEN_HEADER_KID] = key.Kid
 
-	t, err := token.SignedString([]byte(DEFAULT_TOKEN_SECRET_KEY))
+	t, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		s.sulog.Infof("signed token failed, err: [%s]\n", err.Error())
 		return t, err
 	}
 	return t, nil
 }
+
+// GetSigningKeys all the keys accepted when verifying tokens
+func (s *Server) GetSigningKeys() []*SigningKey {
+	return s.keyStore.Keys()
+}
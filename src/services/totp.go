@@ -0,0 +1,287 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go-web-demo/src/db"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// RFC 6238 的参数，与常见的认证器应用保持一致
+const (
+	TOTP_PERIOD = 30
+
+	TOTP_DIGITS = 6
+
+	TOTP_SECRET_LEN = 20
+
+	RECOVERY_CODE_NUM = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// IsTotpRequired whether the user holds any of the roles that must enable 2FA
+func (s *Server) IsTotpRequired(userId int32) (bool, error) {
+	requiredRoles := s.config.TotpConfig.RequiredRoles
+	if len(requiredRoles) == 0 {
+		return false, nil
+	}
+
+	roles, _, err := s.GetUserPermissions(userId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range roles {
+		for _, required := range requiredRoles {
+			if role == required {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// IssueChallengeToken sign a short-lived token for the purpose, such as the
+// second step of login or the 2FA enrollment
+func (s *Server) IssueChallengeToken(user *db.User, purpose string) (string, int64, error) {
+	expire := s.config.TotpConfig.ChallengeExpire
+	if purpose == TOKEN_PURPOSE_TOTP_ENROLL {
+		expire = s.config.TokenConfig.AccessTokenExpire
+	}
+	expiresAt := time.Now().Add(expire).Unix()
+
+	token, err := s.GenToken(&MyClaims{
+		Id:      user.Id,
+		Name:    user.UserName,
+		Role:    db.UserRoleTypeName[user.UserRole],
+		Ver:     user.TokenVersion,
+		Purpose: purpose,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: expiresAt,
+		},
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return token, expiresAt, nil
+}
+
+// SetupTotp generate a new secret for the user, it takes effect after EnableTotp
+func (s *Server) SetupTotp(user *db.User) (string, string, error) {
+	if user.TotpEnabled {
+		return "", "", errors.New("2FA has been enabled")
+	}
+
+	b := make([]byte, TOTP_SECRET_LEN)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := totpEncoding.EncodeToString(b)
+
+	err := s.gormDb.Model(&db.User{}).Where("id = ?", user.Id).
+		Update("totp_secret", secret).Error
+	if err != nil {
+		s.sulog.Infof("setup totp failed, err: [%s], user: [%s]\n",
+			err.Error(), user.UserName)
+		return "", "", err
+	}
+
+	return secret, s.totpProvisioningUri(secret, user.UserName), nil
+}
+
+// EnableTotp verify the first code of the new secret and return the recovery codes
+func (s *Server) EnableTotp(user *db.User, code string) ([]string, error) {
+	if user.TotpEnabled {
+		return nil, errors.New("2FA has been enabled")
+	}
+
+	if len(user.TotpSecret) == 0 {
+		return nil, errors.New("2FA has not been set up")
+	}
+
+	counter, ok := verifyTotp(user.TotpSecret, code, time.Now(),
+		s.config.TotpConfig.Skew, 0)
+	if !ok {
+		return nil, errors.New("the code is invalid")
+	}
+
+	err := s.gormDb.Model(&db.User{}).Where("id = ?", user.Id).
+		Updates(map[string]interface{}{
+			"totp_enabled":      true,
+			"totp_last_counter": counter,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GenRecoveryCodes(user.Id)
+}
+
+// DisableTotp clear the secret and the recovery codes of the user
+func (s *Server) DisableTotp(userId int32) error {
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&db.User{}).Where("id = ?", userId).
+			Updates(map[string]interface{}{
+				"totp_secret":       "",
+				"totp_enabled":      false,
+				"totp_last_counter": 0,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userId).Delete(&db.RecoveryCode{}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("disable totp failed, err: [%s], user: [%d]\n",
+			err.Error(), userId)
+		return err
+	}
+	return nil
+}
+
+// GenRecoveryCodes replace all the recovery codes of the user
+func (s *Server) GenRecoveryCodes(userId int32) ([]string, error) {
+	codes := make([]string, 0, RECOVERY_CODE_NUM)
+	records := make([]*db.RecoveryCode, 0, RECOVERY_CODE_NUM)
+	for i := 0; i < RECOVERY_CODE_NUM; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))
+		code = code[:4] + "-" + code[4:]
+
+		codes = append(codes, code)
+		records = append(records, &db.RecoveryCode{
+			UserId:   userId,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&db.RecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		s.sulog.Infof("generate recovery codes failed, err: [%s], user: [%d]\n",
+			err.Error(), userId)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifySecondFactor accept a TOTP code or an unused recovery code,
+// a code can not be used twice
+func (s *Server) VerifySecondFactor(user *db.User, code string) error {
+	if !user.TotpEnabled {
+		return errors.New("2FA has not been enabled")
+	}
+
+	counter, ok := verifyTotp(user.TotpSecret, code, time.Now(),
+		s.config.TotpConfig.Skew, user.TotpLastCounter)
+	if ok {
+		// 条件更新，并发请求中同一动态码只有一个能通过
+		result := s.gormDb.Model(&db.User{}).
+			Where("id = ? AND totp_last_counter < ?", user.Id, counter).
+			Update("totp_last_counter", counter)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("the code has been used")
+		}
+		return nil
+	}
+
+	result := s.gormDb.Model(&db.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at = 0",
+			user.Id, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now().Unix())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("the code is invalid")
+	}
+
+	s.sulog.Infof("recovery code used, user: [%s]\n", user.UserName)
+	return nil
+}
+
+func (s *Server) totpProvisioningUri(secret, userName string) string {
+	issuer := s.config.TotpConfig.Issuer
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTP_DIGITS))
+	params.Set("period", fmt.Sprint(TOTP_PERIOD))
+
+	label := url.PathEscape(issuer + ":" + userName)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpCode the HOTP value of the counter, RFC 4226
+func totpCode(key []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// verifyTotp return the matched time step, which must be greater than lastCounter
+func verifyTotp(secret, code string, t time.Time, skew int, lastCounter int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTP_DIGITS {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := t.Unix() / TOTP_PERIOD
+	for i := -skew; i <= skew; i++ {
+		counter := current + int64(i)
+		if counter <= lastCounter {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(key, uint64(counter), TOTP_DIGITS)), []byte(code)) {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(code)), "-", "")
+}
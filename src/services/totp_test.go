@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTotpCode(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA1 测试向量
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for ts, code := range vectors {
+		require.Equal(t, code, totpCode(key, uint64(ts/TOTP_PERIOD), 8))
+	}
+}
+
+func TestVerifyTotp(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+	counter := now.Unix() / TOTP_PERIOD
+	key := []byte("12345678901234567890")
+
+	current := totpCode(key, uint64(counter), TOTP_DIGITS)
+	matched, ok := verifyTotp(secret, current, now, 1, 0)
+	require.True(t, ok)
+	require.Equal(t, counter, matched)
+
+	// 上一个时间步在允许的偏差内
+	previous := totpCode(key, uint64(counter-1), TOTP_DIGITS)
+	_, ok = verifyTotp(secret, previous, now, 1, 0)
+	require.True(t, ok)
+
+	_, ok = verifyTotp(secret, previous, now, 0, 0)
+	require.False(t, ok)
+
+	// 已使用过的时间步不能再次通过
+	_, ok = verifyTotp(secret, current, now, 1, counter)
+	require.False(t, ok)
+
+	_, ok = verifyTotp(secret, "000000x", now, 1, 0)
+	require.False(t, ok)
+}
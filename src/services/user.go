@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"go-web-demo/src/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 邀请码展示用的前缀长度
+const INVITE_CODE_PREFIX_LEN = 6
+
+// InitAdmin create the first administrator from the config when there is
+// no administrator, an empty password is replaced by a random one which is
+// printed to the log only once
+func (s *Server) InitAdmin() error {
+	userConf := s.config.UserConfig
+	if len(userConf.InitAdminName) == 0 {
+		return nil
+	}
+
+	var count int64
+	err := s.gormDb.Model(&db.User{}).Where("user_role = ?", db.ADMIN).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return nil
+	}
+
+	err = s.QueryObjectByCondition(new(db.User), "user_name", userConf.InitAdminName)
+	if err == nil {
+		s.sulog.Warnf("the init admin [%s] already exists as another role, skip it\n",
+			userConf.InitAdminName)
+		return nil
+	}
+
+	pwd := userConf.InitAdminPwd
+	if len(pwd) == 0 {
+		pwd, err = genRandomString(12)
+		if err != nil {
+			return err
+		}
+		s.sulog.Warnf("the init admin [%s] is created with password [%s], change it after login\n",
+			userConf.InitAdminName, pwd)
+	}
+
+	hashedPwd, err := s.HashPassword(pwd)
+	if err != nil {
+		return err
+	}
+
+	return s.RegisterUser(&db.User{
+		UserName:     userConf.InitAdminName,
+		UserRole:     db.ADMIN,
+		UserPwd:      hashedPwd,
+		UserNickName: userConf.InitAdminName,
+		UserState:    db.USER_ENABLED,
+	}, nil)
+}
+
+// GetRegisterMode the self registration mode in the config
+func (s *Server) GetRegisterMode() string {
+	return s.config.UserConfig.RegisterMode
+}
+
+// CreateInviteCode generate an invite code for the role, only the hash is saved
+func (s *Server) CreateInviteCode(role db.UserRoleType, createdBy string) (string, *db.InviteCode, error) {
+	code, err := genRandomString(12)
+	if err != nil {
+		return "", nil, err
+	}
+
+	inviteCode := &db.InviteCode{
+		CodeHash:   hashToken(code),
+		CodePrefix: code[:INVITE_CODE_PREFIX_LEN],
+		UserRole:   role,
+		CreatedBy:  createdBy,
+		ExpiresAt:  time.Now().Add(s.config.UserConfig.InviteCodeExpire).Unix(),
+	}
+
+	err = s.InsertOneObjertToDB(inviteCode)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return code, inviteCode, nil
+}
+
+// QueryInviteCode find an unused and unexpired invite code
+func (s *Server) QueryInviteCode(code string) (*db.InviteCode, error) {
+	inviteCode := new(db.InviteCode)
+	err := s.gormDb.Where("code_hash = ? AND used_by = '' AND expires_at > ?",
+		hashToken(code), time.Now().Unix()).First(inviteCode).Error
+	if err != nil {
+		return nil, errors.New("the invite code is invalid or expired")
+	}
+	return inviteCode, nil
+}
+
+// RegisterUser insert the user and bind it to the built-in role of its UserRole,
+// the invite code is consumed in the same transaction so that one code can only
+// register one user
+func (s *Server) RegisterUser(user *db.User, inviteCode *db.InviteCode) error {
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		if inviteCode != nil {
+			result := tx.Model(&db.InviteCode{}).
+				Where("id = ? AND used_by = ''", inviteCode.Id).
+				Updates(map[string]interface{}{
+					"used_by":   user.UserName,
+					"used_time": time.Now().Unix(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("the invite code has been used")
+			}
+		}
+
+		err := tx.Create(user).Error
+		if err != nil {
+			return err
+		}
+
+		return bindUserRoles(tx, user.Id, []string{db.UserRoleTypeName[user.UserRole]})
+	})
+	if err != nil {
+		s.sulog.Infof("register user failed, err: [%s], user: [%s]\n",
+			err.Error(), user.UserName)
+		return err
+	}
+	return nil
+}
+
+// DeleteUser delete the user with its refresh tokens, role bindings and api keys
+func (s *Server) DeleteUser(userId int32) error {
+	err := s.gormDb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&db.RefreshToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", userId).Delete(&db.UserSession{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", userId).Delete(&db.UserRoleBinding{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", userId).Delete(&db.ApiKey{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", userId).Delete(&db.User{}).Error
+	})
+	if err != nil {
+		s.sulog.Infof("delete user failed, err: [%s], user: [%d]\n",
+			err.Error(), userId)
+		return err
+	}
+
+	s.permCache.invalidate(userId)
+	return nil
+}